@@ -1,32 +1,52 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Bar3D",
+		title:       "3D Bar Chart",
+		description: "Three-dimensional bar visualization",
+		preview:     "Bar3D",
+		roles: []Role{
+			{Name: "X Axis", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "Y Axis", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "Z Axis", Description: "Bar heights", Type: NumericValue, Min: 1, Max: 1},
+		},
+		render: renderBar3DChart,
+	})
+}
+
 // GenerateBar3DChart creates a Bar3D chart from the given data
+// and writes it to a file, returning the file path
 func GenerateBar3DChart(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 3 {
-		return "", fmt.Errorf("bar3D chart requires at least 3 columns: X, Y, Z")
+	return GenerateGraph(data, "Bar3D")
+}
+
+// renderBar3DChart writes the Bar3D chart as HTML to w
+func renderBar3DChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "Bar3D chart"}
 	}
 
+	xIndex, yIndex, zIndex := cols.index("X Axis"), cols.index("Y Axis"), cols.index("Z Axis")
+
 	// Extract data points
 	points := []opts.Chart3DData{}
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 3 {
-			continue // Skip rows with insufficient columns
-		}
+	xCol, yCol, zCol := data.Columns[xIndex], data.Columns[yIndex], data.Columns[zIndex]
+	for i := 0; i < data.Len(); i++ {
+		x, okX := xCol.Float(i)
+		y, okY := yCol.Float(i)
+		z, okZ := zCol.Float(i)
 
-		x, errX := parseNumericValue(row[0])
-		y, errY := parseNumericValue(row[1])
-		z, errZ := parseNumericValue(row[2])
-
-		if errX != nil || errY != nil || errZ != nil {
-			fmt.Printf("Skipping invalid row %d: %v, %v, %v\n", i+1, errX, errY, errZ)
+		if !okX || !okY || !okZ {
+			spec.Diagnostics.skip(data, i, firstInvalid(i, xCol, yCol, zCol), NumericValue)
 			continue
 		}
 
@@ -36,36 +56,23 @@ func GenerateBar3DChart(data [][]string) (string, error) {
 	}
 
 	if len(points) == 0 {
-		return "", fmt.Errorf("no valid data for Bar3D chart")
+		return &InsufficientDataError{Chart: "Bar3D chart", Rows: data.Len()}
 	}
 
 	// Create Bar3D chart
 	bar3D := charts.NewBar3D()
 	bar3D.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Bar3D Chart",
+			Title:    spec.title("Bar3D Chart"),
 			Subtitle: "",
 		}),
-		charts.WithXAxis3DOpts(opts.XAxis3D{Name: "X"}),
-		charts.WithYAxis3DOpts(opts.YAxis3D{Name: "Y"}),
-		charts.WithZAxis3DOpts(opts.ZAxis3D{Name: "Z"}),
+		charts.WithXAxis3DOpts(opts.XAxis3D{Name: xCol.Name}),
+		charts.WithYAxis3DOpts(opts.YAxis3D{Name: yCol.Name}),
+		charts.WithZAxis3DOpts(opts.ZAxis3D{Name: zCol.Name}),
 	)
 
 	// Add data to the chart
 	bar3D.AddSeries("Bar3D", points)
 
-	// Render to file
-	filePath := "bar3d_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = bar3D.Render(file)
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return bar3D.Render(w)
 }
@@ -1,86 +1,81 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
-	"strconv"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Bar",
+		title:       "Bar Chart",
+		description: "Simple bar chart for comparing categories",
+		preview:     "Bar",
+		roles: []Role{
+			{Name: "X Axis", Description: "Category labels", Min: 1, Max: 1},
+			{Name: "Y Axis", Description: "Bar heights, one series per column", Type: NumericValue, Min: 1, Max: Unlimited},
+		},
+		stackable: true,
+		render:    renderBarChart,
+	})
+}
+
 // GenerateBarChart creates an HTML bar chart from the given data
+// and writes it to a file, returning the file path
 func GenerateBarChart(data [][]string) (string, error) {
+	return GenerateGraph(data, "Bar")
+}
+
+// renderBarChart writes the Bar chart as HTML to w
+func renderBarChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
 	// Validate input data
-	if len(data) < 2 {
-		return "", fmt.Errorf("insufficient data for bar chart")
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "bar chart"}
 	}
 
-	// Extract X-axis labels and Y-axis values
-	xLabels := []string{}
-	yValues := []opts.BarData{}
+	xIndex := cols.index("X Axis")
 
-	for _, row := range data[1:] { // Skip the header row
-		if len(row) < 2 {
-			continue // Skip rows without enough columns
-		}
-
-		xLabels = append(xLabels, row[0]) // First column as X-axis label
-
-		// Parse Y-axis value (skip if invalid)
-		yValue := row[1]
-		value, err := parseNumericValue(yValue)
-		if err != nil {
-			fmt.Printf("Skipping invalid Y-axis value: %s\n", yValue)
-			continue
-		}
-		yValues = append(yValues, opts.BarData{Value: value})
+	// Extract X-axis labels and one series per Y column
+	series := extractSeries(data, xIndex, cols["Y Axis"], spec.Diagnostics)
+	if len(series.labels) == 0 {
+		return &InsufficientDataError{Chart: "bar chart", Rows: data.Len()}
 	}
 
-	if len(xLabels) == 0 || len(yValues) == 0 {
-		return "", fmt.Errorf("no valid data for bar chart")
+	yName := "Values"
+	if len(series.names) == 1 {
+		yName = series.names[0]
 	}
 
 	// Create a new bar chart
 	bar := charts.NewBar()
 	bar.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Bar Chart",
+			Title:    spec.title("Bar Chart"),
 			Subtitle: "",
 		}),
-		charts.WithXAxisOpts(opts.XAxis{
-			Name: "Categories",
-		}),
+		charts.WithLegendOpts(legendOpts(len(series.names))),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithXAxisOpts(series.xAxisOpts(data.Columns[xIndex].Name)),
 		charts.WithYAxisOpts(opts.YAxis{
-			Name: "Values",
+			Name: yName,
 		}),
 	)
-
-	// Add data to the chart
-	bar.SetXAxis(xLabels).AddSeries("Data", yValues)
-
-	// Render the chart to an HTML file
-	filePath := "bar_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
+	if series.timeAxis() {
+		bar.SetGlobalOptions(charts.WithDataZoomOpts(dataZoomOpts()...))
 	}
-	defer file.Close()
 
-	err = bar.Render(file)
-	if err != nil {
-		return "", err
+	// Add one series per value column
+	bar.SetXAxis(series.categories())
+	for j, name := range series.names {
+		yValues := make([]opts.BarData, len(series.values[j]))
+		for i := range series.values[j] {
+			yValues[i] = opts.BarData{Value: series.point(j, i)}
+		}
+		bar.AddSeries(name, yValues, charts.WithBarChartOpts(opts.BarChart{Stack: stackName(spec)}))
 	}
 
-	return filePath, nil
-}
-
-// Converts a string to a float64, handling potential errors
-func parseNumericValue(input string) (float64, error) {
-	// Attempt to parse the string as a float
-	value, err := strconv.ParseFloat(input, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid numeric value: %s", input)
-	}
-	return value, nil
+	return bar.Render(w)
 }
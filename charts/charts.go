@@ -2,77 +2,116 @@
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
-	"strconv"
+	"strings"
 
+	"graph-viewer/dataset"
 	"graph-viewer/logger"
-
-	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
-// Checks if all Y-axis values are strings
-func areAllYValuesStrings(yAxis []opts.BarData) bool {
-	for _, v := range yAxis {
-		if _, ok := v.Value.(string); !ok {
-			return false
-		}
-	}
-	return true
+// ChartSpec describes which chart to render and how
+type ChartSpec struct {
+	Type  string // graph type, e.g. "Bar" or "Pie"
+	Title string // chart title, empty for the chart's default title
+
+	// Stacked stacks the series of multi-series charts instead of grouping
+	// them side by side. Only chart types implementing StackableChart use it.
+	Stacked bool
+
+	// Columns maps role names to the dataset columns that fill them. When
+	// empty the dataset's columns are assigned to the roles in order.
+	Columns map[string][]string
+
+	// Invalid chooses what happens to values that do not fit their role.
+	// Diagnostics, when set, collects the rows skipped or changed.
+	Invalid     ValuePolicy
+	Diagnostics *Diagnostics
 }
 
-func ContainsNonNumericData(data [][]string) bool {
-	for _, row := range data[1:] { // Skip header
-		if len(row) > 1 {
-			if _, err := strconv.ParseFloat(row[1], 64); err != nil {
-				return true // Found a non-numeric value
-			}
-		}
+// title returns the configured title or the given default
+func (s ChartSpec) title(defaultTitle string) string {
+	if s.Title != "" {
+		return s.Title
 	}
-	return false
+	return defaultTitle
 }
 
-// GenerateGraph creates a graph based on the selected type
-func GenerateGraph(data [][]string, graphType string) (string, error) {
-	switch graphType {
-	case "Bar":
-		return GenerateBarChart(data)
-	case "Heatmap":
-		return GenerateHeatmap(data)
-	case "Kline":
-		return GenerateKlineChart(data)
-	case "Pie":
-		return GeneratePieChart(data)
-	case "Sankey":
-		return GenerateSankeyChart(data)
-	case "Overlap":
-		return GenerateOverlapChart(data)
-	case "Scatter3D":
-		return GenerateScatter3D(data)
-	case "Bar3D":
-		return GenerateBar3DChart(data)
-	case "ThemeRiver":
-		return GenerateThemeRiverChart(data)
-	default:
-		return "", fmt.Errorf("unsupported graph type: %s", graphType)
+// FileOutput controls where rendered charts are written
+type FileOutput struct {
+	Dir  string // directory for generated files, empty for the working directory
+	Name string // exact file name; empty to generate a unique name per chart
+}
+
+// DefaultOutput is used by GenerateGraph and the Generate* functions
+var DefaultOutput = FileOutput{}
+
+// Render writes the chart described by spec as HTML to w
+func Render(w io.Writer, spec ChartSpec, data *dataset.Dataset) error {
+	ct, ok := Lookup(spec.Type)
+	if !ok {
+		return fmt.Errorf("unsupported graph type: %s", spec.Type)
 	}
+	return ct.Render(w, spec, data)
 }
 
-func generateBarChart(data [][]string) (string, error) {
-	// Generate a bar chart (implement logic similar to your existing bar chart code)
-	filePath := "bar_chart.html"
-	file, err := os.Create(filePath)
+// RenderToFile renders the chart into a file and returns its path. Without
+// out.Name a unique name based on the graph type is used, so repeated renders
+// never overwrite each other.
+func RenderToFile(spec ChartSpec, data *dataset.Dataset, out FileOutput) (string, error) {
+	ct, ok := Lookup(spec.Type)
+	if !ok {
+		return "", fmt.Errorf("unsupported graph type: %s", spec.Type)
+	}
+	baseName := strings.ToLower(ct.Name()) + "_chart"
+
+	dir := out.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	var (
+		file *os.File
+		err  error
+	)
+	if out.Name != "" {
+		file, err = os.Create(filepath.Join(dir, out.Name))
+	} else {
+		file, err = os.CreateTemp(dir, baseName+"_*.html")
+	}
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
 
-	// Add bar chart rendering logic here...
+	filePath := file.Name()
+	if err := Render(file, spec, data); err != nil {
+		file.Close()
+		os.Remove(filePath)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
 
 	return filePath, nil
 }
 
+// GenerateGraph creates a graph based on the selected type and returns the file path.
+// The first record holds the column names, which are assigned to the roles in order.
+func GenerateGraph(data [][]string, graphType string) (string, error) {
+	ds, err := dataset.FromRecords(data)
+	if err != nil {
+		return "", fmt.Errorf("insufficient data for %s chart: %w", graphType, err)
+	}
+	return RenderToFile(ChartSpec{Type: graphType}, ds, DefaultOutput)
+}
+
 // Opens the chart.html file in the default browser
 func ShowChartInBrowser(filePath string) error {
 	logger.LogWithTrace(fmt.Sprintf("Attempting to open chart at: %s", filePath))
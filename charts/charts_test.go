@@ -0,0 +1,349 @@
+﻿package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"graph-viewer/dataset"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var sampleBarData = mustDataset([][]string{
+	{"Region", "Revenue"},
+	{"North", "10"},
+	{"South", "20.5"},
+})
+
+func mustDataset(records [][]string) *dataset.Dataset {
+	data, err := dataset.FromRecords(records)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestRenderWritesHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, ChartSpec{Type: "Bar", Title: "Revenue by Region"}, sampleBarData); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	html := buf.String()
+	if !strings.Contains(html, "<html") || !strings.Contains(html, "Revenue by Region") {
+		t.Errorf("rendered output does not look like the requested chart")
+	}
+}
+
+func TestRenderUnknownType(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, ChartSpec{Type: "Unknown"}, sampleBarData); err == nil {
+		t.Errorf("expected an error for an unsupported graph type")
+	}
+}
+
+func TestRenderToFileUsesUniqueNames(t *testing.T) {
+	out := FileOutput{Dir: t.TempDir()}
+
+	first, err := RenderToFile(ChartSpec{Type: "Bar"}, sampleBarData, out)
+	if err != nil {
+		t.Fatalf("first render failed: %v", err)
+	}
+	second, err := RenderToFile(ChartSpec{Type: "Bar"}, sampleBarData, out)
+	if err != nil {
+		t.Fatalf("second render failed: %v", err)
+	}
+
+	if first == second {
+		t.Fatalf("both renders were written to %s", first)
+	}
+	for _, path := range []string{first, second} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected chart file %s: %v", path, err)
+		}
+	}
+}
+
+func TestRenderToFileRemovesFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	_, err := RenderToFile(ChartSpec{Type: "Bar"}, mustDataset([][]string{{"A", "B"}}), FileOutput{Dir: dir, Name: "chart.html"})
+	if err == nil {
+		t.Fatalf("expected an error for an empty dataset")
+	}
+
+	if _, statErr := os.Stat(filepath.Join(dir, "chart.html")); !os.IsNotExist(statErr) {
+		t.Errorf("failed render left a file behind")
+	}
+}
+
+// sampleDataFor builds a small dataset with one column per role of the chart type
+func sampleDataFor(ct ChartType) *dataset.Dataset {
+	var headers []string
+	for _, role := range ct.Roles() {
+		headers = append(headers, role.Name)
+	}
+	records := [][]string{headers}
+	for i := 1; i <= 3; i++ {
+		row := make([]string, len(ct.Roles()))
+		for j, role := range ct.Roles() {
+			switch role.Type {
+			case NumericValue:
+				row[j] = strconv.Itoa(i * (j + 1))
+			case TimeValue:
+				row[j] = fmt.Sprintf("2024-01-%02d", i)
+			default:
+				row[j] = fmt.Sprintf("%s %d", role.Name, i)
+			}
+		}
+		records = append(records, row)
+	}
+	return mustDataset(records)
+}
+
+func TestRegisteredChartTypesRender(t *testing.T) {
+	types := Types()
+	if len(types) == 0 {
+		t.Fatal("no chart types registered")
+	}
+
+	for _, ct := range types {
+		t.Run(ct.Name(), func(t *testing.T) {
+			if ct.Title() == "" || ct.Description() == "" || len(ct.Roles()) == 0 {
+				t.Errorf("chart type %s is missing metadata", ct.Name())
+			}
+
+			var buf bytes.Buffer
+			if err := Render(&buf, ChartSpec{Type: ct.Name()}, sampleDataFor(ct)); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if buf.Len() == 0 {
+				t.Errorf("Render produced no output")
+			}
+		})
+	}
+}
+
+func TestLookupIgnoresCase(t *testing.T) {
+	ct, ok := Lookup("themeriver")
+	if !ok || ct.Name() != "ThemeRiver" {
+		t.Errorf("Lookup(themeriver) = %v, %v", ct, ok)
+	}
+}
+
+func TestAssignColumns(t *testing.T) {
+	heatmap, _ := Lookup("Heatmap")
+
+	columns, err := AssignColumns(heatmap, []string{"Name", "Jan", "Feb"})
+	if err != nil {
+		t.Fatalf("AssignColumns failed: %v", err)
+	}
+	if got := columns["Row Label"]; len(got) != 1 || got[0] != "Name" {
+		t.Errorf("Row Label = %v, want [Name]", got)
+	}
+	if got := columns["Values"]; len(got) != 2 {
+		t.Errorf("Values = %v, want [Jan Feb]", got)
+	}
+
+	sankey, _ := Lookup("Sankey")
+	if _, err := AssignColumns(sankey, []string{"From", "To"}); err == nil {
+		t.Errorf("expected an error when Sankey gets too few columns")
+	}
+	if _, err := AssignColumns(sankey, []string{"From", "To", "Amount", "Extra"}); err == nil {
+		t.Errorf("expected an error when Sankey gets too many columns")
+	}
+}
+
+func TestRenderHeatmapLabelsRowsByText(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		want    string
+	}{
+		// Numbers are all values, as GenerateHeatmap has always drawn them
+		{"numbers", [][]string{{"Id", "Jan", "Feb"}, {"1", "3", "4"}, {"2", "5", "6"}},
+			`"data":["Id","Jan","Feb"]}],"yAxis":[{"name":"Rows","type":"category","data":["2","3"]}]`},
+		{"text", [][]string{{"Name", "Jan", "Feb"}, {"North", "3", "4"}, {"South", "5", "6"}},
+			`"data":["Jan","Feb"]}],"yAxis":[{"name":"Rows","type":"category","data":["North","South"]}]`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Render(&buf, ChartSpec{Type: "Heatmap"}, mustDataset(tt.records)); err != nil {
+			t.Fatalf("%s: Render failed: %v", tt.name, err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%s: rendered heatmap is missing %s", tt.name, tt.want)
+		}
+	}
+}
+
+func TestRenderWithNamedRoles(t *testing.T) {
+	data := mustDataset([][]string{
+		{"Amount", "To", "From"},
+		{"5", "B", "A"},
+		{"3", "C", "B"},
+	})
+	spec := ChartSpec{Type: "Sankey", Columns: map[string][]string{
+		"Source": {"From"},
+		"Target": {"To"},
+		"Value":  {"Amount"},
+	}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, spec, data); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	spec.Columns["Value"] = nil
+	if err := Render(&buf, spec, data); err == nil {
+		t.Errorf("expected an error when a required role is empty")
+	}
+}
+
+func TestRenderMultipleSeries(t *testing.T) {
+	data := mustDataset([][]string{
+		{"Month", "Revenue", "Cost"},
+		{"Jan", "10", "4"},
+		{"Feb", "12", "5"},
+	})
+	spec := ChartSpec{Type: "Bar", Stacked: true, Columns: map[string][]string{
+		"X Axis": {"Month"},
+		"Y Axis": {"Revenue", "Cost"},
+	}}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, spec, data); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	html := buf.String()
+	for _, want := range []string{`"name":"Revenue"`, `"name":"Cost"`, `"stack":"total"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("rendered chart is missing %s", want)
+		}
+	}
+}
+
+func TestAssignColumnsFillsOptionalRolesFirst(t *testing.T) {
+	themeRiver, _ := Lookup("ThemeRiver")
+
+	columns, err := AssignColumns(themeRiver, []string{"Date", "Amount", "Team"})
+	if err != nil {
+		t.Fatalf("AssignColumns failed: %v", err)
+	}
+	if got := columns["Category"]; len(got) != 1 || got[0] != "Team" {
+		t.Errorf("Category = %v, want [Team]", got)
+	}
+}
+
+func TestRenderRejectsTextInNumericRole(t *testing.T) {
+	data := mustDataset([][]string{
+		{"Region", "Revenue"},
+		{"North", "10"},
+		{"South", "n/a"},
+	})
+
+	var buf bytes.Buffer
+	err := Render(&buf, ChartSpec{Type: "Pie"}, data)
+	var convErr *dataset.ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("Render error = %v, want a *dataset.ConversionError", err)
+	}
+	if len(convErr.Rows) != 1 || convErr.Values[0] != "n/a" {
+		t.Errorf("conversion error reports %v %v", convErr.Rows, convErr.Values)
+	}
+}
+
+func TestRenderTimeAxis(t *testing.T) {
+	data := mustDataset([][]string{
+		{"Day", "Sales"},
+		{"03/15/2024", "7"},
+		{"01/02/2024", "5"},
+		{"02/20/2024", "6"},
+	})
+
+	var buf bytes.Buffer
+	if err := Render(&buf, ChartSpec{Type: "Line"}, data); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	html := buf.String()
+	if !strings.Contains(html, `"type":"time"`) || !strings.Contains(html, `"dataZoom"`) {
+		t.Errorf("time column is not plotted on a zoomable time axis")
+	}
+	first, last := strings.Index(html, "2024-01-02"), strings.Index(html, "2024-03-15")
+	if first == -1 || last == -1 || first > last {
+		t.Errorf("points are not in chronological order")
+	}
+}
+
+func TestRenderInvalidValuePolicies(t *testing.T) {
+	data := mustDataset([][]string{
+		{"Region", "Revenue"},
+		{"North", "10"},
+		{"South", "n/a"},
+		{"East", ""},
+		{"West", "5"},
+	})
+
+	tests := []struct {
+		policy           ValuePolicy
+		skipped, coerced int
+	}{
+		// The empty cell is always left out when rendering
+		{SkipInvalid, 2, 0},
+		{CoerceInvalid, 1, 1},
+	}
+	for _, tt := range tests {
+		diagnostics := &Diagnostics{}
+		var buf bytes.Buffer
+		spec := ChartSpec{Type: "Pie", Invalid: tt.policy, Diagnostics: diagnostics}
+		if err := Render(&buf, spec, data); err != nil {
+			t.Fatalf("%s: Render failed: %v", tt.policy, err)
+		}
+		if diagnostics.skipped != tt.skipped || diagnostics.coerced != tt.coerced {
+			t.Errorf("%s: %s, want %d skipped and %d coerced", tt.policy, diagnostics.Summary(), tt.skipped, tt.coerced)
+		}
+
+		var wrongType *WrongTypeError
+		if !errors.As(diagnostics.Items[0].Err, &wrongType) || wrongType.Row != 3 || wrongType.Value != "n/a" {
+			t.Errorf("%s: first diagnostic = %v, want row 3 holding n/a", tt.policy, diagnostics.Items[0].Err)
+		}
+	}
+}
+
+func TestRenderReportsInsufficientData(t *testing.T) {
+	data := mustDataset([][]string{
+		{"Region", "Revenue"},
+		{"North", "x"},
+		{"South", "y"},
+	})
+
+	var buf bytes.Buffer
+	diagnostics := &Diagnostics{}
+	err := Render(&buf, ChartSpec{Type: "Pie", Invalid: SkipInvalid, Diagnostics: diagnostics}, data)
+	var insufficient *InsufficientDataError
+	if !errors.As(err, &insufficient) || diagnostics.Len() != 2 {
+		t.Errorf("Render error = %v with %d diagnostics, want a *InsufficientDataError and 2", err, diagnostics.Len())
+	}
+
+	// The heatmap skips single cells, so rows left without any are dropped
+	empty := mustDataset([][]string{
+		{"Region", "Q1", "Q2"},
+		{"North", "", ""},
+		{"South", "", ""},
+	})
+	diagnostics = &Diagnostics{}
+	spec := ChartSpec{Type: "Heatmap", Invalid: SkipInvalid, Diagnostics: diagnostics}
+	if err := renderHeatmap(&buf, spec, empty, roleColumns{"Row Label": {0}, "Values": {1, 2}}); !errors.As(err, &insufficient) || diagnostics.Len() != 4 {
+		t.Errorf("Heatmap error = %v with %d diagnostics, want a *InsufficientDataError and 4", err, diagnostics.Len())
+	}
+
+	err = Render(&buf, ChartSpec{Type: "Pie", Columns: map[string][]string{"Category": {"Region"}, "Value": {"Missing"}}}, data)
+	var missing *MissingColumnError
+	if !errors.As(err, &missing) || missing.Column != "Missing" || missing.Role != "Value" {
+		t.Errorf("Render error = %v, want a *MissingColumnError for Value", err)
+	}
+}
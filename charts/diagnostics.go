@@ -0,0 +1,194 @@
+﻿package charts
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"graph-viewer/dataset"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ValuePolicy chooses what happens to values that do not fit the type of
+// their role, e.g. text in a numeric column
+type ValuePolicy int
+
+const (
+	FailInvalid   ValuePolicy = iota // reject the data at the first invalid value
+	SkipInvalid                      // leave out the rows holding invalid values
+	CoerceInvalid                    // chart invalid numbers as zero; rows with invalid times are left out
+)
+
+// ValuePolicies lists the policies in the order offered to users
+var ValuePolicies = []ValuePolicy{FailInvalid, SkipInvalid, CoerceInvalid}
+
+func (p ValuePolicy) String() string {
+	switch p {
+	case SkipInvalid:
+		return "skip"
+	case CoerceInvalid:
+		return "coerce"
+	default:
+		return "fail"
+	}
+}
+
+// ParseValuePolicy returns the policy with the given name
+func ParseValuePolicy(name string) (ValuePolicy, error) {
+	for _, p := range ValuePolicies {
+		if strings.EqualFold(p.String(), strings.TrimSpace(name)) {
+			return p, nil
+		}
+	}
+	return FailInvalid, fmt.Errorf("unknown invalid value policy %q, use fail, skip or coerce", name)
+}
+
+// InsufficientDataError reports data without a single row a chart can use
+type InsufficientDataError struct {
+	Chart string // e.g. "pie chart"
+	Rows  int    // rows in the data, all of them unusable; 0 when none are left
+}
+
+func (e *InsufficientDataError) Error() string {
+	if e.Rows == 0 {
+		return fmt.Sprintf("insufficient data for %s: no rows to chart", e.Chart)
+	}
+	return fmt.Sprintf("insufficient data for %s: none of the %d rows has valid values", e.Chart, e.Rows)
+}
+
+// WrongTypeError reports a value that does not fit the type of its role,
+// or a missing value where the role needs one
+type WrongTypeError struct {
+	Column string
+	Row    int // source row, or the line it starts on in text files
+	Value  string
+	Want   ValueType
+}
+
+func (e *WrongTypeError) Error() string {
+	if strings.TrimSpace(e.Value) == "" {
+		return fmt.Sprintf("row %d: column %s has no value", e.Row, e.Column)
+	}
+	return fmt.Sprintf("row %d: %q in column %s is not %s", e.Row, e.Value, e.Column, e.Want)
+}
+
+// MissingColumnError reports a column selected for a role that the data
+// does not have
+type MissingColumnError struct {
+	Column string
+	Role   string
+}
+
+func (e *MissingColumnError) Error() string {
+	return fmt.Sprintf("column %q for %s not found in data", e.Column, e.Role)
+}
+
+// Diagnostic records a row left out of a chart, or a value changed to fit it
+type Diagnostic struct {
+	Row     int   // source row, or the line it starts on in text files
+	Err     error // why, usually a *WrongTypeError
+	Coerced bool  // the value was replaced and the row kept
+}
+
+// Action returns what was done to the row, "skipped" or "coerced"
+func (d Diagnostic) Action() string {
+	if d.Coerced {
+		return "coerced"
+	}
+	return "skipped"
+}
+
+// maxDiagnostics is the number of diagnostics a collector lists; further
+// ones are only counted
+const maxDiagnostics = 10000
+
+// Diagnostics collects the rows left out or changed while data is extracted
+// and rendered. Methods on a nil *Diagnostics do nothing, so collecting is
+// optional.
+type Diagnostics struct {
+	Items   []Diagnostic // the first diagnostics, at most maxDiagnostics
+	skipped int
+	coerced int
+}
+
+// Add records a diagnostic
+func (d *Diagnostics) Add(diagnostic Diagnostic) {
+	if d == nil {
+		return
+	}
+	if diagnostic.Coerced {
+		d.coerced++
+	} else {
+		d.skipped++
+	}
+	if len(d.Items) < maxDiagnostics {
+		d.Items = append(d.Items, diagnostic)
+	}
+}
+
+// skip records that row i of data was left out because col has no usable
+// value of type want there
+func (d *Diagnostics) skip(data *dataset.Dataset, i int, col *dataset.Column, want ValueType) {
+	d.Add(Diagnostic{
+		Row: data.SourceRows[i],
+		Err: &WrongTypeError{Column: col.Name, Row: data.SourceRows[i], Value: col.String(i), Want: want},
+	})
+}
+
+// Len returns the number of rows skipped and values coerced
+func (d *Diagnostics) Len() int {
+	if d == nil {
+		return 0
+	}
+	return d.skipped + d.coerced
+}
+
+// Summary describes the diagnostics, e.g. "12 rows skipped, 3 values coerced"
+func (d *Diagnostics) Summary() string {
+	var parts []string
+	switch n := d.skipped; n {
+	case 0:
+	case 1:
+		parts = append(parts, "1 row skipped")
+	default:
+		parts = append(parts, fmt.Sprintf("%d rows skipped", n))
+	}
+	switch n := d.coerced; n {
+	case 0:
+	case 1:
+		parts = append(parts, "1 value coerced")
+	default:
+		parts = append(parts, fmt.Sprintf("%d values coerced", n))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteCSV writes the listed diagnostics as CSV with a header row
+func (d *Diagnostics) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"Row", "Action", "Column", "Value", "Reason"})
+	for _, diagnostic := range d.Items {
+		column, value := "", ""
+		var wrongType *WrongTypeError
+		if errors.As(diagnostic.Err, &wrongType) {
+			column, value = wrongType.Column, wrongType.Value
+		}
+		out.Write([]string{
+			strconv.Itoa(diagnostic.Row), diagnostic.Action(), column, value, diagnostic.Err.Error(),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// firstInvalid returns the first of the columns without a number at row i,
+// or nil when all of them hold one
+func firstInvalid(i int, cols ...*dataset.Column) *dataset.Column {
+	for _, col := range cols {
+		if _, ok := col.Float(i); !ok {
+			return col
+		}
+	}
+	return nil
+}
@@ -2,54 +2,89 @@
 
 import (
 	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Heatmap",
+		title:       "Heat Map",
+		description: "Visualize data density and patterns",
+		preview:     "Heatmap",
+		roles: []Role{
+			{Name: "Row Label", Description: "Optional label for each row", Min: 0, Max: 1},
+			{Name: "Values", Description: "One heatmap column per selected column", Type: NumericValue, Min: 1, Max: Unlimited},
+		},
+		render: renderHeatmap,
+	})
+}
+
 // GenerateHeatmap creates an HTML heatmap chart from the given data
+// and writes it to a file, returning the file path
 func GenerateHeatmap(data [][]string) (string, error) {
-	if len(data) < 2 {
-		return "", fmt.Errorf("insufficient data for heatmap")
+	return GenerateGraph(data, "Heatmap")
+}
+
+// renderHeatmap writes the Heatmap chart as HTML to w
+func renderHeatmap(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "heatmap"}
 	}
 
-	// Extract headers and values
-	headers := data[0]
-	values := [][]opts.HeatMapData{}
+	labelIndex := cols.index("Row Label")
+	valueIndices := cols["Values"]
 
-	for i, row := range data[1:] { // Skip header row
-		if len(row) != len(headers) {
-			continue // Skip rows with mismatched column lengths
-		}
+	// Extract column names, row labels and values
+	columnNames := make([]string, len(valueIndices))
+	for j, index := range valueIndices {
+		columnNames[j] = data.Columns[index].Name
+	}
+	rowLabels := []string{}
+	values := [][]opts.HeatMapData{}
 
+	for i := 0; i < data.Len(); i++ {
+		y := len(rowLabels)
 		rowValues := []opts.HeatMapData{}
-		for j, cell := range row {
-			value, err := parseNumericValue(cell)
-			if err != nil {
-				fmt.Printf("Skipping invalid value at row %d, col %d: %s\n", i+1, j+1, cell)
+		for j, index := range valueIndices {
+			col := data.Columns[index]
+			value, ok := col.Float(i)
+			if !ok {
+				spec.Diagnostics.skip(data, i, col, NumericValue)
 				continue
 			}
 			rowValues = append(rowValues, opts.HeatMapData{
-				Value: []interface{}{j, i, value}, // Column (x), Row (y), Value (z)
+				Value: []interface{}{j, y, value}, // Column (x), Row (y), Value (z)
 			})
 		}
+		if len(rowValues) == 0 {
+			continue // every value of the row was skipped
+		}
+
+		label := fmt.Sprintf("%d", data.SourceRows[i])
+		if labelIndex != -1 {
+			label = data.Columns[labelIndex].String(i)
+		}
+		rowLabels = append(rowLabels, label)
 		values = append(values, rowValues)
 	}
 
 	if len(values) == 0 {
-		return "", fmt.Errorf("no valid data for heatmap")
+		return &InsufficientDataError{Chart: "heatmap", Rows: data.Len()}
 	}
 
 	// Create heatmap chart
 	heatmap := charts.NewHeatMap()
 	heatmap.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Heatmap",
+			Title:    spec.title("Heatmap"),
 			Subtitle: "",
 		}),
-		charts.WithXAxisOpts(opts.XAxis{Name: "Columns", Type: "category", Data: headers}),
-		charts.WithYAxisOpts(opts.YAxis{Name: "Rows", Type: "category"}),
+		charts.WithXAxisOpts(opts.XAxis{Name: "Columns", Type: "category", Data: columnNames}),
+		charts.WithYAxisOpts(opts.YAxis{Name: "Rows", Type: "category", Data: rowLabels}),
 	)
 
 	// Flatten data for heatmap series
@@ -60,18 +95,5 @@ func GenerateHeatmap(data [][]string) (string, error) {
 
 	heatmap.AddSeries("Heatmap", flattenedValues)
 
-	// Render the chart to an HTML file
-	filePath := "heatmap_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = heatmap.Render(file)
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return heatmap.Render(w)
 }
@@ -1,75 +1,98 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
+	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Kline",
+		title:       "Candlestick Chart",
+		description: "Show open, close, low and high prices over time",
+		roles: []Role{
+			{Name: "Date", Type: TimeValue, Min: 1, Max: 1},
+			{Name: "Open", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "Close", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "Low", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "High", Type: NumericValue, Min: 1, Max: 1},
+		},
+		render: renderKlineChart,
+	})
+}
+
 // GenerateKlineChart creates a Kline chart from financial data
+// and writes it to a file, returning the file path
 func GenerateKlineChart(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 5 {
-		return "", fmt.Errorf("kline chart requires at least 5 columns: Date, Open, Close, Low, High")
+	return GenerateGraph(data, "Kline")
+}
+
+// renderKlineChart writes the Kline chart as HTML to w
+func renderKlineChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "kline chart"}
 	}
 
+	dateCol := data.Columns[cols.index("Date")]
+	openCol, closeCol := data.Columns[cols.index("Open")], data.Columns[cols.index("Close")]
+	lowCol, highCol := data.Columns[cols.index("Low")], data.Columns[cols.index("High")]
+
 	// Extract data
 	values := []opts.KlineData{}
-	xLabels := []string{}
-
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 5 {
-			continue // Skip rows with insufficient columns
-		}
+	times := []time.Time{}
 
-		// Parse values
-		date := row[0]
-		open, err1 := parseNumericValue(row[1])
-		close, err2 := parseNumericValue(row[2])
-		low, err3 := parseNumericValue(row[3])
-		high, err4 := parseNumericValue(row[4])
+	for i := 0; i < data.Len(); i++ {
+		date, ok0 := dateCol.Time(i)
+		open, ok1 := openCol.Float(i)
+		close, ok2 := closeCol.Float(i)
+		low, ok3 := lowCol.Float(i)
+		high, ok4 := highCol.Float(i)
 
-		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
-			fmt.Printf("Skipping invalid row %d: %v, %v, %v, %v\n", i+1, err1, err2, err3, err4)
+		if !ok0 {
+			spec.Diagnostics.skip(data, i, dateCol, TimeValue)
+			continue
+		}
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			spec.Diagnostics.skip(data, i, firstInvalid(i, openCol, closeCol, lowCol, highCol), NumericValue)
 			continue
 		}
 
-		xLabels = append(xLabels, date)
+		times = append(times, date)
 		values = append(values, opts.KlineData{Value: [4]float64{open, close, low, high}})
 	}
 
 	if len(values) == 0 {
-		return "", fmt.Errorf("no valid data for kline chart")
+		return &InsufficientDataError{Chart: "kline chart", Rows: data.Len()}
 	}
 
+	// Candles are drawn in date order on a category axis, so days without
+	// trading such as weekends leave no empty space
+	order := timeOrder(times)
+	values = reorder(values, order)
+	xLabels := formatTimes(reorder(times, order))
+
 	// Create Kline chart
 	kline := charts.NewKLine()
 	kline.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Kline Chart",
+			Title:    spec.title("Kline Chart"),
 			Subtitle: "Financial Data",
 		}),
 		charts.WithXAxisOpts(opts.XAxis{
 			Type: "category",
-			Data: xLabels,
+			Name: dateCol.Name,
 		}),
+		charts.WithDataZoomOpts(dataZoomOpts()...),
 	)
 
-	kline.AddSeries("Kline", values)
+	// The axis data is only kept when set through SetXAxis
+	kline.SetXAxis(xLabels)
 
-	// Render to file
-	filePath := "kline_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = kline.Render(file)
-	if err != nil {
-		return "", err
-	}
+	kline.AddSeries("Kline", values)
 
-	return filePath, nil
+	return kline.Render(w)
 }
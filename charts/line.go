@@ -0,0 +1,79 @@
+﻿package charts
+
+import (
+	"graph-viewer/dataset"
+	"io"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+func init() {
+	Register(&builtinChart{
+		name:        "Line",
+		title:       "Line Chart",
+		description: "Compare one or more series along an axis",
+		roles: []Role{
+			{Name: "X Axis", Description: "Category labels", Min: 1, Max: 1},
+			{Name: "Y Axis", Description: "Line values, one series per column", Type: NumericValue, Min: 1, Max: Unlimited},
+		},
+		stackable: true,
+		render:    renderLineChart,
+	})
+}
+
+// GenerateLineChart creates an HTML line chart from the given data
+// and writes it to a file, returning the file path
+func GenerateLineChart(data [][]string) (string, error) {
+	return GenerateGraph(data, "Line")
+}
+
+// renderLineChart writes the Line chart as HTML to w
+func renderLineChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "line chart"}
+	}
+
+	xIndex := cols.index("X Axis")
+
+	series := extractSeries(data, xIndex, cols["Y Axis"], spec.Diagnostics)
+	if len(series.labels) == 0 {
+		return &InsufficientDataError{Chart: "line chart", Rows: data.Len()}
+	}
+
+	yName := "Values"
+	if len(series.names) == 1 {
+		yName = series.names[0]
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title: spec.title("Line Chart"),
+		}),
+		charts.WithLegendOpts(legendOpts(len(series.names))),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithXAxisOpts(series.xAxisOpts(data.Columns[xIndex].Name)),
+		charts.WithYAxisOpts(opts.YAxis{Name: yName}),
+	)
+	if series.timeAxis() {
+		line.SetGlobalOptions(charts.WithDataZoomOpts(dataZoomOpts()...))
+	}
+
+	// Stacked lines are drawn as areas so the totals are readable
+	seriesOpts := []charts.SeriesOpts{charts.WithLineChartOpts(opts.LineChart{Stack: stackName(spec)})}
+	if spec.Stacked {
+		seriesOpts = append(seriesOpts, charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.4}))
+	}
+
+	line.SetXAxis(series.categories())
+	for j, name := range series.names {
+		lineValues := make([]opts.LineData, len(series.values[j]))
+		for i := range series.values[j] {
+			lineValues[i] = opts.LineData{Value: series.point(j, i)}
+		}
+		line.AddSeries(name, lineValues, seriesOpts...)
+	}
+
+	return line.Render(w)
+}
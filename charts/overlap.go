@@ -1,79 +1,86 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Overlap",
+		title:       "Bar and Line Chart",
+		description: "Compare two series as overlapping bars and a line",
+		roles: []Role{
+			{Name: "X Axis", Description: "Category labels", Min: 1, Max: 1},
+			{Name: "Bar", Description: "Values drawn as bars", Type: NumericValue, Min: 1, Max: Unlimited},
+			{Name: "Line", Description: "Values drawn as lines", Type: NumericValue, Min: 1, Max: Unlimited},
+		},
+		stackable: true,
+		render:    renderOverlapChart,
+	})
+}
+
 // GenerateOverlapChart creates a chart with overlapping series (e.g., Bar and Line)
+// and writes it to a file, returning the file path
 func GenerateOverlapChart(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 3 {
-		return "", fmt.Errorf("overlap chart requires at least 3 columns: X, Y1, Y2")
-	}
-
-	// Extract data for the charts
-	xLabels := []string{}
-	barValues := []opts.BarData{}
-	lineValues := []opts.LineData{}
-
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 3 {
-			continue // Skip rows with insufficient columns
-		}
-
-		xLabels = append(xLabels, row[0]) // First column as X-axis label
-
-		// Parse values for bar and line
-		y1, err1 := parseNumericValue(row[1])
-		y2, err2 := parseNumericValue(row[2])
-
-		if err1 != nil || err2 != nil {
-			fmt.Printf("Skipping invalid row %d: %v, %v\n", i+1, err1, err2)
-			continue
-		}
+	return GenerateGraph(data, "Overlap")
+}
 
-		barValues = append(barValues, opts.BarData{Value: y1})
-		lineValues = append(lineValues, opts.LineData{Value: y2})
+// renderOverlapChart writes the Overlap chart as HTML to w
+func renderOverlapChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "overlap chart"}
 	}
 
-	if len(xLabels) == 0 || len(barValues) == 0 || len(lineValues) == 0 {
-		return "", fmt.Errorf("no valid data for overlap chart")
+	xIndex := cols.index("X Axis")
+	barCount := len(cols["Bar"])
+
+	// Extract bar and line series together so both stay aligned with the labels
+	series := extractSeries(data, xIndex, append(append([]int(nil), cols["Bar"]...), cols["Line"]...), spec.Diagnostics)
+	if len(series.labels) == 0 {
+		return &InsufficientDataError{Chart: "overlap chart", Rows: data.Len()}
 	}
 
 	// Create Bar chart
 	bar := charts.NewBar()
 	bar.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Overlap Chart",
+			Title:    spec.title("Overlap Chart"),
 			Subtitle: "Bar and Line",
 		}),
-		charts.WithXAxisOpts(opts.XAxis{Name: "X-axis"}),
+		charts.WithLegendOpts(legendOpts(len(series.names))),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithXAxisOpts(series.xAxisOpts(data.Columns[xIndex].Name)),
 		charts.WithYAxisOpts(opts.YAxis{Name: "Y-axis"}),
 	)
-	bar.SetXAxis(xLabels).AddSeries("Bar", barValues)
+	if series.timeAxis() {
+		bar.SetGlobalOptions(charts.WithDataZoomOpts(dataZoomOpts()...))
+	}
+	bar.SetXAxis(series.categories())
+	for j := 0; j < barCount; j++ {
+		barValues := make([]opts.BarData, len(series.values[j]))
+		for i := range series.values[j] {
+			barValues[i] = opts.BarData{Value: series.point(j, i)}
+		}
+		bar.AddSeries(series.names[j], barValues, charts.WithBarChartOpts(opts.BarChart{Stack: stackName(spec)}))
+	}
 
 	// Create Line chart
 	line := charts.NewLine()
-	line.SetXAxis(xLabels).AddSeries("Line", lineValues)
+	line.SetXAxis(series.categories())
+	for j := barCount; j < len(series.names); j++ {
+		lineValues := make([]opts.LineData, len(series.values[j]))
+		for i := range series.values[j] {
+			lineValues[i] = opts.LineData{Value: series.point(j, i)}
+		}
+		line.AddSeries(series.names[j], lineValues)
+	}
 
 	// Overlap the charts
 	bar.Overlap(line)
 
-	// Render to file
-	filePath := "overlap_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = bar.Render(file)
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return bar.Render(w)
 }
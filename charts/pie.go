@@ -1,62 +1,66 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Pie",
+		title:       "Pie Chart",
+		description: "Show proportion between categories",
+		preview:     "Pie",
+		roles: []Role{
+			{Name: "Category", Description: "Slice labels", Min: 1, Max: 1},
+			{Name: "Value", Description: "Slice sizes", Type: NumericValue, Min: 1, Max: 1},
+		},
+		render: renderPieChart,
+	})
+}
+
 // GeneratePieChart creates a Pie chart from the given data
+// and writes it to a file, returning the file path
 func GeneratePieChart(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 2 {
-		return "", fmt.Errorf("pie chart requires at least 2 columns: Category, Value")
+	return GenerateGraph(data, "Pie")
+}
+
+// renderPieChart writes the Pie chart as HTML to w
+func renderPieChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "pie chart"}
 	}
 
+	categoryCol, valueCol := data.Columns[cols.index("Category")], data.Columns[cols.index("Value")]
+
 	// Extract categories and values
 	items := []opts.PieData{}
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 2 {
-			continue // Skip rows with insufficient columns
-		}
-
-		category := row[0]
-		value, err := parseNumericValue(row[1])
-		if err != nil {
-			fmt.Printf("Skipping invalid row %d: %v\n", i+1, err)
+	for i := 0; i < data.Len(); i++ {
+		value, ok := valueCol.Float(i)
+		if !ok {
+			spec.Diagnostics.skip(data, i, valueCol, NumericValue)
 			continue
 		}
 
-		items = append(items, opts.PieData{Name: category, Value: value})
+		items = append(items, opts.PieData{Name: categoryCol.String(i), Value: value})
 	}
 
 	if len(items) == 0 {
-		return "", fmt.Errorf("no valid data for Pie chart")
+		return &InsufficientDataError{Chart: "pie chart", Rows: data.Len()}
 	}
 
 	// Create Pie chart
 	pie := charts.NewPie()
 	pie.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title: "Pie Chart",
+			Title: spec.title("Pie Chart"),
 		}),
 	)
 
-	pie.AddSeries("Pie", items)
-
-	// Render to file
-	filePath := "pie_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = pie.Render(file)
-	if err != nil {
-		return "", err
-	}
+	pie.AddSeries(valueCol.Name, items)
 
-	return filePath, nil
+	return pie.Render(w)
 }
@@ -0,0 +1,113 @@
+﻿package charts
+
+import (
+	"fmt"
+	"graph-viewer/dataset"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChartType is a graph type that can be rendered from a Dataset
+type ChartType interface {
+	// Name is the unique key of the chart type, e.g. "Bar"
+	Name() string
+	// Title is the human readable name, e.g. "Bar Chart"
+	Title() string
+	Description() string
+	// Preview is the name of the example image, empty if there is none
+	Preview() string
+	Roles() []Role
+	Render(w io.Writer, spec ChartSpec, data *dataset.Dataset) error
+}
+
+// StackableChart is implemented by chart types whose series can be stacked
+type StackableChart interface {
+	ChartType
+	CanStack() bool
+}
+
+// CanStack reports whether the chart type supports ChartSpec.Stacked
+func CanStack(ct ChartType) bool {
+	stackable, ok := ct.(StackableChart)
+	return ok && stackable.CanStack()
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ChartType)
+)
+
+// Register makes a chart type available to Render, the UI and the CLI.
+// It panics if a chart type with the same name is already registered.
+func Register(ct ChartType) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if ct == nil {
+		panic("charts: Register chart type is nil")
+	}
+	if _, dup := registry[ct.Name()]; dup {
+		panic(fmt.Sprintf("charts: Register called twice for chart type %s", ct.Name()))
+	}
+	registry[ct.Name()] = ct
+}
+
+// Lookup returns the chart type registered under name, ignoring case
+func Lookup(name string) (ChartType, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if ct, ok := registry[name]; ok {
+		return ct, true
+	}
+	for key, ct := range registry {
+		if strings.EqualFold(key, name) {
+			return ct, true
+		}
+	}
+	return nil, false
+}
+
+// Types returns all registered chart types sorted by name
+func Types() []ChartType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]ChartType, 0, len(registry))
+	for _, ct := range registry {
+		types = append(types, ct)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name() < types[j].Name() })
+	return types
+}
+
+// builtinChart implements ChartType for the charts shipped with this package
+type builtinChart struct {
+	name        string
+	title       string
+	description string
+	preview     string
+	roles       []Role
+	stackable   bool
+	render      func(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error
+}
+
+func (c *builtinChart) Name() string        { return c.name }
+func (c *builtinChart) Title() string       { return c.title }
+func (c *builtinChart) Description() string { return c.description }
+func (c *builtinChart) Preview() string     { return c.preview }
+func (c *builtinChart) Roles() []Role       { return c.roles }
+func (c *builtinChart) CanStack() bool      { return c.stackable }
+
+func (c *builtinChart) Render(w io.Writer, spec ChartSpec, data *dataset.Dataset) error {
+	cols, err := ResolveRoles(c, spec, data)
+	if err != nil {
+		return err
+	}
+	if data, err = convertRoleColumns(c.roles, cols, data, spec); err != nil {
+		return err
+	}
+	return c.render(w, spec, data, cols)
+}
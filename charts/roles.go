@@ -0,0 +1,326 @@
+﻿package charts
+
+import (
+	"errors"
+	"fmt"
+	"graph-viewer/dataset"
+	"slices"
+	"strings"
+)
+
+// Unlimited is used as Role.Max for roles that accept any number of columns
+const Unlimited = -1
+
+// ValueType constrains the columns that can fill a role
+type ValueType int
+
+const (
+	AnyValue     ValueType = iota // labels, categories or numbers
+	NumericValue                  // values must parse as numbers
+	TimeValue                     // values must parse as dates or times
+)
+
+// String returns the name used for the value type in the UI
+func (v ValueType) String() string {
+	switch v {
+	case NumericValue:
+		return "numeric"
+	case TimeValue:
+		return "date/time"
+	default:
+		return "any"
+	}
+}
+
+// Accepts reports whether a column is suitable for a role of this value type
+func (v ValueType) Accepts(col *dataset.Column) bool {
+	switch v {
+	case NumericValue:
+		return col.Kind.Numeric() || (col.Type.Numeric() && col.Confidence >= dataset.MinConfidence)
+	case TimeValue:
+		return col.Kind == dataset.KindTime || (col.Type == dataset.TypeDateTime && col.Confidence >= dataset.MinConfidence)
+	default:
+		return true
+	}
+}
+
+// Kind returns the storage kind columns are converted to before rendering
+func (v ValueType) Kind() dataset.Kind {
+	switch v {
+	case NumericValue:
+		return dataset.KindFloat
+	case TimeValue:
+		return dataset.KindTime
+	default:
+		return dataset.KindString
+	}
+}
+
+// Holds reports whether a column already stores values of the type, so it
+// needs no conversion
+func (v ValueType) Holds(col *dataset.Column) bool {
+	switch v {
+	case NumericValue:
+		return col.Kind.Numeric()
+	case TimeValue:
+		return col.Kind == dataset.KindTime
+	default:
+		return true
+	}
+}
+
+// Role is a named set of columns a chart type needs, e.g. the Source,
+// Target and Value columns of a Sankey diagram
+type Role struct {
+	Name        string // label shown to the user, e.g. "X Axis" or "Source"
+	Description string
+	Type        ValueType
+	Min         int // minimum number of columns, 0 for optional roles
+	Max         int // maximum number of columns or Unlimited
+}
+
+// Optional reports whether the role may be left empty
+func (r Role) Optional() bool {
+	return r.Min == 0
+}
+
+// Multiple reports whether the role accepts more than one column
+func (r Role) Multiple() bool {
+	return r.Max == Unlimited || r.Max > 1
+}
+
+// accepts reports whether n columns satisfy the role's cardinality
+func (r Role) accepts(n int) bool {
+	return n >= r.Min && (r.Max == Unlimited || n <= r.Max)
+}
+
+// cardinality describes how many columns the role accepts
+func (r Role) cardinality() string {
+	switch {
+	case r.Min == r.Max:
+		return fmt.Sprintf("exactly %d", r.Min)
+	case r.Max == Unlimited:
+		return fmt.Sprintf("at least %d", r.Min)
+	default:
+		return fmt.Sprintf("%d to %d", r.Min, r.Max)
+	}
+}
+
+// ValidateColumns checks that the columns selected per role match the
+// cardinality of the chart type's roles
+func ValidateColumns(ct ChartType, columns map[string][]string) error {
+	known := make(map[string]bool)
+	for _, role := range ct.Roles() {
+		known[role.Name] = true
+		if n := len(columns[role.Name]); !role.accepts(n) {
+			return fmt.Errorf("%s needs %s column(s) for %s, got %d", ct.Name(), role.cardinality(), role.Name, n)
+		}
+	}
+	for name := range columns {
+		if !known[name] {
+			return fmt.Errorf("%s has no role named %s", ct.Name(), name)
+		}
+	}
+	return nil
+}
+
+// AssignColumns maps a flat list of columns onto the roles of a chart type
+// in declaration order. Each role takes its minimum number of columns;
+// columns left over fill optional single-column roles first and then the
+// first role that accepts more.
+func AssignColumns(ct ChartType, names []string) (map[string][]string, error) {
+	roles := ct.Roles()
+	counts, err := roleCounts(ct.Name(), roles, len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string][]string)
+	next := 0
+	for i, role := range roles {
+		if counts[i] > 0 {
+			columns[role.Name] = names[next : next+counts[i]]
+		}
+		next += counts[i]
+	}
+	return columns, nil
+}
+
+// roleCounts returns how many of n columns each role takes when they are
+// assigned in order, as described for AssignColumns
+func roleCounts(chart string, roles []Role, n int) ([]int, error) {
+	counts := make([]int, len(roles))
+	required := 0
+	for i, role := range roles {
+		counts[i] = role.Min
+		required += role.Min
+	}
+	if n < required {
+		return nil, fmt.Errorf("%s needs at least %d columns (%s), got %d", chart, required, roleNames(roles), n)
+	}
+
+	extra := n - required
+	for i, role := range roles {
+		if extra > 0 && role.Optional() && role.Max == 1 {
+			counts[i]++
+			extra--
+		}
+	}
+	for i, role := range roles {
+		if extra > 0 && (role.Max == Unlimited || role.Max > counts[i]) {
+			take := extra
+			if role.Max != Unlimited && take > role.Max-counts[i] {
+				take = role.Max - counts[i]
+			}
+			counts[i] += take
+			extra -= take
+		}
+	}
+	if extra > 0 {
+		return nil, fmt.Errorf("%s takes at most %d columns, got %d", chart, n-extra, n)
+	}
+	return counts, nil
+}
+
+func roleNames(roles []Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// roleColumns maps role names to column indices in a Dataset
+type roleColumns map[string][]int
+
+// index returns the first column of a role, or -1 if the role is empty
+func (c roleColumns) index(role string) int {
+	if indices := c[role]; len(indices) > 0 {
+		return indices[0]
+	}
+	return -1
+}
+
+// ResolveRoles finds the dataset columns for each role of the chart type.
+// Columns named in spec.Columns are looked up by header; without a mapping
+// the dataset's columns are assigned to roles in order.
+func ResolveRoles(ct ChartType, spec ChartSpec, data *dataset.Dataset) (map[string][]int, error) {
+	columns := spec.Columns
+	if len(columns) == 0 {
+		return resolvePositions(ct, data)
+	}
+
+	if err := ValidateColumns(ct, columns); err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string][]int)
+	for role, names := range columns {
+		for _, name := range names {
+			index := data.Index(name)
+			if index == -1 {
+				return nil, &MissingColumnError{Column: name, Role: role}
+			}
+			resolved[role] = append(resolved[role], index)
+		}
+	}
+	return resolved, nil
+}
+
+// resolvePositions assigns the dataset's columns to the roles of the chart
+// type in order, like AssignColumns. Optional roles that take any value are
+// left empty when their column holds numbers, so numeric data all goes to
+// the numeric roles: the first column of a heatmap of numbers is a value
+// column, not a row label.
+func resolvePositions(ct ChartType, data *dataset.Dataset) (map[string][]int, error) {
+	roles := ct.Roles()
+	counts, err := roleCounts(ct.Name(), roles, len(data.Columns))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(roles); i++ {
+		role := roles[i]
+		if !role.Optional() || role.Type != AnyValue || counts[i] == 0 || !NumericValue.Accepts(data.Columns[sum(counts[:i])]) {
+			continue
+		}
+		without := slices.Delete(slices.Clone(roles), i, i+1)
+		if fewer, err := roleCounts(ct.Name(), without, len(data.Columns)); err == nil {
+			roles, counts = without, fewer
+			i--
+		}
+	}
+
+	// Positional columns may repeat header names, so map them by position
+	resolved := make(map[string][]int)
+	next := 0
+	for i, role := range roles {
+		for range counts[i] {
+			resolved[role.Name] = append(resolved[role.Name], next)
+			next++
+		}
+	}
+	return resolved, nil
+}
+
+// sum returns the number of columns the counts take
+func sum(counts []int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// convertRoleColumns makes sure the columns of numeric and time roles hold
+// numbers and times, converting text columns under the spec's policy
+func convertRoleColumns(roles []Role, cols roleColumns, data *dataset.Dataset, spec ChartSpec) (*dataset.Dataset, error) {
+	for _, role := range roles {
+		for _, index := range cols[role.Name] {
+			if role.Type.Holds(data.Columns[index]) {
+				continue
+			}
+
+			var err error
+			if data, err = ConvertValues(data, index, role.Type, spec.Invalid, spec.Diagnostics); err != nil {
+				return nil, fmt.Errorf("%s: %w", role.Name, err)
+			}
+		}
+	}
+	return data, nil
+}
+
+// ConvertValues returns a copy of data with the column at index converted to
+// the storage kind of t. Values that do not convert are handled by policy:
+// FailInvalid returns the *dataset.ConversionError, SkipInvalid leaves out
+// their rows and CoerceInvalid replaces invalid numbers by zero. Every row
+// left out or changed is added to diagnostics. Data without rows is
+// returned as it is, for the chart to report.
+func ConvertValues(data *dataset.Dataset, index int, t ValueType, policy ValuePolicy, diagnostics *Diagnostics) (*dataset.Dataset, error) {
+	if data.Len() == 0 {
+		return data, nil
+	}
+	converted, err := data.ConvertColumn(index, t.Kind())
+	var convErr *dataset.ConversionError
+	if err == nil || policy == FailInvalid || !errors.As(err, &convErr) {
+		return converted, err
+	}
+
+	coerce := policy == CoerceInvalid && t == NumericValue
+	for k, row := range convErr.SourceRows {
+		diagnostics.Add(Diagnostic{
+			Row:     row,
+			Err:     &WrongTypeError{Column: convErr.Column, Row: row, Value: convErr.Values[k], Want: t},
+			Coerced: coerce,
+		})
+	}
+	if coerce {
+		if data, err = data.ReplaceValues(index, convErr.Rows, "0"); err != nil {
+			return nil, err
+		}
+	} else {
+		if data = data.DropRows(convErr.Rows); data.Len() == 0 {
+			return data, nil
+		}
+	}
+	return data.ConvertColumn(index, t.Kind())
+}
@@ -1,33 +1,53 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "Sankey",
+		title:       "Sankey Diagram",
+		description: "Visualize flow between categories",
+		preview:     "Sankey",
+		roles: []Role{
+			{Name: "Source", Description: "Node the flow starts at", Min: 1, Max: 1},
+			{Name: "Target", Description: "Node the flow ends at", Min: 1, Max: 1},
+			{Name: "Value", Description: "Size of the flow", Type: NumericValue, Min: 1, Max: 1},
+		},
+		render: renderSankeyChart,
+	})
+}
+
 // GenerateSankeyChart creates a Sankey chart from the given data
+// and writes it to a file, returning the file path
 func GenerateSankeyChart(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 3 {
-		return "", fmt.Errorf("sankey chart requires at least 3 columns: Source, Target, Value")
+	return GenerateGraph(data, "Sankey")
+}
+
+// renderSankeyChart writes the Sankey chart as HTML to w
+func renderSankeyChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "Sankey diagram"}
 	}
 
+	sourceCol, targetCol := data.Columns[cols.index("Source")], data.Columns[cols.index("Target")]
+	valueCol := data.Columns[cols.index("Value")]
+
 	// Create nodes and links
 	nodesMap := map[string]struct{}{}
 	links := []opts.SankeyLink{}
 
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 3 {
-			continue // Skip rows with insufficient columns
-		}
-
-		source := row[0]
-		target := row[1]
-		value, err := parseNumericValue(row[2])
-		if err != nil {
-			fmt.Printf("Skipping invalid row %d: %v\n", i+1, err)
+	for i := 0; i < data.Len(); i++ {
+		source := sourceCol.String(i)
+		target := targetCol.String(i)
+		value, ok := valueCol.Float(i)
+		if !ok {
+			spec.Diagnostics.skip(data, i, valueCol, NumericValue)
 			continue
 		}
 
@@ -44,7 +64,7 @@ func GenerateSankeyChart(data [][]string) (string, error) {
 	}
 
 	if len(nodesMap) == 0 || len(links) == 0 {
-		return "", fmt.Errorf("no valid data for Sankey chart")
+		return &InsufficientDataError{Chart: "Sankey diagram", Rows: data.Len()}
 	}
 
 	// Create nodes from map
@@ -57,25 +77,12 @@ func GenerateSankeyChart(data [][]string) (string, error) {
 	sankey := charts.NewSankey()
 	sankey.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title: "Sankey Chart",
+			Title: spec.title("Sankey Chart"),
 		}),
 	)
 
 	// Add nodes and links to the chart
 	sankey.AddSeries("Sankey", nodes, links)
 
-	// Render to file
-	filePath := "sankey_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = sankey.Render(file)
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return sankey.Render(w)
 }
@@ -1,8 +1,8 @@
 ﻿package charts
 
 import (
-	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -17,25 +17,45 @@ func toInterfaceSlice(values ...float64) []interface{} {
 	return interfaceSlice
 }
 
+func init() {
+	Register(&builtinChart{
+		name:        "Scatter3D",
+		title:       "3D Scatter Plot",
+		description: "Three-dimensional scatter visualization",
+		preview:     "Scatter3D",
+		roles: []Role{
+			{Name: "X Axis", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "Y Axis", Type: NumericValue, Min: 1, Max: 1},
+			{Name: "Z Axis", Type: NumericValue, Min: 1, Max: 1},
+		},
+		render: renderScatter3D,
+	})
+}
+
 // GenerateScatter3D creates an HTML Scatter3D chart from the given data
+// and writes it to a file, returning the file path
 func GenerateScatter3D(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 3 {
-		return "", fmt.Errorf("scatter3D requires at least 3 columns: X, Y, and Z")
+	return GenerateGraph(data, "Scatter3D")
+}
+
+// renderScatter3D writes the Scatter3D chart as HTML to w
+func renderScatter3D(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "Scatter3D chart"}
 	}
 
+	xIndex, yIndex, zIndex := cols.index("X Axis"), cols.index("Y Axis"), cols.index("Z Axis")
+
 	// Extract data points
 	points := []opts.Chart3DData{}
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 3 {
-			continue // Skip rows with insufficient columns
-		}
+	xCol, yCol, zCol := data.Columns[xIndex], data.Columns[yIndex], data.Columns[zIndex]
+	for i := 0; i < data.Len(); i++ {
+		x, okX := xCol.Float(i)
+		y, okY := yCol.Float(i)
+		z, okZ := zCol.Float(i)
 
-		x, errX := parseNumericValue(row[0])
-		y, errY := parseNumericValue(row[1])
-		z, errZ := parseNumericValue(row[2])
-
-		if errX != nil || errY != nil || errZ != nil {
-			fmt.Printf("Skipping invalid row %d: %v, %v, %v\n", i+1, errX, errY, errZ)
+		if !okX || !okY || !okZ {
+			spec.Diagnostics.skip(data, i, firstInvalid(i, xCol, yCol, zCol), NumericValue)
 			continue
 		}
 
@@ -45,36 +65,23 @@ func GenerateScatter3D(data [][]string) (string, error) {
 	}
 
 	if len(points) == 0 {
-		return "", fmt.Errorf("no valid data for Scatter3D")
+		return &InsufficientDataError{Chart: "Scatter3D chart", Rows: data.Len()}
 	}
 
 	// Create scatter3D chart
 	scatter := charts.NewScatter3D()
 	scatter.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Scatter3D",
+			Title:    spec.title("Scatter3D"),
 			Subtitle: "",
 		}),
-		charts.WithXAxis3DOpts(opts.XAxis3D{Name: "X"}),
-		charts.WithYAxis3DOpts(opts.YAxis3D{Name: "Y"}),
-		charts.WithZAxis3DOpts(opts.ZAxis3D{Name: "Z"}),
+		charts.WithXAxis3DOpts(opts.XAxis3D{Name: xCol.Name}),
+		charts.WithYAxis3DOpts(opts.YAxis3D{Name: yCol.Name}),
+		charts.WithZAxis3DOpts(opts.ZAxis3D{Name: zCol.Name}),
 	)
 
 	// Add data to the scatter3D chart
 	scatter.AddSeries("Scatter3D", points)
 
-	// Render the chart to an HTML file
-	filePath := "scatter3d_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = scatter.Render(file)
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return scatter.Render(w)
 }
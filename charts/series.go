@@ -0,0 +1,159 @@
+﻿package charts
+
+import (
+	"graph-viewer/dataset"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// seriesData holds category labels with one or more numeric series
+type seriesData struct {
+	labels []string
+	times  []time.Time // the labels as times when the label column holds times
+	names  []string
+	values [][]float64 // values[series][label]
+}
+
+// extractSeries reads the label column and one series per value column.
+// Rows with a missing value in any series are skipped, and added to
+// diagnostics, so the series stay aligned with the labels. Time labels are
+// sorted chronologically.
+func extractSeries(data *dataset.Dataset, labelIndex int, valueIndices []int, diagnostics *Diagnostics) seriesData {
+	series := seriesData{
+		names:  make([]string, len(valueIndices)),
+		values: make([][]float64, len(valueIndices)),
+	}
+	for j, index := range valueIndices {
+		series.names[j] = data.Columns[index].Name
+	}
+
+	labels := data.Columns[labelIndex]
+	for i := 0; i < data.Len(); i++ {
+		rowValues := make([]float64, len(valueIndices))
+		valid := true
+		for j, index := range valueIndices {
+			value, ok := data.Columns[index].Float(i)
+			if !ok {
+				diagnostics.skip(data, i, data.Columns[index], NumericValue)
+				valid = false
+				break
+			}
+			rowValues[j] = value
+		}
+		if !valid {
+			continue
+		}
+
+		if labels.Kind == dataset.KindTime {
+			t, ok := labels.Time(i)
+			if !ok {
+				diagnostics.skip(data, i, labels, TimeValue)
+				continue
+			}
+			series.times = append(series.times, t)
+		}
+		series.labels = append(series.labels, labels.String(i))
+		for j, value := range rowValues {
+			series.values[j] = append(series.values[j], value)
+		}
+	}
+
+	if series.times != nil {
+		order := timeOrder(series.times)
+		series.times = reorder(series.times, order)
+		series.labels = formatTimes(series.times)
+		for j := range series.values {
+			series.values[j] = reorder(series.values[j], order)
+		}
+	}
+	return series
+}
+
+// timeAxis reports whether the series are plotted on a time axis
+func (s seriesData) timeAxis() bool {
+	return s.times != nil
+}
+
+// categories returns the labels of a category X axis, nil for a time axis
+// where each value carries its own time
+func (s seriesData) categories() []string {
+	if s.timeAxis() {
+		return nil
+	}
+	return s.labels
+}
+
+// xAxisOpts returns the options of the X axis named after the label column
+func (s seriesData) xAxisOpts(name string) opts.XAxis {
+	if s.timeAxis() {
+		return opts.XAxis{Name: name, Type: "time"}
+	}
+	return opts.XAxis{Name: name}
+}
+
+// point returns value i of series j, paired with its time on a time axis
+func (s seriesData) point(j, i int) interface{} {
+	if s.timeAxis() {
+		return []interface{}{s.labels[i], s.values[j][i]}
+	}
+	return s.values[j][i]
+}
+
+// dataZoomOpts lets users zoom into a range of the X axis with the mouse
+// wheel or a slider below the chart
+func dataZoomOpts() []opts.DataZoom {
+	return []opts.DataZoom{{Type: "inside"}, {Type: "slider"}}
+}
+
+// timeOrder returns the indices of times in chronological order, keeping
+// equal times in file order
+func timeOrder(times []time.Time) []int {
+	order := make([]int, len(times))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return times[order[a]].Before(times[order[b]]) })
+	return order
+}
+
+// reorder returns the values in the given order
+func reorder[T any](values []T, order []int) []T {
+	sorted := make([]T, len(order))
+	for i, index := range order {
+		sorted[i] = values[index]
+	}
+	return sorted
+}
+
+// formatTimes formats times the way echarts parses them, leaving out the
+// clock when all times are at midnight
+func formatTimes(times []time.Time) []string {
+	layout := "2006-01-02"
+	for _, t := range times {
+		if hour, minute, second := t.Clock(); hour+minute+second+t.Nanosecond() != 0 {
+			layout = "2006-01-02 15:04:05"
+			break
+		}
+	}
+
+	formatted := make([]string, len(times))
+	for i, t := range times {
+		formatted[i] = t.Format(layout)
+	}
+	return formatted
+}
+
+// legendOpts shows the legend when a chart has more than one series
+func legendOpts(seriesCount int) opts.Legend {
+	return opts.Legend{Show: opts.Bool(seriesCount > 1), Top: "bottom"}
+}
+
+// stackName returns the echarts stack group for the series, empty when grouped
+func stackName(spec ChartSpec) string {
+	if spec.Stacked {
+		return "total"
+	}
+	return ""
+}
@@ -2,66 +2,91 @@
 
 import (
 	"fmt"
-	"os"
+	"graph-viewer/dataset"
+	"io"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
+func init() {
+	Register(&builtinChart{
+		name:        "ThemeRiver",
+		title:       "Theme River",
+		description: "Show changes over time",
+		preview:     "ThemeRiver",
+		roles: []Role{
+			{Name: "Time", Description: "Date or time of each value", Type: TimeValue, Min: 1, Max: 1},
+			{Name: "Value", Description: "Several columns draw one stream each", Type: NumericValue, Min: 1, Max: Unlimited},
+			{Name: "Category", Description: "Stream of each value when a single Value column is used", Min: 0, Max: 1},
+		},
+		render: renderThemeRiverChart,
+	})
+}
+
 // GenerateThemeRiverChart creates a ThemeRiver chart from the given data
+// and writes it to a file, returning the file path
 func GenerateThemeRiverChart(data [][]string) (string, error) {
-	if len(data) < 2 || len(data[0]) < 3 {
-		return "", fmt.Errorf("themeriver chart requires at least 3 columns: Time, Value, Category")
+	return GenerateGraph(data, "ThemeRiver")
+}
+
+// renderThemeRiverChart writes the ThemeRiver chart as HTML to w
+func renderThemeRiverChart(w io.Writer, spec ChartSpec, data *dataset.Dataset, cols roleColumns) error {
+	if data.Len() == 0 {
+		return &InsufficientDataError{Chart: "ThemeRiver chart"}
 	}
 
-	// Extract data points
-	points := []opts.ThemeRiverData{}
-	for i, row := range data[1:] { // Skip header row
-		if len(row) < 3 {
-			continue // Skip rows with insufficient columns
-		}
+	timeIndex, categoryIndex := cols.index("Time"), cols.index("Category")
+	valueIndices := cols["Value"]
+	if categoryIndex != -1 && len(valueIndices) > 1 {
+		return fmt.Errorf("themeriver chart takes either a Category column or several Value columns, not both")
+	}
 
-		time := row[0]
-		value, err := parseNumericValue(row[1])
-		if err != nil {
-			fmt.Printf("Skipping invalid row %d: %v\n", i+1, err)
+	// Extract data points. With several value columns each column is a stream
+	// named after its header, otherwise the Category column names the stream.
+	points := []opts.ThemeRiverData{}
+	timeCol := data.Columns[timeIndex]
+	dates := formatTimes(timeCol.Times)
+	for i := 0; i < data.Len(); i++ {
+		if _, ok := timeCol.Time(i); !ok {
+			spec.Diagnostics.skip(data, i, timeCol, TimeValue)
 			continue
 		}
-		category := row[2]
+		for _, valueIndex := range valueIndices {
+			valueCol := data.Columns[valueIndex]
+			value, ok := valueCol.Float(i)
+			if !ok {
+				spec.Diagnostics.skip(data, i, valueCol, NumericValue)
+				continue
+			}
+
+			category := valueCol.Name
+			if categoryIndex != -1 {
+				category = data.Columns[categoryIndex].String(i)
+			}
 
-		points = append(points, opts.ThemeRiverData{
-			Name:  category, // The category or type of the flow
-			Value: value,    // Numeric value
-			Date:  time,     // Time or date for the flow
-		})
+			points = append(points, opts.ThemeRiverData{
+				Name:  category, // The category or type of the flow
+				Value: value,    // Numeric value
+				Date:  dates[i], // Time or date for the flow
+			})
+		}
 	}
 
 	if len(points) == 0 {
-		return "", fmt.Errorf("no valid data for ThemeRiver chart")
+		return &InsufficientDataError{Chart: "ThemeRiver chart", Rows: data.Len()}
 	}
 
 	// Create ThemeRiver chart
 	themeriver := charts.NewThemeRiver()
 	themeriver.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title: "ThemeRiver Chart",
+			Title: spec.title("ThemeRiver Chart"),
 		}),
+		charts.WithSingleAxisOpts(opts.SingleAxis{Type: "time"}),
 	)
 
 	themeriver.AddSeries("ThemeRiver", points)
 
-	// Render to file
-	filePath := "themeriver_chart.html"
-	file, err := os.Create(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	err = themeriver.Render(file)
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	return themeriver.Render(w)
 }
@@ -0,0 +1,885 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"graph-viewer/charts"
+	"graph-viewer/dataset"
+	"graph-viewer/logger"
+	"graph-viewer/ui"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK    = 0
+	ExitError = 1 // the command failed, e.g. unreadable file or invalid data
+	ExitUsage = 2 // the command line itself was invalid
+)
+
+// usageError marks errors caused by bad arguments rather than bad data
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"render", "Render a chart without the GUI from text, spreadsheet, JSON, Parquet or SQLite data in a file, archive or folder, optionally joined", runRender},
+	{"list-types", "List the available graph types", runListTypes},
+	{"inspect", "Show the headers and inferred column types of the data render would read", runInspect},
+}
+
+// Run executes the subcommand in args and returns the process exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return ExitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(args[1:], stdout, stderr)
+		switch {
+		case err == nil:
+			return ExitOK
+		case errors.Is(err, flag.ErrHelp):
+			return ExitOK
+		case errors.As(err, new(*usageError)):
+			fmt.Fprintf(stderr, "graph-viewer %s: %v\n", cmd.name, err)
+			return ExitUsage
+		default:
+			logger.LogErrorWithTrace(fmt.Errorf("%s failed: %w", cmd.name, err))
+			fmt.Fprintf(stderr, "graph-viewer %s: %v\n", cmd.name, err)
+			return ExitError
+		}
+	}
+
+	fmt.Fprintf(stderr, "graph-viewer: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return ExitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: graph-viewer [command] [flags]")
+	fmt.Fprintln(w, "\nWithout a command the graphical application is started.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun 'graph-viewer <command> -h' for the flags of a command.")
+}
+
+// usageErrorf wraps a message so Run reports it with ExitUsage
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// parseFlags parses args into fs, converting flag errors into usage errors
+func parseFlags(fs *flag.FlagSet, args []string, stderr io.Writer) error {
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// inputUsage describes the --input flag shared by render and inspect
+const inputUsage = "data to read (required). " +
+	"A CSV, TSV, TXT, LOG, XLSX, XLS, ODS, JSON, NDJSON, Parquet or SQLite file. " +
+	"Files may be compressed (.gz, .zst) or inside a zip archive, as in archive.zip/file.csv. " +
+	"A folder or a quoted glob pattern such as 'reports/2026-*.csv' reads its files as one"
+
+func runRender(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	input := fs.String("input", "", inputUsage)
+	source := addSourceFlags(fs)
+	join := addJoinFlags(fs)
+	graphType := fs.String("type", "", "graph type, see list-types (required)")
+	var roles roleFlag
+	fs.Var(&roles, "role", "columns for a role as Role=col1,col2; repeat for each role")
+	var types typeFlag
+	fs.Var(&types, "as", "override a column type as Column=type, e.g. Year=category or Day=dmy; repeat for each column")
+	numbers := fs.String("numbers", "auto", "number format: auto, point (1,234.56), comma (1.234,56) or a locale such as de")
+	columnList := fs.String("columns", "", "comma separated columns assigned to the roles in order")
+	xAxis := fs.String("x", "", "column for the first role, usually the X axis")
+	yAxis := fs.String("y", "", "column for the second role, usually the Y axis")
+	zAxis := fs.String("z", "", "column for the third role, e.g. the Z axis of 3D graphs")
+	limit := fs.Int("limit", 0, "maximum number of rows to plot, 0 for all; only these rows are read")
+	title := fs.String("title", "", "chart title (default: the graph type's title)")
+	stack := fs.Bool("stack", false, "stack the series of multi-series charts instead of grouping them")
+	invalid := fs.String("invalid", "fail", "values that do not fit their role: fail, skip (leave out the row) or coerce (chart numbers as zero)")
+	diagnosticsFile := fs.String("diagnostics", "", "write the list of skipped rows and coerced values to this CSV file")
+	out := fs.String("out", "", "output HTML file, overwritten if it exists")
+	outDir := fs.String("out-dir", "", "directory for a uniquely named output file when --out is not set")
+	open := fs.Bool("open", false, "open the rendered chart in the default browser")
+	if err := parseFlags(fs, args, stderr); err != nil {
+		return err
+	}
+
+	if *input == "" || *graphType == "" {
+		return usageErrorf("--input and --type are required")
+	}
+	if *out != "" && *outDir != "" {
+		return usageErrorf("--out and --out-dir cannot be combined")
+	}
+	if *limit < 0 {
+		return usageErrorf("--limit must not be negative")
+	}
+
+	chartType, ok := charts.Lookup(*graphType)
+	if !ok {
+		return usageErrorf("unknown graph type %q (see 'graph-viewer list-types')", *graphType)
+	}
+	if *stack && !charts.CanStack(chartType) {
+		return usageErrorf("%s cannot stack series", chartType.Name())
+	}
+	policy, err := charts.ParseValuePolicy(*invalid)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	format, err := dataset.ParseNumberFormat(*numbers)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	joinSpec, err := join.spec()
+	if err != nil {
+		return err
+	}
+
+	// An archive holding one data file reads that file
+	if *input, err = ui.ResolveArchive(*input); err != nil {
+		return err
+	}
+	options, err := source.readOptions(*input, format)
+	if err != nil {
+		return err
+	}
+	if options.Aggregate == nil && options.Sample == 0 && join.file == "" {
+		// Rows past the limit are not plotted, so they need not be read
+		options.Limit = *limit
+	}
+
+	columns, err := roleColumns(chartType, roles, *columnList, []string{*xAxis, *yAxis, *zAxis})
+	if err != nil {
+		return err
+	}
+	if ui.IsParquet(*input) && options.Aggregate == nil {
+		// Parquet stores each column apart, so only the charted ones are read
+		for _, names := range columns {
+			options.Columns = append(options.Columns, names...)
+		}
+		for _, override := range types {
+			options.Columns = append(options.Columns, override.column)
+		}
+		options.Columns = append(options.Columns, joinSpec.LeftKeys...)
+	}
+
+	data, err := readInput(*input, options, format)
+	if err != nil {
+		return err
+	}
+	if err := source.reportRepairs(stderr); err != nil {
+		return err
+	}
+	if data, err = join.apply(data, joinSpec, format, stderr); err != nil {
+		return err
+	}
+
+	for _, names := range columns {
+		for _, column := range names {
+			if data.Index(column) == -1 {
+				return usageErrorf("column %q not found, available columns: %s", column, strings.Join(data.Headers(), ", "))
+			}
+		}
+	}
+
+	for _, override := range types {
+		index := data.Index(override.column)
+		if index == -1 {
+			return usageErrorf("column %q not found, available columns: %s", override.column, strings.Join(data.Headers(), ", "))
+		}
+		if override.format.Auto() {
+			data, err = data.SetType(index, override.t)
+		} else {
+			data, err = data.SetTimeFormat(index, override.format)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	spec := charts.ChartSpec{
+		Type:        chartType.Name(),
+		Title:       *title,
+		Columns:     columns,
+		Stacked:     *stack,
+		Invalid:     policy,
+		Diagnostics: &charts.Diagnostics{},
+	}
+	limits := map[string]int{"X": *limit}
+	selectedData, err := ui.ExtractGraphData(spec, data, limits)
+	if err != nil {
+		return err
+	}
+
+	output := charts.FileOutput{Dir: *outDir}
+	if *out != "" {
+		output = charts.FileOutput{Dir: filepath.Dir(*out), Name: filepath.Base(*out)}
+	}
+
+	graphFile, err := charts.RenderToFile(spec, selectedData, output)
+	// The rows left out explain why no data may have been left to chart
+	if err := reportDiagnostics(spec.Diagnostics, *diagnosticsFile, stderr); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, graphFile)
+
+	if *open {
+		return charts.ShowChartInBrowser(graphFile)
+	}
+	return nil
+}
+
+func runListTypes(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("list-types", flag.ContinueOnError)
+	if err := parseFlags(fs, args, stderr); err != nil {
+		return err
+	}
+
+	for _, chartType := range charts.Types() {
+		fmt.Fprintf(stdout, "%-12s %-20s %s\n", chartType.Name(), chartType.Title(), chartType.Description())
+		for _, role := range chartType.Roles() {
+			var notes []string
+			if role.Type != charts.AnyValue {
+				notes = append(notes, role.Type.String())
+			}
+			if role.Optional() {
+				notes = append(notes, "optional")
+			}
+			if role.Multiple() {
+				notes = append(notes, "multiple")
+			}
+			if role.Multiple() && charts.CanStack(chartType) {
+				notes = append(notes, "stackable")
+			}
+			line := fmt.Sprintf("%-12s   %-12s %s", "", role.Name, strings.Join(notes, ", "))
+			fmt.Fprintln(stdout, strings.TrimRight(line, " "))
+		}
+	}
+	return nil
+}
+
+func runInspect(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	input := fs.String("input", "", inputUsage)
+	source := addSourceFlags(fs)
+	join := addJoinFlags(fs)
+	numbers := fs.String("numbers", "auto", "number format: auto, point (1,234.56), comma (1.234,56) or a locale such as de")
+	if err := parseFlags(fs, args, stderr); err != nil {
+		return err
+	}
+	if *input == "" {
+		return usageErrorf("--input is required")
+	}
+	format, err := dataset.ParseNumberFormat(*numbers)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	joinSpec, err := join.spec()
+	if err != nil {
+		return err
+	}
+	// An archive holding one data file reads that file
+	if *input, err = ui.ResolveArchive(*input); err != nil {
+		return err
+	}
+	options, err := source.readOptions(*input, format)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(*input, options, format)
+	if err != nil {
+		return err
+	}
+	if err := source.reportRepairs(stderr); err != nil {
+		return err
+	}
+	if data, err = join.apply(data, joinSpec, format, stderr); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "File:    %s\n", *input)
+	if ui.IsCombined(*input) {
+		files, err := ui.ListCombined(*input)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", *input, err)
+		}
+		names := make([]string, len(files))
+		for i, file := range files {
+			names[i] = filepath.Base(file)
+		}
+		fmt.Fprintf(stdout, "Files:   %s\n", strings.Join(names, ", "))
+	}
+	if options.Lines != nil {
+		if options.Lines.Pattern != "" {
+			fmt.Fprintf(stdout, "Format:  pattern %s\n", options.Lines.Pattern)
+		} else {
+			fmt.Fprintf(stdout, "Format:  fixed width, columns at %s\n", strings.Trim(strings.Join(strings.Fields(fmt.Sprint(options.Lines.Starts)), ", "), "[]"))
+		}
+	} else if ui.IsDelimited(*input) {
+		dialect := options.Dialect
+		if dialect == nil {
+			detected, err := ui.DetectDialect(*input)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", *input, err)
+			}
+			dialect = &detected
+		}
+		fmt.Fprintf(stdout, "Format:  %s\n", dialect)
+	}
+	if ui.IsWorkbook(*input) {
+		contents, err := ui.ListWorkbook(*input)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", *input, err)
+		}
+		fmt.Fprintf(stdout, "Sheets:  %s (active: %s)\n", strings.Join(contents.Sheets, ", "), contents.Active)
+		if len(contents.Tables) > 0 {
+			fmt.Fprintf(stdout, "Tables:  %s\n", strings.Join(contents.Tables, ", "))
+		}
+	}
+	if ui.IsSQLite(*input) {
+		tables, err := ui.ListTables(*input)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", *input, err)
+		}
+		fmt.Fprintf(stdout, "Tables:  %s\n", strings.Join(tables, ", "))
+	}
+	fmt.Fprintf(stdout, "Rows:    %d\n", data.Len())
+	fmt.Fprintf(stdout, "Columns: %d\n\n", len(data.Columns))
+
+	for i, col := range data.Columns {
+		inferred := fmt.Sprintf("%.0f%%", col.Confidence*100)
+		if col.Declared {
+			inferred = "declared"
+		} else if col.Confidence < dataset.MinConfidence {
+			inferred += ", kept as " + col.Kind.String()
+		} else if col.Type.Numeric() {
+			inferred += " as " + col.Number.Name
+		} else if col.Kind == dataset.KindTime {
+			inferred += " as " + col.TimeFormat.Name
+		}
+		fmt.Fprintf(stdout, "%3d  %-30s %-11s %-22s %d empty\n", i+1, col.Name, col.Type, inferred, col.NullCount())
+	}
+	return nil
+}
+
+// roleFlag collects repeated --role Role=col1,col2 arguments
+type roleFlag map[string][]string
+
+func (f *roleFlag) String() string {
+	return fmt.Sprint(map[string][]string(*f))
+}
+
+func (f *roleFlag) Set(value string) error {
+	role, list, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(role) == "" {
+		return fmt.Errorf("expected Role=column[,column...], got %q", value)
+	}
+	if *f == nil {
+		*f = make(roleFlag)
+	}
+	role = strings.TrimSpace(role)
+	(*f)[role] = append((*f)[role], splitList(list)...)
+	return nil
+}
+
+// sourceFlags are the flags selecting the part of a file to read and which
+// of its rows to keep
+type sourceFlags struct {
+	options   ui.ReadOptions
+	memory    int64
+	groupBy   string
+	aggregate string
+
+	ragged  string
+	repairs string // file the list of repaired rows is written to
+	report  ui.RepairReport
+
+	// dialect overrides, empty or negative to keep what is detected
+	encoding, delimiter, quote, comment string
+	skipLines                           int
+
+	// line format of text that is not delimited
+	fixed, names, pattern      string
+	lineFormat, saveLineFormat string
+
+	arrays string
+}
+
+// addSourceFlags registers the source flags
+func addSourceFlags(fs *flag.FlagSet) *sourceFlags {
+	f := &sourceFlags{}
+	fs.StringVar(&f.options.Sheet, "sheet", "", "worksheet of an XLSX, XLS or ODS file to read (default: the active sheet)")
+	fs.StringVar(&f.options.Table, "table", "", "table or named range of an XLSX file to read instead of a sheet, or table of an SQLite database")
+	fs.StringVar(&f.options.Query, "query", "", "SQL query whose result is read from an SQLite database instead of a table")
+	fs.StringVar(&f.options.Range, "range", "", "cell range of the sheet to read, e.g. B2:F100")
+	fs.StringVar(&f.encoding, "encoding", "", "character encoding of a text file, e.g. utf-16le, shift_jis or windows-1252 (default: detected)")
+	fs.StringVar(&f.delimiter, "delimiter", "", "field delimiter of a text file, e.g. ';', tab or pipe (default: detected)")
+	fs.StringVar(&f.quote, "quote", "", "quote character of a text file, or none (default: detected)")
+	fs.StringVar(&f.comment, "comment", "", "lines of a text file starting with this character are skipped, or none (default: detected)")
+	fs.IntVar(&f.skipLines, "skip-lines", -1, "lines above the header of a text file (default: detected)")
+	fs.StringVar(&f.fixed, "fixed", "", "read a text file as fixed-width columns starting at these character positions, e.g. 0,12,30, or auto to find them from how the lines align")
+	fs.StringVar(&f.names, "names", "", "comma separated names of the --fixed columns (default: the first line)")
+	fs.StringVar(&f.pattern, "pattern", "", "read a text file with a regular expression whose named groups are the columns, skipping lines it does not match")
+	fs.StringVar(&f.lineFormat, "line-format", "", "read a text file with the fixed-width columns or pattern saved in this file")
+	fs.StringVar(&f.saveLineFormat, "save-line-format", "", "save the line format given by --fixed or --pattern to this file for --line-format")
+	fs.StringVar(&f.options.SourceColumn, "source-column", "", "add a column with this name holding the file of each row when --input is a folder or glob pattern")
+	fs.StringVar(&f.arrays, "arrays", "", "arrays in JSON records: join (one cell, comma separated) or explode (one row per element) (default: join)")
+	fs.StringVar(&f.ragged, "ragged", "fail", "rows with more or fewer fields than the header: fail, truncate (pad short rows, drop extra fields) or merge (pad short rows, join extra fields into the last)")
+	fs.StringVar(&f.repairs, "repairs", "", "write the list of repaired rows to this CSV file")
+	fs.IntVar(&f.options.Sample, "sample", 0, "read a random sample of this many rows spread over the file")
+	fs.Int64Var(&f.memory, "memory", ui.DefaultMemoryBudget>>20, "memory budget in MB for the rows read")
+	fs.StringVar(&f.aggregate, "aggregate", "", "summarize the rows while reading with sum, mean, min, max or count")
+	fs.StringVar(&f.groupBy, "group-by", "", "column whose values form the groups summarized by --aggregate")
+	return f
+}
+
+// readOptions validates the flags and returns the options for reading input,
+// reading aggregated numbers in the given format
+func (f *sourceFlags) readOptions(input string, format dataset.NumberFormat) (ui.ReadOptions, error) {
+	options := f.options
+	if options.Sample < 0 || f.memory <= 0 {
+		return options, usageErrorf("--sample must not be negative and --memory must be positive")
+	}
+	options.MemoryBudget = f.memory << 20
+
+	ragged, err := ui.ParseRowRepair(f.ragged)
+	if err != nil {
+		return options, usageErrorf("%v", err)
+	}
+	options.Ragged, options.OnRepair = ragged, f.report.Add
+
+	if f.fixed != "" || f.pattern != "" || f.lineFormat != "" || ui.IsLog(input) {
+		format, err := f.lines(input)
+		if err != nil {
+			return options, err
+		}
+		options.Lines = &format
+	} else if f.names != "" || f.saveLineFormat != "" {
+		return options, usageErrorf("--names and --save-line-format need --fixed, --pattern or --line-format")
+	} else if f.encoding != "" || f.delimiter != "" || f.quote != "" || f.comment != "" || f.skipLines >= 0 {
+		dialect, err := f.dialect(input)
+		if err != nil {
+			return options, err
+		}
+		options.Dialect = &dialect
+	}
+
+	if options.SourceColumn != "" && !ui.IsCombined(input) {
+		return options, usageErrorf("--source-column only applies to a folder or glob pattern of files")
+	}
+
+	if options.Query != "" {
+		if !ui.IsSQLite(input) {
+			return options, usageErrorf("--query only applies to SQLite databases")
+		}
+		if options.Table != "" {
+			return options, usageErrorf("--query and --table cannot be combined")
+		}
+	}
+
+	if f.arrays != "" {
+		if !ui.IsJSON(input) {
+			return options, usageErrorf("--arrays only applies to JSON files")
+		}
+		if options.Arrays, err = ui.ParseArrayHandling(f.arrays); err != nil {
+			return options, usageErrorf("%v", err)
+		}
+	}
+
+	if f.aggregate == "" {
+		if f.groupBy != "" {
+			return options, usageErrorf("--group-by needs --aggregate")
+		}
+		return options, nil
+	}
+	function, err := dataset.ParseAggregateFunction(f.aggregate)
+	if err != nil {
+		return options, usageErrorf("%v", err)
+	}
+	if options.Sample > 0 {
+		return options, usageErrorf("--sample cannot be combined with --aggregate")
+	}
+	options.Aggregate = &dataset.Aggregation{GroupBy: f.groupBy, Function: function, Number: format}
+	return options, nil
+}
+
+// reportRepairs tells how many rows were repaired while reading and writes
+// their list when asked to
+func (f *sourceFlags) reportRepairs(stderr io.Writer) error {
+	if f.report.Len() > 0 {
+		fmt.Fprintf(stderr, "repaired rows: %s\n", f.report.Summary())
+	}
+	if f.repairs == "" {
+		return nil
+	}
+
+	file, err := os.Create(f.repairs)
+	if err != nil {
+		return err
+	}
+	if err := f.report.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// reportDiagnostics tells how many rows were skipped or changed to fit the
+// chart and writes their list to path when it is set
+func reportDiagnostics(diagnostics *charts.Diagnostics, path string, stderr io.Writer) error {
+	if diagnostics.Len() > 0 {
+		fmt.Fprintf(stderr, "invalid values: %s\n", diagnostics.Summary())
+	}
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := diagnostics.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// dialect detects the dialect of a text file and applies the overrides
+func (f *sourceFlags) dialect(input string) (ui.Dialect, error) {
+	if !ui.IsDelimited(input) {
+		return ui.Dialect{}, usageErrorf("--encoding, --delimiter, --quote, --comment and --skip-lines only apply to text files")
+	}
+	encoding := ""
+	if f.encoding != "" {
+		var err error
+		if encoding, err = ui.ParseEncoding(f.encoding); err != nil {
+			return ui.Dialect{}, usageErrorf("%v", err)
+		}
+	}
+	dialect, err := ui.DetectDialectWithEncoding(input, encoding)
+	if err != nil {
+		return dialect, fmt.Errorf("reading %s: %w", input, err)
+	}
+
+	for _, override := range []struct {
+		flag, value string
+		char        *rune
+	}{
+		{"delimiter", f.delimiter, &dialect.Delimiter},
+		{"quote", f.quote, &dialect.Quote},
+		{"comment", f.comment, &dialect.Comment},
+	} {
+		if override.value == "" {
+			continue
+		}
+		r, err := ui.ParseDialectChar(override.value)
+		if err != nil {
+			return dialect, usageErrorf("--%s: %v", override.flag, err)
+		}
+		*override.char = r
+	}
+	if dialect.Delimiter == 0 {
+		return dialect, usageErrorf("--delimiter cannot be none")
+	}
+	if f.skipLines >= 0 {
+		dialect.SkipLines = f.skipLines
+	}
+	return dialect, nil
+}
+
+// lines returns the line format given by the flags, loaded from a file or
+// set by --fixed or --pattern, and saves it when asked to
+func (f *sourceFlags) lines(input string) (ui.LineFormat, error) {
+	var format ui.LineFormat
+	switch {
+	case !ui.IsDelimited(input) && !ui.IsLog(input):
+		return format, usageErrorf("--fixed, --pattern and --line-format only apply to text files")
+	case f.delimiter != "" || f.quote != "" || f.comment != "":
+		return format, usageErrorf("--delimiter, --quote and --comment cannot be combined with a line format")
+	case f.fixed != "" && f.pattern != "":
+		return format, usageErrorf("--fixed and --pattern cannot be combined")
+	case f.fixed == "" && f.pattern == "" && f.lineFormat == "":
+		return format, usageErrorf("%s files need --fixed, --pattern or --line-format", filepath.Ext(input))
+	case f.names != "" && f.fixed == "":
+		return format, usageErrorf("--names only applies to --fixed")
+	}
+
+	if f.lineFormat != "" {
+		var err error
+		if format, err = ui.LoadLineFormat(f.lineFormat); err != nil {
+			return format, usageErrorf("%v", err)
+		}
+	}
+	if f.encoding != "" {
+		var err error
+		if format.Encoding, err = ui.ParseEncoding(f.encoding); err != nil {
+			return format, usageErrorf("%v", err)
+		}
+	}
+	if f.skipLines >= 0 {
+		format.SkipLines = f.skipLines
+	}
+
+	switch {
+	case f.fixed == "auto":
+		starts, err := ui.SuggestColumns(input, format)
+		if err != nil {
+			return format, fmt.Errorf("reading %s: %w", input, err)
+		}
+		format.Starts, format.Headers, format.Pattern = starts, splitList(f.names), ""
+	case f.fixed != "":
+		starts, err := ui.ParseColumnStarts(f.fixed)
+		if err != nil {
+			return format, usageErrorf("--fixed: %v", err)
+		}
+		format.Starts, format.Headers, format.Pattern = starts, splitList(f.names), ""
+	case f.pattern != "":
+		format.Starts, format.Headers, format.Pattern = nil, nil, f.pattern
+	}
+	if err := format.Validate(); err != nil {
+		return format, usageErrorf("%v", err)
+	}
+
+	if f.saveLineFormat != "" {
+		if err := ui.SaveLineFormat(f.saveLineFormat, format); err != nil {
+			return format, err
+		}
+	}
+	return format, nil
+}
+
+// joinFlags are the flags joining a second file to the input on key columns
+type joinFlags struct {
+	file, on, how string
+}
+
+// addJoinFlags registers the join flags
+func addJoinFlags(fs *flag.FlagSet) *joinFlags {
+	f := &joinFlags{}
+	fs.StringVar(&f.file, "join", "", "join the rows of this file, read with the detected settings, to the input rows whose key columns match")
+	fs.StringVar(&f.on, "on", "", "comma separated key columns of --join, as Column or InputColumn=JoinColumn when their names differ (default: the first column both files have)")
+	fs.StringVar(&f.how, "how", "inner", "rows kept by --join: inner (matched in both files), left (every input row) or outer (every row of both)")
+	return f
+}
+
+// spec validates the flags and returns the join they describe. Without
+// --on the key columns are only known once both files are read.
+func (f *joinFlags) spec() (dataset.Join, error) {
+	var join dataset.Join
+	if f.file == "" {
+		if f.on != "" || f.how != "inner" {
+			return join, usageErrorf("--on and --how need --join")
+		}
+		return join, nil
+	}
+	kind, err := dataset.ParseJoinKind(f.how)
+	if err != nil {
+		return join, usageErrorf("--how: %v", err)
+	}
+	join.Kind = kind
+	for _, key := range splitList(f.on) {
+		left, right, paired := strings.Cut(key, "=")
+		left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+		if !paired {
+			right = left
+		}
+		if left == "" || right == "" {
+			return join, usageErrorf("--on: invalid key %q, use Column or InputColumn=JoinColumn", key)
+		}
+		join.LeftKeys = append(join.LeftKeys, left)
+		join.RightKeys = append(join.RightKeys, right)
+	}
+	return join, nil
+}
+
+// apply reads the --join file and joins its rows to data as join says,
+// telling the size of the result and which keys did not match or repeat
+func (f *joinFlags) apply(data *dataset.Dataset, join dataset.Join, format dataset.NumberFormat, stderr io.Writer) (*dataset.Dataset, error) {
+	if f.file == "" {
+		return data, nil
+	}
+	path, err := ui.ResolveArchive(f.file)
+	if err != nil {
+		return nil, err
+	}
+	right, err := readInput(path, ui.ReadOptions{}, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(join.LeftKeys) == 0 {
+		key := ""
+		for _, name := range data.Headers() {
+			if right.Index(name) != -1 {
+				key = name
+				break
+			}
+		}
+		if key == "" {
+			return nil, usageErrorf("the input and %s share no column, name the keys to join on with --on", filepath.Base(path))
+		}
+		join.LeftKeys = []string{key}
+	}
+
+	joined, report, err := data.Join(right, join)
+	for _, warning := range report.Warnings() {
+		fmt.Fprintf(stderr, "join: %s\n", warning)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("joining %s: %w", path, err)
+	}
+	fmt.Fprintf(stderr, "joined %s: %s\n", path, report.Summary())
+	if format != dataset.AutoNumberFormat {
+		return joined.WithNumberFormat(format)
+	}
+	return joined, nil
+}
+
+// readInput reads a data file, reading numbers in the given format.
+// Aggregated values are already plain numbers.
+func readInput(path string, options ui.ReadOptions, format dataset.NumberFormat) (*dataset.Dataset, error) {
+	data, err := ui.ReadData(path, options)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if format != dataset.AutoNumberFormat && options.Aggregate == nil {
+		return data.WithNumberFormat(format)
+	}
+	return data, nil
+}
+
+// typeFlag collects repeated --as Column=type overrides in order
+type typeFlag []typeOverride
+
+type typeOverride struct {
+	column string
+	t      dataset.SemanticType
+	format dataset.TimeFormat // for date/time columns, auto when not given
+}
+
+func (f *typeFlag) String() string {
+	var values []string
+	for _, o := range *f {
+		if o.format.Auto() {
+			values = append(values, o.column+"="+o.t.String())
+		} else {
+			values = append(values, o.column+"="+o.format.Name)
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+func (f *typeFlag) Set(value string) error {
+	column, name, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(column) == "" {
+		return fmt.Errorf("expected Column=type, got %q", value)
+	}
+	override := typeOverride{column: strings.TrimSpace(column)}
+	t, err := dataset.ParseSemanticType(strings.TrimSpace(name))
+	if err != nil {
+		// A time format such as dmy or excel implies a date/time column
+		format, formatErr := dataset.ParseTimeFormat(name)
+		if formatErr != nil {
+			return fmt.Errorf("%v or time format", err)
+		}
+		t, override.format = dataset.TypeDateTime, format
+	}
+	override.t = t
+	*f = append(*f, override)
+	return nil
+}
+
+// roleColumns builds the role to column mapping from --role, or from the
+// --columns list or --x/--y/--z shorthands assigned to the roles in order
+func roleColumns(chartType charts.ChartType, roles roleFlag, columnList string, shorthands []string) (map[string][]string, error) {
+	if len(roles) > 0 {
+		if columnList != "" {
+			return nil, usageErrorf("--role cannot be combined with --columns")
+		}
+		columns := make(map[string][]string)
+		for name, list := range roles {
+			role, ok := findRole(chartType, name)
+			if !ok {
+				return nil, usageErrorf("%s has no role %q (see 'graph-viewer list-types')", chartType.Name(), name)
+			}
+			columns[role.Name] = list
+		}
+		if err := charts.ValidateColumns(chartType, columns); err != nil {
+			return nil, usageErrorf("%v", err)
+		}
+		return columns, nil
+	}
+
+	var names []string
+	if columnList != "" {
+		names = splitList(columnList)
+	} else {
+		for _, column := range shorthands {
+			if column != "" {
+				names = append(names, column)
+			}
+		}
+	}
+
+	columns, err := charts.AssignColumns(chartType, names)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+	return columns, nil
+}
+
+func findRole(chartType charts.ChartType, name string) (charts.Role, bool) {
+	for _, role := range chartType.Roles() {
+		if strings.EqualFold(role.Name, name) {
+			return role, true
+		}
+	}
+	return charts.Role{}, false
+}
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
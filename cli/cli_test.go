@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSales = "Region,Units,Price\nNorth,3,1.5\nSouth,4,2.5\nEast,5,n/a\n"
+
+// writeSales writes a CSV file to a temporary directory
+func writeSales(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sales.csv")
+	if err := os.WriteFile(path, []byte(testSales), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	input := writeSales(t)
+	out := filepath.Join(t.TempDir(), "chart.html")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string // text the output must contain
+		stderr string // text the errors must contain
+	}{
+		{"usage", nil, ExitOK, "Commands:", ""},
+		{"unknown command", []string{"plot"}, ExitUsage, "", `unknown command "plot"`},
+
+		{"list types", []string{"list-types"}, ExitOK, "X Axis", ""},
+		{"list types arguments", []string{"list-types", "Bar"}, ExitUsage, "", "unexpected arguments: Bar"},
+
+		{"render", []string{"render", "--input", input, "--type", "bar", "--x", "Region", "--y", "Units", "--out", out}, ExitOK, out, ""},
+		{"render help", []string{"render", "-h"}, ExitOK, "", "-input"},
+		{"render unknown flag", []string{"render", "--input", input, "--type", "Bar", "--colour", "red"}, ExitUsage, "", "flag provided but not defined: -colour"},
+		{"render missing type", []string{"render", "--input", input}, ExitUsage, "", "--input and --type are required"},
+		{"render unknown type", []string{"render", "--input", input, "--type", "Donut", "--x", "Region"}, ExitUsage, "", `unknown graph type "Donut"`},
+		{"render unknown role", []string{"render", "--input", input, "--type", "Pie", "--role", "Size=Units"}, ExitUsage, "", `Pie has no role "Size"`},
+		// Checked before the input is read, which does not exist here
+		{"render unstackable", []string{"render", "--input", filepath.Join(t.TempDir(), "missing.csv"), "--type", "Pie", "--x", "Region", "--y", "Units", "--stack"}, ExitUsage, "", "Pie cannot stack series"},
+		{"render missing column", []string{"render", "--input", input, "--type", "Bar", "--x", "Region", "--y", "Revenue"}, ExitUsage, "", `column "Revenue" not found, available columns: Region, Units, Price`},
+		{"render bad number format", []string{"render", "--input", input, "--type", "Bar", "--x", "Region", "--y", "Units", "--numbers", "roman"}, ExitUsage, "", "roman"},
+		{"render missing file", []string{"render", "--input", filepath.Join(t.TempDir(), "missing.csv"), "--type", "Bar", "--x", "Region", "--y", "Units"}, ExitError, "", "reading"},
+		{"render invalid values", []string{"render", "--input", input, "--type", "Bar", "--x", "Region", "--y", "Price", "--as", "Price=numeric", "--out", out}, ExitError, "", "n/a"},
+
+		{"inspect", []string{"inspect", "--input", input}, ExitOK, "Rows:    3", ""},
+		{"inspect missing input", []string{"inspect"}, ExitUsage, "", "--input is required"},
+		{"inspect missing file", []string{"inspect", "--input", filepath.Join(t.TempDir(), "missing.csv")}, ExitError, "", "no such file"},
+		{"inspect join without file", []string{"inspect", "--input", input, "--on", "Region"}, ExitUsage, "", "--on and --how need --join"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(tt.args, &stdout, &stderr)
+			if code != tt.code {
+				t.Errorf("exit code = %d, want %d; stderr: %s", code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("output %q does not contain %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("errors %q do not contain %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
+
+func TestInspectListsColumns(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"inspect", "--input", writeSales(t)}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+	for _, line := range []string{"Columns: 3", "Region", "Units                          integer", "Price"} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("output does not contain %q:\n%s", line, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AggregateFunction combines the values of a group of rows into one
+type AggregateFunction int
+
+const (
+	AggregateSum AggregateFunction = iota
+	AggregateMean
+	AggregateMin
+	AggregateMax
+	AggregateCount
+)
+
+// AggregateFunctions lists the functions in the order offered to users
+var AggregateFunctions = []AggregateFunction{AggregateSum, AggregateMean, AggregateMin, AggregateMax, AggregateCount}
+
+func (f AggregateFunction) String() string {
+	switch f {
+	case AggregateMean:
+		return "mean"
+	case AggregateMin:
+		return "min"
+	case AggregateMax:
+		return "max"
+	case AggregateCount:
+		return "count"
+	default:
+		return "sum"
+	}
+}
+
+// ParseAggregateFunction returns the function with the given name
+func ParseAggregateFunction(name string) (AggregateFunction, error) {
+	name = strings.TrimSpace(name)
+	for _, f := range AggregateFunctions {
+		if strings.EqualFold(f.String(), name) {
+			return f, nil
+		}
+	}
+	if strings.EqualFold(name, "avg") || strings.EqualFold(name, "average") {
+		return AggregateMean, nil
+	}
+	return AggregateSum, fmt.Errorf("unknown aggregate function %q, use sum, mean, min, max or count", name)
+}
+
+// Aggregation describes how rows are summarized by group
+type Aggregation struct {
+	GroupBy  string // column whose distinct values form the groups, empty for one group of all rows
+	Function AggregateFunction
+
+	// Columns are the columns to aggregate. When empty, every other column
+	// holding numbers is aggregated. AggregateCount ignores them and counts
+	// the rows of each group.
+	Columns []string
+
+	// Number is the format of the aggregated values, AutoNumberFormat to
+	// detect it per column from the first rows
+	Number NumberFormat
+}
+
+// Aggregator computes an aggregation over rows added one at a time. Its
+// memory use grows with the number of groups rather than the number of rows,
+// so files too large to load can still be summarized.
+type Aggregator struct {
+	spec    Aggregation
+	key     int      // index of the GroupBy column, -1 for a single group
+	columns []int    // indices of the aggregated columns
+	names   []string // names of the aggregated columns
+	formats []NumberFormat
+
+	// pending holds the first rows until the number formats are detected
+	pending    [][]string
+	pendingRow []int
+
+	groups map[string]*group
+	order  []string // group keys in order of first appearance
+	size   int64
+}
+
+// group holds the running statistics of one group
+type group struct {
+	sourceRow int // source row of the first row of the group
+	rows      int
+	stats     []stat
+}
+
+// stat holds the running statistics of one column of a group
+type stat struct {
+	count         int
+	sum, min, max float64
+}
+
+// NewAggregator prepares an aggregation of rows with the given headers
+func NewAggregator(headers []string, spec Aggregation) (*Aggregator, error) {
+	a := &Aggregator{spec: spec, key: -1, groups: make(map[string]*group)}
+	if spec.GroupBy != "" {
+		if a.key = indexOf(headers, spec.GroupBy); a.key == -1 {
+			return nil, fmt.Errorf("column %q not found, available columns: %s", spec.GroupBy, strings.Join(headers, ", "))
+		}
+	}
+
+	if spec.Function != AggregateCount {
+		for _, name := range spec.Columns {
+			index := indexOf(headers, name)
+			if index == -1 {
+				return nil, fmt.Errorf("column %q not found, available columns: %s", name, strings.Join(headers, ", "))
+			}
+			a.columns, a.names = append(a.columns, index), append(a.names, name)
+		}
+		if len(spec.Columns) == 0 {
+			for i, name := range headers {
+				if i != a.key {
+					a.columns, a.names = append(a.columns, i), append(a.names, name)
+				}
+			}
+		}
+	}
+	return a, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add adds a row whose row number in the source is sourceRow
+func (a *Aggregator) Add(row []string, sourceRow int) {
+	if a.formats == nil {
+		a.pending = append(a.pending, row)
+		a.pendingRow = append(a.pendingRow, sourceRow)
+		if len(a.pending) == DefaultSampleSize {
+			a.flush()
+		}
+		return
+	}
+
+	key := ""
+	if a.key != -1 {
+		key = row[a.key]
+	}
+	g, ok := a.groups[key]
+	if !ok {
+		g = &group{sourceRow: sourceRow, stats: make([]stat, len(a.columns))}
+		a.groups[key] = g
+		a.order = append(a.order, key)
+		a.size += int64(len(key)) + 64 + 32*int64(len(a.columns))
+	}
+
+	g.rows++
+	for i, index := range a.columns {
+		if strings.TrimSpace(row[index]) == "" {
+			continue
+		}
+		v, err := a.formats[i].Parse(row[index])
+		if err != nil {
+			continue
+		}
+		s := &g.stats[i]
+		if s.count == 0 {
+			s.min, s.max = v, v
+		}
+		s.count++
+		s.sum += v
+		s.min, s.max = math.Min(s.min, v), math.Max(s.max, v)
+	}
+}
+
+// flush detects the number format of each column from the pending rows and
+// adds them
+func (a *Aggregator) flush() {
+	a.formats = make([]NumberFormat, len(a.columns))
+	for i, index := range a.columns {
+		a.formats[i] = a.spec.Number
+		if a.formats[i].Decimal == 0 {
+			values := make([]string, len(a.pending))
+			for j, row := range a.pending {
+				values[j] = row[index]
+			}
+			a.formats[i] = DetectNumberFormat(values)
+		}
+	}
+
+	pending, pendingRow := a.pending, a.pendingRow
+	a.pending, a.pendingRow = nil, nil
+	for i, row := range pending {
+		a.Add(row, pendingRow[i])
+	}
+}
+
+// Size estimates the memory held by the aggregator in bytes
+func (a *Aggregator) Size() int64 {
+	size := a.size
+	for _, row := range a.pending {
+		size += RowSize(row)
+	}
+	return size
+}
+
+// RowSize estimates the memory held by a row of cells in bytes
+func RowSize(row []string) int64 {
+	size := int64(24 + 16*len(row))
+	for _, cell := range row {
+		size += int64(len(cell))
+	}
+	return size
+}
+
+// Dataset returns the aggregated data: the GroupBy column followed by one
+// column per aggregated column, named e.g. "Revenue (sum)", or a single
+// "Count" column. Groups are in order of first appearance. Values of the
+// aggregated columns that are not numbers are ignored, and columns without
+// any number are left out unless they were asked for.
+func (a *Aggregator) Dataset() (*Dataset, error) {
+	if a.formats == nil {
+		a.flush()
+	}
+
+	var headers []string
+	if a.key != -1 {
+		headers = append(headers, a.spec.GroupBy)
+	}
+
+	// Keep the columns that hold numbers
+	var kept []int
+	if a.spec.Function == AggregateCount {
+		headers = append(headers, "Count")
+	} else {
+		for i, name := range a.names {
+			numbers := false
+			for _, g := range a.groups {
+				numbers = numbers || g.stats[i].count > 0
+			}
+			if !numbers && len(a.spec.Columns) > 0 {
+				return nil, fmt.Errorf("column %s holds no numbers to aggregate", name)
+			}
+			if numbers {
+				kept = append(kept, i)
+				headers = append(headers, fmt.Sprintf("%s (%s)", name, a.spec.Function))
+			}
+		}
+		if len(kept) == 0 {
+			return nil, fmt.Errorf("no column holds numbers to aggregate")
+		}
+	}
+
+	rows := make([][]string, 0, len(a.order))
+	sourceRows := make([]int, 0, len(a.order))
+	for _, key := range a.order {
+		g := a.groups[key]
+		var row []string
+		if a.key != -1 {
+			row = append(row, key)
+		}
+		if a.spec.Function == AggregateCount {
+			row = append(row, strconv.Itoa(g.rows))
+		}
+		for _, i := range kept {
+			row = append(row, g.stats[i].value(a.spec.Function))
+		}
+		rows = append(rows, row)
+		sourceRows = append(sourceRows, g.sourceRow)
+	}
+	return New(headers, rows, sourceRows)
+}
+
+// value returns the aggregated value as text, empty when the group had no
+// numbers in the column
+func (s stat) value(f AggregateFunction) string {
+	if s.count == 0 {
+		return ""
+	}
+	var v float64
+	switch f {
+	case AggregateMean:
+		v = s.sum / float64(s.count)
+	case AggregateMin:
+		v = s.min
+	case AggregateMax:
+		v = s.max
+	default:
+		v = s.sum
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
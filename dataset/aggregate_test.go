@@ -0,0 +1,98 @@
+package dataset
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	headers := []string{"Region", "Revenue", "Note"}
+	rows := [][]string{
+		{"North", "10", "a"},
+		{"South", "1.5", "b"},
+		{"North", "20", ""},
+		{"South", "n/a", "c"},
+		{"East", "", "d"},
+	}
+
+	tests := []struct {
+		spec    Aggregation
+		headers []string
+		values  []string // the last column
+	}{
+		{Aggregation{GroupBy: "Region", Function: AggregateSum}, []string{"Region", "Revenue (sum)"}, []string{"30", "1.5", ""}},
+		{Aggregation{GroupBy: "Region", Function: AggregateMean}, []string{"Region", "Revenue (mean)"}, []string{"15", "1.5", ""}},
+		{Aggregation{GroupBy: "Region", Function: AggregateMax}, []string{"Region", "Revenue (max)"}, []string{"20", "1.5", ""}},
+		{Aggregation{GroupBy: "Region", Function: AggregateCount}, []string{"Region", "Count"}, []string{"2", "2", "1"}},
+		{Aggregation{Function: AggregateMin}, []string{"Revenue (min)"}, []string{"1.5"}},
+	}
+	for _, tt := range tests {
+		a, err := NewAggregator(headers, tt.spec)
+		if err != nil {
+			t.Fatalf("NewAggregator(%+v) failed: %v", tt.spec, err)
+		}
+		for i, row := range rows {
+			a.Add(row, i+2)
+		}
+		data, err := a.Dataset()
+		if err != nil {
+			t.Fatalf("%s: Dataset failed: %v", tt.spec.Function, err)
+		}
+		if !reflect.DeepEqual(data.Headers(), tt.headers) {
+			t.Errorf("%s: headers = %v, want %v", tt.spec.Function, data.Headers(), tt.headers)
+		}
+		if got := data.Columns[len(data.Columns)-1].Raw; !reflect.DeepEqual(got, tt.values) {
+			t.Errorf("%s: values = %q, want %q", tt.spec.Function, got, tt.values)
+		}
+	}
+}
+
+func TestAggregatorDetectsNumberFormat(t *testing.T) {
+	a, err := NewAggregator([]string{"Day", "Amount"}, Aggregation{GroupBy: "Day", Columns: []string{"Amount"}})
+	if err != nil {
+		t.Fatalf("NewAggregator failed: %v", err)
+	}
+	// More rows than are buffered for the detection
+	for i := 0; i < DefaultSampleSize+10; i++ {
+		a.Add([]string{"Mon", "1.000,5"}, i+2)
+	}
+	data, err := a.Dataset()
+	if err != nil {
+		t.Fatalf("Dataset failed: %v", err)
+	}
+	want := strconv.FormatFloat(1000.5*float64(DefaultSampleSize+10), 'f', -1, 64)
+	if got := data.Columns[1].Raw[0]; got != want {
+		t.Errorf("sum = %s, want %s", got, want)
+	}
+	if data.SourceRows[0] != 2 {
+		t.Errorf("source row = %d, want 2", data.SourceRows[0])
+	}
+}
+
+func TestAggregatorErrors(t *testing.T) {
+	headers := []string{"Region", "Note"}
+	if _, err := NewAggregator(headers, Aggregation{GroupBy: "Missing"}); err == nil {
+		t.Errorf("expected an error for an unknown group column")
+	}
+
+	a, _ := NewAggregator(headers, Aggregation{GroupBy: "Region", Columns: []string{"Note"}})
+	a.Add([]string{"North", "text"}, 2)
+	if _, err := a.Dataset(); err == nil {
+		t.Errorf("expected an error when a requested column holds no numbers")
+	}
+}
+
+func TestParseAggregateFunction(t *testing.T) {
+	for _, f := range AggregateFunctions {
+		if got, err := ParseAggregateFunction(f.String()); err != nil || got != f {
+			t.Errorf("ParseAggregateFunction(%s) = %s, %v", f, got, err)
+		}
+	}
+	if got, _ := ParseAggregateFunction("Average"); got != AggregateMean {
+		t.Errorf("ParseAggregateFunction(Average) = %s, want mean", got)
+	}
+	if _, err := ParseAggregateFunction("median"); err == nil {
+		t.Errorf("expected an error for an unknown function")
+	}
+}
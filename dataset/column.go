@@ -0,0 +1,322 @@
+package dataset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind is the storage type of a column
+type Kind int
+
+const (
+	KindString   Kind = iota // free text
+	KindCategory             // text with few distinct values
+	KindFloat
+	KindInt
+	KindTime
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindCategory:
+		return "category"
+	case KindFloat:
+		return "float"
+	case KindInt:
+		return "int"
+	case KindTime:
+		return "time"
+	default:
+		return "string"
+	}
+}
+
+// Numeric reports whether values of the kind are numbers
+func (k Kind) Numeric() bool {
+	return k == KindFloat || k == KindInt
+}
+
+// Column is a named, typed column. Raw always holds the original cell text;
+// the typed slice matching Kind holds the parsed values.
+type Column struct {
+	Name string
+	Kind Kind
+
+	// Type is the inferred or user-chosen meaning of the values and
+	// Confidence the share of sampled values that matched it
+	Type       SemanticType
+	Confidence float64
+	Overridden bool // Type was chosen by the user
+	Declared   bool // Type was declared by the source, e.g. a database schema
+
+	// Number is the format numeric values are read in and TimeFormat the
+	// one of dates and times
+	Number     NumberFormat
+	TimeFormat TimeFormat
+
+	Raw    []string
+	Nulls  []bool // true for empty cells
+	Floats []float64
+	Ints   []int64
+	Times  []time.Time
+
+	// Levels lists the distinct values of a category column in order of
+	// appearance, Codes holds each row's index into Levels (-1 for nulls)
+	Levels []string
+	Codes  []int
+}
+
+// newColumn builds a column from raw values, inferring its type from a
+// sample. Columns whose values do not all convert to the inferred type are
+// kept as text but remember the inference.
+func newColumn(name string, raw []string) *Column {
+	col := &Column{Name: name, Raw: raw, Nulls: make([]bool, len(raw))}
+	for i, value := range raw {
+		col.Nulls[i] = strings.TrimSpace(value) == ""
+	}
+
+	return col.apply(inferColumnType(name, raw, AutoNumberFormat))
+}
+
+// inferColumnType infers the type of a column reading numbers in format.
+// Numbers under a header such as "Date" are tried as timestamps.
+func inferColumnType(name string, raw []string, format NumberFormat) Inference {
+	inference := InferTypeAs(raw, DefaultSampleSize, format)
+	if inference.Type.Numeric() && isTimeName(name) {
+		if f, share := DetectTimeFormat(sampleValues(raw, DefaultSampleSize), true); share >= MinConfidence {
+			inference.Type, inference.Confidence, inference.Time = TypeDateTime, share, f
+		}
+	}
+	return inference
+}
+
+// apply returns a copy of the column with the inference applied, converted
+// to the inferred type when the inference is confident enough
+func (c *Column) apply(inference Inference) *Column {
+	col, _ := c.Convert(KindString)
+	col.Type, col.Confidence = inference.Type, inference.Confidence
+	col.Number, col.TimeFormat = inference.Number, inference.Time
+	if inference.Confidence >= MinConfidence {
+		if converted, err := col.Convert(inference.Type.Kind()); err == nil {
+			return converted
+		}
+	}
+	return col
+}
+
+// Len returns the number of values
+func (c *Column) Len() int {
+	return len(c.Raw)
+}
+
+// IsNull reports whether the value at row i is missing
+func (c *Column) IsNull(i int) bool {
+	return c.Nulls[i]
+}
+
+// Float returns the value at row i as a number. ok is false for nulls and
+// for columns that are not numeric.
+func (c *Column) Float(i int) (value float64, ok bool) {
+	if c.Nulls[i] {
+		return 0, false
+	}
+	switch c.Kind {
+	case KindFloat:
+		return c.Floats[i], true
+	case KindInt:
+		return float64(c.Ints[i]), true
+	default:
+		return 0, false
+	}
+}
+
+// Time returns the value at row i as a time. ok is false for nulls and for
+// columns that are not time columns.
+func (c *Column) Time(i int) (value time.Time, ok bool) {
+	if c.Kind != KindTime || c.Nulls[i] {
+		return time.Time{}, false
+	}
+	return c.Times[i], true
+}
+
+// String returns the original text of the value at row i
+func (c *Column) String(i int) string {
+	return c.Raw[i]
+}
+
+// NullCount returns the number of missing values
+func (c *Column) NullCount() int {
+	count := 0
+	for _, null := range c.Nulls {
+		if null {
+			count++
+		}
+	}
+	return count
+}
+
+// ConversionError reports the values that could not be converted to a kind
+type ConversionError struct {
+	Column string
+	Kind   Kind
+	Rows   []int    // indices of the failing rows
+	Values []string // the failing values, same order as Rows
+
+	// SourceRows holds the source row numbers of the failing rows when the
+	// conversion was made through Dataset.ConvertColumn
+	SourceRows []int
+}
+
+func (e *ConversionError) Error() string {
+	const shown = 3
+	var examples []string
+	for i := 0; i < len(e.Values) && i < shown; i++ {
+		examples = append(examples, fmt.Sprintf("%q", e.Values[i]))
+	}
+	more := ""
+	if len(e.Values) > shown {
+		more = fmt.Sprintf(" and %d more", len(e.Values)-shown)
+	}
+	where := ""
+	if len(e.SourceRows) > 0 {
+		where = fmt.Sprintf(", first at row %d", e.SourceRows[0])
+	}
+	return fmt.Sprintf("column %s is not %s: %d invalid value(s) such as %s%s%s",
+		e.Column, e.Kind, len(e.Values), strings.Join(examples, ", "), more, where)
+}
+
+// Convert returns a copy of the column holding values of the given kind.
+// Nulls are kept; any other value that cannot be converted is reported in a
+// *ConversionError.
+func (c *Column) Convert(kind Kind) (*Column, error) {
+	converted := *c
+	converted.Kind = kind
+	converted.Floats, converted.Ints, converted.Times = nil, nil, nil
+	converted.Levels, converted.Codes = nil, nil
+	convErr := &ConversionError{Column: c.Name, Kind: kind}
+
+	switch kind {
+	case KindFloat:
+		converted.Floats = make([]float64, len(c.Raw))
+		for i, value := range c.Raw {
+			if c.Nulls[i] {
+				continue
+			}
+			f, err := c.Number.Parse(value)
+			if err != nil {
+				convErr.add(i, value)
+				continue
+			}
+			converted.Floats[i] = f
+		}
+	case KindInt:
+		converted.Ints = make([]int64, len(c.Raw))
+		for i, value := range c.Raw {
+			if c.Nulls[i] {
+				continue
+			}
+			f, err := c.Number.Parse(value)
+			n := int64(f)
+			if err != nil || float64(n) != f {
+				convErr.add(i, value)
+				continue
+			}
+			converted.Ints[i] = n
+		}
+	case KindTime:
+		format := c.TimeFormat
+		if format.Auto() {
+			format, _ = DetectTimeFormat(sampleValues(c.Raw, DefaultSampleSize), true)
+			converted.TimeFormat = format
+		}
+		converted.Times = make([]time.Time, len(c.Raw))
+		for i, value := range c.Raw {
+			if c.Nulls[i] {
+				continue
+			}
+			t, err := format.Parse(value)
+			if err != nil {
+				convErr.add(i, value)
+				continue
+			}
+			converted.Times[i] = t
+		}
+	case KindCategory:
+		converted.Codes = make([]int, len(c.Raw))
+		index := make(map[string]int)
+		for i, value := range c.Raw {
+			if c.Nulls[i] {
+				converted.Codes[i] = -1
+				continue
+			}
+			code, ok := index[value]
+			if !ok {
+				code = len(converted.Levels)
+				index[value] = code
+				converted.Levels = append(converted.Levels, value)
+			}
+			converted.Codes[i] = code
+		}
+	case KindString:
+	default:
+		return nil, fmt.Errorf("unknown column kind %d", kind)
+	}
+
+	if len(convErr.Rows) > 0 {
+		return nil, convErr
+	}
+	if kind != KindString && kind != KindCategory && c.NullCount() == c.Len() {
+		return nil, fmt.Errorf("column %s has no values", c.Name)
+	}
+	return &converted, nil
+}
+
+func (e *ConversionError) add(row int, value string) {
+	e.Rows = append(e.Rows, row)
+	e.Values = append(e.Values, value)
+}
+
+// pick returns a column with the values at the given rows, in that order
+func (c *Column) pick(rows []int) *Column {
+	p := *c
+	p.Raw = pick(c.Raw, rows)
+	p.Nulls = pick(c.Nulls, rows)
+	p.Floats = pick(c.Floats, rows)
+	p.Ints = pick(c.Ints, rows)
+	p.Times = pick(c.Times, rows)
+	p.Codes = pick(c.Codes, rows)
+	return &p
+}
+
+// pick returns the values at the given rows, nil when values is nil
+func pick[T any](values []T, rows []int) []T {
+	if values == nil {
+		return nil
+	}
+	picked := make([]T, len(rows))
+	for i, row := range rows {
+		picked[i] = values[row]
+	}
+	return picked
+}
+
+// slice returns a column with the first n values, sharing storage with c
+func (c *Column) slice(n int) *Column {
+	s := *c
+	s.Raw = c.Raw[:n]
+	s.Nulls = c.Nulls[:n]
+	if c.Floats != nil {
+		s.Floats = c.Floats[:n]
+	}
+	if c.Ints != nil {
+		s.Ints = c.Ints[:n]
+	}
+	if c.Times != nil {
+		s.Times = c.Times[:n]
+	}
+	if c.Codes != nil {
+		s.Codes = c.Codes[:n]
+	}
+	return &s
+}
@@ -0,0 +1,275 @@
+// Package dataset holds tabular data with typed columns. Values are parsed
+// once when a dataset is built, so type errors surface before any chart is
+// rendered.
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Dataset is a table of typed columns of equal length
+type Dataset struct {
+	Columns []*Column
+
+	// SourceRows holds the row number in the source file for each row,
+	// counting the header as row 1, or the line it starts on in text files
+	SourceRows []int
+}
+
+// FromRecords builds a dataset from string records whose first row holds the
+// column names. Column kinds are detected from the values.
+func FromRecords(records [][]string) (*Dataset, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records")
+	}
+
+	rows := records[1:]
+	sourceRows := make([]int, len(rows))
+	for i := range rows {
+		sourceRows[i] = i + 2
+	}
+	return New(records[0], rows, sourceRows)
+}
+
+// New builds a dataset from headers and rows. sourceRows gives the source
+// row number of each row; nil numbers the rows from 2 as in FromRecords.
+func New(headers []string, rows [][]string, sourceRows []int) (*Dataset, error) {
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("no columns")
+	}
+	if sourceRows == nil {
+		sourceRows = make([]int, len(rows))
+		for i := range rows {
+			sourceRows[i] = i + 2
+		}
+	}
+	if len(sourceRows) != len(rows) {
+		return nil, fmt.Errorf("got %d source row numbers for %d rows", len(sourceRows), len(rows))
+	}
+
+	d := &Dataset{SourceRows: sourceRows}
+	for j, header := range headers {
+		raw := make([]string, len(rows))
+		for i, row := range rows {
+			if j >= len(row) {
+				return nil, fmt.Errorf("row %d has %d columns, expected %d", sourceRows[i], len(row), len(headers))
+			}
+			raw[i] = row[j]
+		}
+		d.Columns = append(d.Columns, newColumn(header, raw))
+	}
+	return d, nil
+}
+
+// Len returns the number of rows
+func (d *Dataset) Len() int {
+	return len(d.SourceRows)
+}
+
+// Headers returns the column names
+func (d *Dataset) Headers() []string {
+	headers := make([]string, len(d.Columns))
+	for i, col := range d.Columns {
+		headers[i] = col.Name
+	}
+	return headers
+}
+
+// Index returns the position of the named column, or -1
+func (d *Dataset) Index(name string) int {
+	for i, col := range d.Columns {
+		if col.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Column returns the named column, or nil
+func (d *Dataset) Column(name string) *Column {
+	if i := d.Index(name); i != -1 {
+		return d.Columns[i]
+	}
+	return nil
+}
+
+// Select returns a dataset with the named columns in the given order. The
+// columns are shared with d, not copied.
+func (d *Dataset) Select(names []string) (*Dataset, error) {
+	selected := &Dataset{SourceRows: d.SourceRows}
+	for _, name := range names {
+		col := d.Column(name)
+		if col == nil {
+			return nil, fmt.Errorf("column %q not found, available columns: %s", name, strings.Join(d.Headers(), ", "))
+		}
+		selected.Columns = append(selected.Columns, col)
+	}
+	return selected, nil
+}
+
+// Head returns a dataset with at most the first n rows
+func (d *Dataset) Head(n int) *Dataset {
+	if n < 0 || n >= d.Len() {
+		return d
+	}
+
+	head := &Dataset{SourceRows: d.SourceRows[:n]}
+	for _, col := range d.Columns {
+		head.Columns = append(head.Columns, col.slice(n))
+	}
+	return head
+}
+
+// DropRows returns a copy of d without the rows at the given indices
+func (d *Dataset) DropRows(rows []int) *Dataset {
+	drop := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		drop[row] = true
+	}
+	var keep []int
+	for i := 0; i < d.Len(); i++ {
+		if !drop[i] {
+			keep = append(keep, i)
+		}
+	}
+
+	kept := &Dataset{SourceRows: pick(d.SourceRows, keep)}
+	for _, col := range d.Columns {
+		kept.Columns = append(kept.Columns, col.pick(keep))
+	}
+	return kept
+}
+
+// ReplaceValues returns a copy of d with the values at the given rows of the
+// column at index replaced by value, converted to the column's kind
+func (d *Dataset) ReplaceValues(index int, rows []int, value string) (*Dataset, error) {
+	col := *d.Columns[index]
+	col.Raw = append([]string(nil), col.Raw...)
+	col.Nulls = append([]bool(nil), col.Nulls...)
+	for _, row := range rows {
+		col.Raw[row] = value
+		col.Nulls[row] = strings.TrimSpace(value) == ""
+	}
+
+	converted, err := col.Convert(col.Kind)
+	if err != nil {
+		return nil, err
+	}
+	return d.replace(index, converted), nil
+}
+
+// Records converts the dataset back to string records, header first
+func (d *Dataset) Records() [][]string {
+	records := make([][]string, 0, d.Len()+1)
+	records = append(records, d.Headers())
+	for i := 0; i < d.Len(); i++ {
+		row := make([]string, len(d.Columns))
+		for j, col := range d.Columns {
+			row[j] = col.Raw[i]
+		}
+		records = append(records, row)
+	}
+	return records
+}
+
+// ConvertColumn returns a copy of d with the column at index converted to
+// kind. The columns are shared with d except the converted one. A
+// *ConversionError lists the source rows of values that do not convert.
+func (d *Dataset) ConvertColumn(index int, kind Kind) (*Dataset, error) {
+	converted, err := d.Columns[index].Convert(kind)
+	if err != nil {
+		var convErr *ConversionError
+		if errors.As(err, &convErr) {
+			for _, row := range convErr.Rows {
+				convErr.SourceRows = append(convErr.SourceRows, d.SourceRows[row])
+			}
+		}
+		return nil, err
+	}
+
+	return d.replace(index, converted), nil
+}
+
+// replace returns a copy of d sharing all columns except the one at index
+func (d *Dataset) replace(index int, col *Column) *Dataset {
+	copied := &Dataset{
+		Columns:    append([]*Column(nil), d.Columns...),
+		SourceRows: d.SourceRows,
+	}
+	copied.Columns[index] = col
+	return copied
+}
+
+// SetType returns a copy of d with the column at index converted to the
+// storage kind of t and marked as overridden by the user
+func (d *Dataset) SetType(index int, t SemanticType) (*Dataset, error) {
+	converted, err := d.ConvertColumn(index, t.Kind())
+	if err != nil {
+		return nil, err
+	}
+
+	col := *converted.Columns[index]
+	col.Type, col.Confidence, col.Overridden = t, 1, true
+	converted.Columns[index] = &col
+	return converted, nil
+}
+
+// DeclareType returns a copy of d with the column at index converted to the
+// storage kind of t and marked as declared by the source of the data.
+// Numbers are read with a decimal point, as databases write them.
+func (d *Dataset) DeclareType(index int, t SemanticType) (*Dataset, error) {
+	col := *d.Columns[index]
+	if t.Numeric() {
+		col.Number = PointDecimal
+	}
+	converted, err := d.replace(index, &col).ConvertColumn(index, t.Kind())
+	if err != nil {
+		return nil, err
+	}
+
+	declared := *converted.Columns[index]
+	declared.Type, declared.Confidence, declared.Declared = t, 1, true
+	converted.Columns[index] = &declared
+	return converted, nil
+}
+
+// SetTimeFormat returns a copy of d with the column at index read as dates
+// and times in format f and marked as overridden by the user.
+// AutoTimeFormat detects the format from the values.
+func (d *Dataset) SetTimeFormat(index int, f TimeFormat) (*Dataset, error) {
+	col := *d.Columns[index]
+	col.TimeFormat = f
+	return d.replace(index, &col).SetType(index, TypeDateTime)
+}
+
+// SetNumberFormat returns a copy of d with the numbers of the column at index
+// read in format f. The type of the column is inferred again unless the user
+// chose it, in which case the values are converted to that type. Columns
+// declared by their source keep the format it writes.
+// AutoNumberFormat detects the format from the values.
+func (d *Dataset) SetNumberFormat(index int, f NumberFormat) (*Dataset, error) {
+	if d.Columns[index].Declared {
+		return d, nil
+	}
+	inference := inferColumnType(d.Columns[index].Name, d.Columns[index].Raw, f)
+	col := *d.Columns[index]
+	col.Number = inference.Number
+	if col.Overridden {
+		return d.replace(index, &col).ConvertColumn(index, col.Type.Kind())
+	}
+	return d.replace(index, col.apply(inference)), nil
+}
+
+// WithNumberFormat applies SetNumberFormat to every column
+func (d *Dataset) WithNumberFormat(f NumberFormat) (*Dataset, error) {
+	updated := d
+	for i := range d.Columns {
+		var err error
+		if updated, err = updated.SetNumberFormat(i, f); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,113 @@
+package dataset
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFromRecordsDetectsKinds(t *testing.T) {
+	data, err := FromRecords([][]string{
+		{"Name", "Count", "Price", "Day", "Team"},
+		{"alpha", "1", "1.5", "2024-01-01", "red"},
+		{"beta", "2", "", "2024-01-02", "red"},
+		{"gamma", "", "3", "2024-01-03", "blue"},
+		{"delta", "4", "4.25", "2024-01-04", "blue"},
+	})
+	if err != nil {
+		t.Fatalf("FromRecords failed: %v", err)
+	}
+
+	want := map[string]Kind{
+		"Name":  KindString,
+		"Count": KindInt,
+		"Price": KindFloat,
+		"Day":   KindTime,
+		"Team":  KindCategory,
+	}
+	for name, kind := range want {
+		if got := data.Column(name).Kind; got != kind {
+			t.Errorf("column %s detected as %s, want %s", name, got, kind)
+		}
+	}
+
+	price := data.Column("Price")
+	if !price.IsNull(1) || price.NullCount() != 1 {
+		t.Errorf("empty Price cell is not tracked as null")
+	}
+	if v, ok := price.Float(3); !ok || v != 4.25 {
+		t.Errorf("Price row 3 = %v, %v", v, ok)
+	}
+	if v, ok := data.Column("Count").Float(3); !ok || v != 4 {
+		t.Errorf("Count row 3 = %v, %v", v, ok)
+	}
+	if levels := data.Column("Team").Levels; len(levels) != 2 {
+		t.Errorf("Team levels = %v", levels)
+	}
+}
+
+func TestConvertColumnReportsSourceRows(t *testing.T) {
+	data, err := New([]string{"Value"}, [][]string{{"1"}, {"oops"}, {"3"}}, []int{10, 11, 12})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	_, err = data.ConvertColumn(0, KindFloat)
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("ConvertColumn error = %v, want *ConversionError", err)
+	}
+	if len(convErr.SourceRows) != 1 || convErr.SourceRows[0] != 11 {
+		t.Errorf("SourceRows = %v, want [11]", convErr.SourceRows)
+	}
+}
+
+func TestSelectAndHead(t *testing.T) {
+	data, _ := FromRecords([][]string{
+		{"A", "B"},
+		{"1", "x"},
+		{"2", "y"},
+		{"3", "z"},
+	})
+
+	selected, err := data.Select([]string{"B", "A"})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	head := selected.Head(2)
+	if head.Len() != 2 || head.Columns[0].Name != "B" || head.Columns[1].Ints[1] != 2 {
+		t.Errorf("unexpected head: %v", head.Records())
+	}
+	if _, err := data.Select([]string{"missing"}); err == nil {
+		t.Errorf("expected an error for a missing column")
+	}
+}
+
+func TestDropRowsAndReplaceValues(t *testing.T) {
+	data, err := New([]string{"Name", "Value"}, [][]string{{"a", "1"}, {"b", "oops"}, {"c", "3"}}, []int{10, 11, 12})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	dropped := data.DropRows([]int{1})
+	if dropped.Len() != 2 || dropped.SourceRows[1] != 12 || dropped.Columns[0].String(1) != "c" {
+		t.Errorf("DropRows kept %v from rows %v", dropped.Records(), dropped.SourceRows)
+	}
+	if data.Len() != 3 {
+		t.Errorf("DropRows changed the original dataset")
+	}
+
+	replaced, err := data.ReplaceValues(1, []int{1}, "0")
+	if err != nil {
+		t.Fatalf("ReplaceValues failed: %v", err)
+	}
+	converted, err := replaced.ConvertColumn(1, KindFloat)
+	if err != nil {
+		t.Fatalf("replaced column does not convert: %v", err)
+	}
+	if v, ok := converted.Columns[1].Float(1); !ok || v != 0 {
+		t.Errorf("replaced value = %v, %v, want 0", v, ok)
+	}
+	if data.Columns[1].String(1) != "oops" {
+		t.Errorf("ReplaceValues changed the original dataset")
+	}
+}
@@ -0,0 +1,210 @@
+package dataset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SemanticType is what the values of a column mean, as opposed to the Kind
+// they are stored as
+type SemanticType int
+
+const (
+	TypeText SemanticType = iota
+	TypeCategory
+	TypeBoolean
+	TypeInteger
+	TypeNumeric
+	TypePercentage
+	TypeCurrency
+	TypeDateTime
+)
+
+// SemanticTypes lists all semantic types in the order offered to users
+var SemanticTypes = []SemanticType{
+	TypeNumeric, TypeInteger, TypePercentage, TypeCurrency,
+	TypeDateTime, TypeBoolean, TypeCategory, TypeText,
+}
+
+func (t SemanticType) String() string {
+	switch t {
+	case TypeCategory:
+		return "category"
+	case TypeBoolean:
+		return "boolean"
+	case TypeInteger:
+		return "integer"
+	case TypeNumeric:
+		return "numeric"
+	case TypePercentage:
+		return "percentage"
+	case TypeCurrency:
+		return "currency"
+	case TypeDateTime:
+		return "date/time"
+	default:
+		return "text"
+	}
+}
+
+// ParseSemanticType returns the semantic type with the given name
+func ParseSemanticType(name string) (SemanticType, error) {
+	for _, t := range SemanticTypes {
+		if strings.EqualFold(t.String(), name) {
+			return t, nil
+		}
+	}
+	if strings.EqualFold(name, "datetime") || strings.EqualFold(name, "date") || strings.EqualFold(name, "time") {
+		return TypeDateTime, nil
+	}
+	return TypeText, fmt.Errorf("unknown column type %q", name)
+}
+
+// Numeric reports whether values of the type are numbers
+func (t SemanticType) Numeric() bool {
+	switch t {
+	case TypeInteger, TypeNumeric, TypePercentage, TypeCurrency:
+		return true
+	default:
+		return false
+	}
+}
+
+// Kind returns the storage kind used for the type
+func (t SemanticType) Kind() Kind {
+	switch t {
+	case TypeInteger:
+		return KindInt
+	case TypeNumeric, TypePercentage, TypeCurrency:
+		return KindFloat
+	case TypeDateTime:
+		return KindTime
+	case TypeCategory, TypeBoolean:
+		return KindCategory
+	default:
+		return KindString
+	}
+}
+
+// Inference is the result of classifying a column from sampled values
+type Inference struct {
+	Type       SemanticType
+	Confidence float64 // share of sampled values matching Type, 0 to 1
+	Sampled    int     // number of non-empty values sampled
+	Number     NumberFormat
+	Time       TimeFormat
+}
+
+const (
+	// DefaultSampleSize is the number of values InferType looks at
+	DefaultSampleSize = 1000
+
+	// MinConfidence is the share of sampled values that must match a type
+	// before it is applied to a column
+	MinConfidence = 0.9
+)
+
+var booleanValues = map[string]bool{"true": true, "false": true, "yes": true, "no": true, "y": true, "n": true}
+
+// typeMatchers are tried in order; the first type matched by enough sampled
+// values wins, so more specific types come first. The matchers read values
+// in the number and time formats of the inference.
+var typeMatchers = []struct {
+	t     SemanticType
+	match func(v string, in Inference) bool
+}{
+	{TypeBoolean, func(v string, in Inference) bool { return booleanValues[strings.ToLower(v)] }},
+	{TypeInteger, func(v string, in Inference) bool {
+		n, err := in.Number.parse(v)
+		return err == nil && !n.fraction && !n.scaled && !n.percent && !n.currency
+	}},
+	{TypePercentage, func(v string, in Inference) bool { n, err := in.Number.parse(v); return err == nil && n.percent }},
+	{TypeCurrency, func(v string, in Inference) bool { n, err := in.Number.parse(v); return err == nil && n.currency }},
+	{TypeNumeric, func(v string, in Inference) bool { _, err := in.Number.parse(v); return err == nil }},
+	{TypeDateTime, func(v string, in Inference) bool { _, err := in.Time.Parse(v); return err == nil }},
+}
+
+// InferType classifies values by sampling up to sampleSize non-empty values
+// spread evenly over the column. The number and time formats are detected
+// from the sample.
+func InferType(values []string, sampleSize int) Inference {
+	return InferTypeAs(values, sampleSize, AutoNumberFormat)
+}
+
+// InferTypeAs is like InferType but reads numbers in the given format
+func InferTypeAs(values []string, sampleSize int, format NumberFormat) Inference {
+	sample := sampleValues(values, sampleSize)
+	if len(sample) == 0 {
+		return Inference{Type: TypeText, Number: format, Time: AutoTimeFormat}
+	}
+
+	if format.Decimal == 0 {
+		format = DetectNumberFormat(sample)
+	}
+	timeFormat, _ := DetectTimeFormat(sample, false)
+
+	best := Inference{Type: TypeText, Sampled: len(sample), Number: format, Time: timeFormat}
+	for _, m := range typeMatchers {
+		candidate := best
+		matched := 0
+		for _, v := range sample {
+			if m.match(v, candidate) {
+				matched++
+			}
+		}
+		candidate.Type, candidate.Confidence = m.t, float64(matched)/float64(len(sample))
+		if candidate.Confidence >= MinConfidence {
+			return candidate
+		}
+		if candidate.Confidence > best.Confidence && candidate.Confidence >= 0.5 {
+			best = candidate
+		}
+	}
+	if best.Type != TypeText {
+		return best
+	}
+
+	best.Confidence = 1
+	if sampleIsCategorical(sample) {
+		best.Type = TypeCategory
+	}
+	return best
+}
+
+// sampleValues returns up to sampleSize trimmed non-empty values spread
+// evenly over values
+func sampleValues(values []string, sampleSize int) []string {
+	filled := 0
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			filled++
+		}
+	}
+
+	step := 1
+	if sampleSize > 0 && filled > sampleSize {
+		step = filled / sampleSize
+	}
+	var sample []string
+	seen := 0
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if seen%step == 0 {
+			sample = append(sample, v)
+		}
+		seen++
+	}
+	return sample
+}
+
+// sampleIsCategorical reports whether values repeat enough to be categories
+func sampleIsCategorical(values []string) bool {
+	distinct := make(map[string]struct{})
+	for _, v := range values {
+		distinct[v] = struct{}{}
+	}
+	return len(values) > 0 && len(distinct) <= 1000 && len(distinct)*2 <= len(values)
+}
@@ -0,0 +1,84 @@
+package dataset
+
+import "testing"
+
+func TestInferType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   SemanticType
+	}{
+		{"integer", []string{"1", "2", "", "30"}, TypeInteger},
+		{"numeric", []string{"1.5", "2", "1,200.25"}, TypeNumeric},
+		{"percentage", []string{"12%", "3.5%", "100 %"}, TypePercentage},
+		{"currency", []string{"$10", "$1,250.00", "€3"}, TypeCurrency},
+		{"date/time", []string{"2024-01-01", "2024-02-01"}, TypeDateTime},
+		{"boolean", []string{"yes", "no", "Yes"}, TypeBoolean},
+		{"category", []string{"red", "blue", "red", "blue"}, TypeCategory},
+		{"text", []string{"alpha", "beta", "gamma"}, TypeText},
+		{"empty", []string{"", " "}, TypeText},
+	}
+	for _, tt := range tests {
+		if got := InferType(tt.values, DefaultSampleSize); got.Type != tt.want {
+			t.Errorf("%s: InferType(%q) = %s, want %s", tt.name, tt.values, got.Type, tt.want)
+		}
+	}
+}
+
+func TestInferTypeConfidence(t *testing.T) {
+	values := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "n/a"}
+	got := InferType(values, DefaultSampleSize)
+	if got.Type != TypeInteger || got.Confidence != 0.9 || got.Sampled != 10 {
+		t.Errorf("InferType = %+v, want integer at 0.9 of 10", got)
+	}
+
+	col := newColumn("Value", values)
+	if col.Kind != KindString || col.Type != TypeInteger {
+		t.Errorf("column with an unparsable value: kind %s type %s, want string kept with integer inferred", col.Kind, col.Type)
+	}
+}
+
+func TestSetTypeOverridesInference(t *testing.T) {
+	data, err := New([]string{"Year"}, [][]string{{"2021"}, {"2022"}, {"2023"}}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	overridden, err := data.SetType(0, TypeCategory)
+	if err != nil {
+		t.Fatalf("SetType failed: %v", err)
+	}
+	col := overridden.Columns[0]
+	if col.Kind != KindCategory || col.Type != TypeCategory || !col.Overridden {
+		t.Errorf("overridden column = kind %s type %s overridden %v", col.Kind, col.Type, col.Overridden)
+	}
+	if data.Columns[0].Type != TypeInteger {
+		t.Errorf("SetType changed the original dataset")
+	}
+
+	if _, err := data.SetType(0, TypeBoolean); err != nil {
+		t.Errorf("SetType(boolean) failed: %v", err)
+	}
+}
+
+func TestDeclareTypeKeepsSourceFormat(t *testing.T) {
+	data, err := New([]string{"Price"}, [][]string{{"1.500"}, {"2.250"}}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	declared, err := data.DeclareType(0, TypeNumeric)
+	if err != nil {
+		t.Fatalf("DeclareType failed: %v", err)
+	}
+	// Reading the file's other numbers with decimal commas leaves the
+	// declared column as its source wrote it
+	declared, err = declared.WithNumberFormat(CommaDecimal)
+	if err != nil {
+		t.Fatalf("WithNumberFormat failed: %v", err)
+	}
+	col := declared.Columns[0]
+	if v, ok := col.Float(0); !col.Declared || col.Overridden || !ok || v != 1.5 {
+		t.Errorf("declared column = %v, %v (declared %v), want 1.5", v, ok, col.Declared)
+	}
+}
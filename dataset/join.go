@@ -0,0 +1,276 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// JoinKind chooses which rows of two joined datasets are kept
+type JoinKind int
+
+const (
+	JoinInner JoinKind = iota // rows whose key is in both datasets
+	JoinLeft                  // every left row, empty on the right when unmatched
+	JoinOuter                 // every row of both datasets
+)
+
+// JoinKinds lists the kinds in the order offered to users
+var JoinKinds = []JoinKind{JoinInner, JoinLeft, JoinOuter}
+
+func (k JoinKind) String() string {
+	switch k {
+	case JoinLeft:
+		return "left"
+	case JoinOuter:
+		return "outer"
+	default:
+		return "inner"
+	}
+}
+
+// ParseJoinKind returns the join kind with the given name
+func ParseJoinKind(name string) (JoinKind, error) {
+	name = strings.TrimSpace(name)
+	for _, k := range JoinKinds {
+		if strings.EqualFold(k.String(), name) {
+			return k, nil
+		}
+	}
+	if strings.EqualFold(name, "full") {
+		return JoinOuter, nil
+	}
+	return JoinInner, fmt.Errorf("unknown join %q, use inner, left or outer", name)
+}
+
+// Join describes how two datasets are joined: rows match when the values of
+// each left key column equal those of the right key column in the same
+// position, compared as text without surrounding spaces. Empty keys never
+// match.
+type Join struct {
+	Kind      JoinKind
+	LeftKeys  []string
+	RightKeys []string // the LeftKeys names when empty
+}
+
+// RightSuffix is appended to the names of right columns that the left
+// dataset also has
+const RightSuffix = " (right)"
+
+// maxKeyExamples is the number of unmatched or duplicated keys a
+// JoinReport lists
+const maxKeyExamples = 5
+
+// JoinReport tells how the keys of two joined datasets matched
+type JoinReport struct {
+	LeftRows, RightRows int // rows of the datasets joined
+	Rows, Columns       int // size of the joined dataset
+
+	// UnmatchedLeft and UnmatchedRight count the rows without a match in
+	// the other dataset, whether or not the join keeps them
+	UnmatchedLeft, UnmatchedRight int
+
+	// DuplicateLeft and DuplicateRight count the keys found on more than
+	// one row, whose matches are repeated for each of those rows
+	DuplicateLeft, DuplicateRight int
+
+	// Examples of the keys counted above, as joined key text
+	UnmatchedLeftKeys, UnmatchedRightKeys []string
+	DuplicateLeftKeys, DuplicateRightKeys []string
+}
+
+// Summary describes the size of the joined dataset
+func (r JoinReport) Summary() string {
+	return fmt.Sprintf("%d rows and %d columns from %d left and %d right rows", r.Rows, r.Columns, r.LeftRows, r.RightRows)
+}
+
+// Warnings describes the unmatched and duplicated keys, one sentence each
+func (r JoinReport) Warnings() []string {
+	var warnings []string
+	if r.UnmatchedLeft > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d of %d left rows have no match, e.g. %s", r.UnmatchedLeft, r.LeftRows, strings.Join(r.UnmatchedLeftKeys, ", ")))
+	}
+	if r.UnmatchedRight > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d of %d right rows have no match, e.g. %s", r.UnmatchedRight, r.RightRows, strings.Join(r.UnmatchedRightKeys, ", ")))
+	}
+	if r.DuplicateLeft > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d key(s) on several left rows, e.g. %s", r.DuplicateLeft, strings.Join(r.DuplicateLeftKeys, ", ")))
+	}
+	if r.DuplicateRight > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d key(s) on several right rows, repeating the left rows they match, e.g. %s", r.DuplicateRight, strings.Join(r.DuplicateRightKeys, ", ")))
+	}
+	return warnings
+}
+
+// joinKeys returns the key text of each row of d, empty when a key column
+// is empty
+func joinKeys(d *Dataset, names []string) ([]string, error) {
+	columns := make([]*Column, len(names))
+	for i, name := range names {
+		if columns[i] = d.Column(name); columns[i] == nil {
+			return nil, fmt.Errorf("key column %q not found, available columns: %s", name, strings.Join(d.Headers(), ", "))
+		}
+	}
+
+	keys := make([]string, d.Len())
+	parts := make([]string, len(columns))
+	for row := range keys {
+		complete := true
+		for i, col := range columns {
+			parts[i] = strings.TrimSpace(col.Raw[row])
+			complete = complete && parts[i] != ""
+		}
+		if complete {
+			keys[row] = strings.Join(parts, " | ")
+		}
+	}
+	return keys, nil
+}
+
+// keyIndex maps each key to the rows holding it, in order, and notes the
+// keys on several rows
+func keyIndex(keys []string) (rows map[string][]int, duplicates int, examples []string) {
+	rows = make(map[string][]int)
+	for row, key := range keys {
+		if key == "" {
+			continue
+		}
+		rows[key] = append(rows[key], row)
+		if len(rows[key]) == 2 {
+			duplicates++
+			if len(examples) < maxKeyExamples {
+				examples = append(examples, key)
+			}
+		}
+	}
+	return rows, duplicates, examples
+}
+
+// Join returns the rows of d joined with the rows of right whose keys match,
+// left rows first in their order with their matches in right's order, then
+// for an outer join the unmatched right rows. The key columns appear once,
+// under their left names, and the other right columns follow the left ones.
+// Column types are inferred again, keeping the types declared by a source.
+func (d *Dataset) Join(right *Dataset, spec Join) (*Dataset, JoinReport, error) {
+	rightKeys := spec.RightKeys
+	if len(rightKeys) == 0 {
+		rightKeys = spec.LeftKeys
+	}
+	if len(spec.LeftKeys) == 0 || len(spec.LeftKeys) != len(rightKeys) {
+		return nil, JoinReport{}, fmt.Errorf("a join needs the same number of key columns on both sides, got %d and %d", len(spec.LeftKeys), len(rightKeys))
+	}
+
+	leftKeyText, err := joinKeys(d, spec.LeftKeys)
+	if err != nil {
+		return nil, JoinReport{}, fmt.Errorf("left %w", err)
+	}
+	rightKeyText, err := joinKeys(right, rightKeys)
+	if err != nil {
+		return nil, JoinReport{}, fmt.Errorf("right %w", err)
+	}
+	report := JoinReport{LeftRows: d.Len(), RightRows: right.Len()}
+	leftIndex, dupLeft, dupLeftKeys := keyIndex(leftKeyText)
+	rightIndex, dupRight, dupRightKeys := keyIndex(rightKeyText)
+	report.DuplicateLeft, report.DuplicateLeftKeys = dupLeft, dupLeftKeys
+	report.DuplicateRight, report.DuplicateRightKeys = dupRight, dupRightKeys
+
+	// The right columns that are not keys, renamed when the left has them
+	isKey := make(map[int]int) // right column index to left key column index
+	for i, name := range rightKeys {
+		isKey[right.Index(name)] = d.Index(spec.LeftKeys[i])
+	}
+	headers := d.Headers()
+	var rightColumns []int
+	for j, col := range right.Columns {
+		if _, ok := isKey[j]; ok {
+			continue
+		}
+		name := col.Name
+		if d.Index(name) != -1 {
+			name += RightSuffix
+		}
+		headers = append(headers, name)
+		rightColumns = append(rightColumns, j)
+	}
+
+	var rows [][]string
+	var sourceRows []int
+	addRow := func(left, match int) {
+		row := make([]string, len(headers))
+		if left >= 0 {
+			for j, col := range d.Columns {
+				row[j] = col.Raw[left]
+			}
+			sourceRows = append(sourceRows, d.SourceRows[left])
+		} else {
+			for j, k := range isKey {
+				row[k] = right.Columns[j].Raw[match]
+			}
+			sourceRows = append(sourceRows, right.SourceRows[match])
+		}
+		if match >= 0 {
+			for i, j := range rightColumns {
+				row[len(d.Columns)+i] = right.Columns[j].Raw[match]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	for left, key := range leftKeyText {
+		matches := rightIndex[key]
+		if key == "" || len(matches) == 0 {
+			report.UnmatchedLeft++
+			report.UnmatchedLeftKeys = addExample(report.UnmatchedLeftKeys, key)
+			if spec.Kind != JoinInner {
+				addRow(left, -1)
+			}
+			continue
+		}
+		for _, match := range matches {
+			addRow(left, match)
+		}
+	}
+	for match, key := range rightKeyText {
+		if key != "" && len(leftIndex[key]) > 0 {
+			continue
+		}
+		report.UnmatchedRight++
+		report.UnmatchedRightKeys = addExample(report.UnmatchedRightKeys, key)
+		if spec.Kind == JoinOuter {
+			addRow(-1, match)
+		}
+	}
+	report.Rows, report.Columns = len(rows), len(headers)
+	if len(rows) == 0 {
+		return nil, report, fmt.Errorf("the join keeps no rows: no keys match")
+	}
+
+	joined, err := New(headers, rows, sourceRows)
+	if err != nil {
+		return nil, report, err
+	}
+	sources := d.Columns
+	for _, j := range rightColumns {
+		sources = append(sources[:len(sources):len(sources)], right.Columns[j])
+	}
+	for index, col := range sources {
+		if col.Declared {
+			if withType, err := joined.DeclareType(index, col.Type); err == nil {
+				joined = withType
+			}
+		}
+	}
+	return joined, report, nil
+}
+
+// addExample adds a key to the examples of a warning unless they are full or
+// have it, naming empty keys
+func addExample(examples []string, key string) []string {
+	if key == "" {
+		key = "(empty)"
+	}
+	if len(examples) == maxKeyExamples || slices.Contains(examples, key) {
+		return examples
+	}
+	return append(examples, key)
+}
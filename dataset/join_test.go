@@ -0,0 +1,144 @@
+package dataset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	sales, err := New([]string{"Region", "Month", "Units"}, [][]string{
+		{"North", "Jan", "3"},
+		{"South", "Jan", "4"},
+		{"West", "Jan", "5"},
+		{"", "Jan", "6"},
+	}, []int{2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := New([]string{"Area", "Units", "Target"}, [][]string{
+		{"North", "10", "30"},
+		{"South", "20", "40"},
+		{"South", "21", "41"},
+		{"East", "30", "50"},
+	}, []int{2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind       JoinKind
+		want       [][]string
+		sourceRows []int
+	}{
+		{JoinInner, [][]string{
+			{"Region", "Month", "Units", "Units (right)", "Target"},
+			{"North", "Jan", "3", "10", "30"},
+			{"South", "Jan", "4", "20", "40"},
+			{"South", "Jan", "4", "21", "41"},
+		}, []int{2, 3, 3}},
+		{JoinLeft, [][]string{
+			{"Region", "Month", "Units", "Units (right)", "Target"},
+			{"North", "Jan", "3", "10", "30"},
+			{"South", "Jan", "4", "20", "40"},
+			{"South", "Jan", "4", "21", "41"},
+			{"West", "Jan", "5", "", ""},
+			{"", "Jan", "6", "", ""},
+		}, []int{2, 3, 3, 4, 5}},
+		{JoinOuter, [][]string{
+			{"Region", "Month", "Units", "Units (right)", "Target"},
+			{"North", "Jan", "3", "10", "30"},
+			{"South", "Jan", "4", "20", "40"},
+			{"South", "Jan", "4", "21", "41"},
+			{"West", "Jan", "5", "", ""},
+			{"", "Jan", "6", "", ""},
+			{"East", "", "", "30", "50"},
+		}, []int{2, 3, 3, 4, 5, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			joined, report, err := sales.Join(targets, Join{Kind: tt.kind, LeftKeys: []string{"Region"}, RightKeys: []string{"Area"}})
+			if err != nil {
+				t.Fatalf("Join failed: %v", err)
+			}
+			if got := joined.Records(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(joined.SourceRows, tt.sourceRows) {
+				t.Errorf("source rows = %v, want %v", joined.SourceRows, tt.sourceRows)
+			}
+			if joined.Column("Target").Kind != KindInt {
+				t.Errorf("Target detected as %s, want int", joined.Column("Target").Kind)
+			}
+
+			want := JoinReport{
+				LeftRows: 4, RightRows: 4, Rows: len(tt.want) - 1, Columns: 5,
+				UnmatchedLeft: 2, UnmatchedRight: 1, DuplicateRight: 1,
+				UnmatchedLeftKeys: []string{"West", "(empty)"}, UnmatchedRightKeys: []string{"East"}, DuplicateRightKeys: []string{"South"},
+			}
+			if !reflect.DeepEqual(report, want) {
+				t.Errorf("report = %+v, want %+v", report, want)
+			}
+		})
+	}
+}
+
+func TestJoinOnSeveralKeys(t *testing.T) {
+	left, err := FromRecords([][]string{{"Region", "Month", "Units"}, {"North", "Jan", "3"}, {"North", "Feb", "4"}, {"North", "Feb", "5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := FromRecords([][]string{{"Month", "Region", "Price"}, {"Feb", " North ", "1.5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined, report, err := left.Join(right, Join{Kind: JoinInner, LeftKeys: []string{"Region", "Month"}})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	want := [][]string{{"Region", "Month", "Units", "Price"}, {"North", "Feb", "4", "1.5"}, {"North", "Feb", "5", "1.5"}}
+	if got := joined.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	if got := report.Summary(); got != "2 rows and 4 columns from 3 left and 1 right rows" {
+		t.Errorf("summary = %q", got)
+	}
+	warnings := report.Warnings()
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "1 of 3 left rows have no match, e.g. North | Jan") || !strings.Contains(warnings[1], "North | Feb") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	left, _ := FromRecords([][]string{{"Region", "Units"}, {"North", "3"}})
+	right, _ := FromRecords([][]string{{"Region", "Target"}, {"South", "4"}})
+
+	tests := []struct {
+		name string
+		spec Join
+		want string
+	}{
+		{"no keys", Join{}, "same number of key columns"},
+		{"uneven keys", Join{LeftKeys: []string{"Region"}, RightKeys: []string{"Region", "Target"}}, "same number of key columns"},
+		{"missing left key", Join{LeftKeys: []string{"Area"}, RightKeys: []string{"Region"}}, `left key column "Area" not found`},
+		{"missing right key", Join{LeftKeys: []string{"Units"}}, `right key column "Units" not found`},
+		{"no matches", Join{LeftKeys: []string{"Region"}}, "keeps no rows"},
+	}
+	for _, tt := range tests {
+		if _, _, err := left.Join(right, tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseJoinKind(t *testing.T) {
+	for name, want := range map[string]JoinKind{"inner": JoinInner, "Left": JoinLeft, "outer": JoinOuter, "full": JoinOuter} {
+		if got, err := ParseJoinKind(name); err != nil || got != want {
+			t.Errorf("ParseJoinKind(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseJoinKind("cross"); err == nil {
+		t.Errorf("ParseJoinKind accepted an unknown join")
+	}
+}
@@ -0,0 +1,256 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NumberFormat describes how numbers are written: which character separates
+// the decimals and which ones may group the thousands. Currency symbols,
+// percent signs, accounting negatives such as (300) and the SI suffixes k, M,
+// G and T are accepted in every format.
+type NumberFormat struct {
+	Name     string
+	Decimal  rune   // decimal separator, 0 to detect the format from the values
+	Grouping string // characters accepted as thousands separators
+}
+
+// spaces used as thousands separators: space, no-break space, thin space and
+// narrow no-break space
+const groupSpaces = " \u00a0\u2009\u202f"
+
+var (
+	// AutoNumberFormat detects the format of each column from its values
+	AutoNumberFormat = NumberFormat{Name: "auto"}
+
+	// PointDecimal is used in English speaking countries, e.g. 1,234.56
+	PointDecimal = NumberFormat{Name: "1,234.56", Decimal: '.', Grouping: ",'’" + groupSpaces}
+
+	// CommaDecimal is used in most of continental Europe, e.g. 1.234,56
+	CommaDecimal = NumberFormat{Name: "1.234,56", Decimal: ',', Grouping: ".'’" + groupSpaces}
+
+	// NumberFormats lists the formats offered to users
+	NumberFormats = []NumberFormat{AutoNumberFormat, PointDecimal, CommaDecimal}
+)
+
+// commaLocales are the languages writing decimals with a comma
+var commaLocales = map[string]bool{
+	"bg": true, "ca": true, "cs": true, "da": true, "de": true, "el": true, "es": true,
+	"et": true, "fi": true, "fr": true, "hr": true, "hu": true, "id": true, "it": true,
+	"lt": true, "lv": true, "nb": true, "nl": true, "no": true, "pl": true, "pt": true,
+	"ro": true, "ru": true, "sk": true, "sl": true, "sr": true, "sv": true, "tr": true,
+	"uk": true, "vi": true,
+}
+
+// ParseNumberFormat returns the format with the given name. Besides the
+// format names it accepts "point", "comma" and locales such as de or en-US.
+func ParseNumberFormat(name string) (NumberFormat, error) {
+	name = strings.TrimSpace(name)
+	for _, f := range NumberFormats {
+		if strings.EqualFold(f.Name, name) {
+			return f, nil
+		}
+	}
+	switch strings.ToLower(name) {
+	case "point", "dot":
+		return PointDecimal, nil
+	case "comma":
+		return CommaDecimal, nil
+	}
+
+	language, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(name, "_", "-")), "-")
+	if len(language) == 2 && isLetters(language) {
+		if commaLocales[language] {
+			return CommaDecimal, nil
+		}
+		return PointDecimal, nil
+	}
+	return AutoNumberFormat, fmt.Errorf("unknown number format %q, use auto, point, comma or a locale such as de", name)
+}
+
+// Parse converts a formatted number to a float. Percentages keep their
+// written value, so 45% parses as 45.
+func (f NumberFormat) Parse(value string) (float64, error) {
+	n, err := f.parse(value)
+	if err != nil {
+		return 0, err
+	}
+	return n.value, nil
+}
+
+// number is a parsed value together with the decorations found around it
+type number struct {
+	value    float64
+	percent  bool
+	currency bool
+	fraction bool // a decimal separator or exponent was present
+	scaled   bool // an SI suffix was present
+}
+
+var (
+	currencySymbols = "$€£¥₹₩₽₺₪฿¢"
+	currencyCodes   = map[string]bool{
+		"AUD": true, "CAD": true, "CHF": true, "CNY": true, "DKK": true, "EUR": true,
+		"GBP": true, "INR": true, "JPY": true, "NOK": true, "NZD": true, "PLN": true,
+		"SEK": true, "USD": true,
+	}
+	siSuffixes = map[rune]float64{'k': 1e3, 'K': 1e3, 'M': 1e6, 'G': 1e9, 'T': 1e12}
+)
+
+func (f NumberFormat) parse(value string) (number, error) {
+	if f.Decimal == 0 {
+		f = PointDecimal
+	}
+	invalid := fmt.Errorf("invalid numeric value: %s", value)
+
+	var n number
+	scale := 1.0
+	s := strings.TrimSpace(value)
+	negative := false
+
+	// Strip signs, accounting parentheses, currency, percent and suffix in
+	// whatever order they appear, e.g. -$5, $-5, $ (5), 5 € or 12.5k
+	for changed := true; changed && s != ""; {
+		changed = false
+		first, last := firstRune(s), lastRune(s)
+		switch {
+		case !negative && first == '(' && last == ')' && len(s) > 1:
+			negative, s, changed = true, strings.TrimSpace(s[1:len(s)-1]), true
+		case !negative && strings.ContainsRune("-−", first):
+			negative, s, changed = true, trimFirst(s), true
+		case first == '+':
+			s, changed = trimFirst(s), true
+		case !negative && last == '-':
+			negative, s, changed = true, trimLast(s), true
+		case !n.currency && strings.ContainsRune(currencySymbols, first):
+			n.currency, s, changed = true, trimFirst(s), true
+		case !n.currency && strings.ContainsRune(currencySymbols, last):
+			n.currency, s, changed = true, trimLast(s), true
+		case !n.currency && len(s) > 3 && currencyCodes[s[:3]]:
+			n.currency, s, changed = true, strings.TrimSpace(s[3:]), true
+		case !n.currency && len(s) > 3 && currencyCodes[s[len(s)-3:]]:
+			n.currency, s, changed = true, strings.TrimSpace(s[:len(s)-3]), true
+		case !n.percent && !n.scaled && last == '%':
+			n.percent, s, changed = true, trimLast(s), true
+		case !n.scaled && !n.percent && siSuffixes[last] != 0 && len(s) > 1:
+			n.scaled, scale, s, changed = true, siSuffixes[last], trimLast(s), true
+		}
+	}
+
+	v, fraction, ok := f.parseDigits(s)
+	if !ok {
+		return number{}, invalid
+	}
+	n.fraction = fraction
+	n.value = v * scale
+	if negative {
+		n.value = -n.value
+	}
+	return n, nil
+}
+
+// parseDigits parses the bare number left after removing the decorations.
+// Thousands separators must separate groups of three digits, which keeps
+// 1,5 from being read as 15 in the point format.
+func (f NumberFormat) parseDigits(s string) (value float64, fraction bool, ok bool) {
+	if strings.ContainsAny(s, "eE") {
+		// Scientific notation, written without grouping
+		v, err := strconv.ParseFloat(strings.Replace(s, string(f.Decimal), ".", 1), 64)
+		return v, true, err == nil && !math.IsInf(v, 0) && !strings.ContainsAny(s, "nN")
+	}
+
+	integer, decimals, fraction := strings.Cut(s, string(f.Decimal))
+	if (integer == "" && decimals == "") || !isDigits(decimals) {
+		return 0, false, false
+	}
+
+	var digits strings.Builder
+	var separator rune
+	group := 0
+	for i, r := range integer {
+		switch {
+		case unicode.IsDigit(r) && r < unicode.MaxASCII:
+			digits.WriteRune(r)
+			group++
+		case strings.ContainsRune(f.Grouping, r) && i > 0 && (separator == 0 || separator == r):
+			if group == 0 || group > 3 || (separator != 0 && group != 3) {
+				return 0, false, false
+			}
+			separator, group = r, 0
+		default:
+			return 0, false, false
+		}
+	}
+	if separator != 0 && group != 3 {
+		return 0, false, false
+	}
+
+	number := digits.String()
+	if decimals != "" {
+		number += "." + decimals
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	return v, fraction, err == nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	return r[len(r)-1]
+}
+
+// trimFirst removes the first rune and any space following it
+func trimFirst(s string) string {
+	_, rest, _ := strings.Cut(s, string(firstRune(s)))
+	return strings.TrimSpace(rest)
+}
+
+// trimLast removes the last rune and any space preceding it
+func trimLast(s string) string {
+	return strings.TrimSpace(strings.TrimSuffix(s, string(lastRune(s))))
+}
+
+// DetectNumberFormat returns the format that parses the most values,
+// preferring PointDecimal when the values fit both, e.g. 1,234
+func DetectNumberFormat(values []string) NumberFormat {
+	point, comma := 0, 0
+	for _, v := range values {
+		if _, err := PointDecimal.parse(v); err == nil {
+			point++
+		}
+		if _, err := CommaDecimal.parse(v); err == nil {
+			comma++
+		}
+	}
+	if comma > point {
+		return CommaDecimal
+	}
+	return PointDecimal
+}
@@ -0,0 +1,112 @@
+package dataset
+
+import "testing"
+
+func TestNumberFormatParse(t *testing.T) {
+	tests := []struct {
+		format NumberFormat
+		value  string
+		want   float64
+	}{
+		{PointDecimal, "1,234.56", 1234.56},
+		{PointDecimal, "$1,200", 1200},
+		{PointDecimal, "-$5", -5},
+		{PointDecimal, "45%", 45},
+		{PointDecimal, "(300)", -300},
+		{PointDecimal, "($1,250.50)", -1250.5},
+		{PointDecimal, "$ (1,000.00)", -1000},
+		{PointDecimal, "$(1,000)", -1000},
+		{CommaDecimal, "(1.000,00) €", -1000},
+		{PointDecimal, "300-", -300},
+		{PointDecimal, "1.5k", 1500},
+		{PointDecimal, "2M", 2e6},
+		{PointDecimal, "USD 12", 12},
+		{PointDecimal, "1.5e3", 1500},
+		{PointDecimal, "1 234 567", 1234567},
+		{CommaDecimal, "1.234,56", 1234.56},
+		{CommaDecimal, "1 234,5 €", 1234.5},
+		{CommaDecimal, "−12,5 %", -12.5},
+		{CommaDecimal, "3,2k", 3200},
+		{CommaDecimal, "EUR 7", 7},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.Parse(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("%s.Parse(%q) = %v, %v, want %v", tt.format.Name, tt.value, got, err, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		format NumberFormat
+		value  string
+	}{
+		{PointDecimal, "1,5"},
+		{PointDecimal, "1.234,56"},
+		{PointDecimal, "12,34,567"},
+		{CommaDecimal, "1.5"},
+		{PointDecimal, "$"},
+		{PointDecimal, "(-5)"},
+		{PointDecimal, "n/a"},
+		{PointDecimal, "Inf"},
+		{PointDecimal, "2024-01-01"},
+	}
+	for _, tt := range invalid {
+		if got, err := tt.format.Parse(tt.value); err == nil {
+			t.Errorf("%s.Parse(%q) = %v, want an error", tt.format.Name, tt.value, got)
+		}
+	}
+}
+
+func TestDetectNumberFormat(t *testing.T) {
+	if got := DetectNumberFormat([]string{"1.234,56", "12,5", "7"}); got != CommaDecimal {
+		t.Errorf("European values detected as %s", got.Name)
+	}
+	if got := DetectNumberFormat([]string{"1,234", "5,678"}); got != PointDecimal {
+		t.Errorf("ambiguous values detected as %s, want %s", got.Name, PointDecimal.Name)
+	}
+}
+
+func TestParseNumberFormat(t *testing.T) {
+	for name, want := range map[string]NumberFormat{
+		"auto": AutoNumberFormat, "point": PointDecimal, "comma": CommaDecimal,
+		"de": CommaDecimal, "fr_FR": CommaDecimal, "en-US": PointDecimal, "1.234,56": CommaDecimal,
+	} {
+		if got, err := ParseNumberFormat(name); err != nil || got != want {
+			t.Errorf("ParseNumberFormat(%q) = %s, %v, want %s", name, got.Name, err, want.Name)
+		}
+	}
+	if _, err := ParseNumberFormat("klingon"); err == nil {
+		t.Errorf("ParseNumberFormat accepted an unknown name")
+	}
+}
+
+func TestEuropeanColumns(t *testing.T) {
+	data, err := New([]string{"Amount", "Share"}, [][]string{
+		{"1.234,50", "12,5%"},
+		{"(300,00)", "7%"},
+		{"2.000", "80,5%"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	amount := data.Column("Amount")
+	if amount.Kind != KindFloat || amount.Number != CommaDecimal {
+		t.Fatalf("Amount detected as %s in %s", amount.Kind, amount.Number.Name)
+	}
+	if v, _ := amount.Float(1); v != -300 {
+		t.Errorf("Amount row 1 = %v, want -300", v)
+	}
+	if share := data.Column("Share"); share.Type != TypePercentage {
+		t.Errorf("Share detected as %s, want percentage", share.Type)
+	}
+
+	// Read as point decimals the amounts are no longer all numbers
+	point, err := data.SetNumberFormat(0, PointDecimal)
+	if err != nil {
+		t.Fatalf("SetNumberFormat failed: %v", err)
+	}
+	if col := point.Column("Amount"); col.Kind == KindFloat {
+		t.Errorf("Amount still numeric in %s", col.Number.Name)
+	}
+}
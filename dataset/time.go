@@ -0,0 +1,210 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TimeFormat describes how the dates and times of a column are written,
+// either as text in one of several layouts or as a number counting units
+// since an epoch
+type TimeFormat struct {
+	Name    string
+	layouts []string
+
+	// numeric timestamps: value * unit after epoch, plausible between min and max
+	unit     time.Duration
+	epoch    time.Time
+	min, max float64
+}
+
+// monthNameLayouts are unambiguous and accepted by every text format
+var monthNameLayouts = []string{
+	"2 Jan 2006", "2 January 2006", "Jan 2, 2006", "January 2, 2006", "Jan 2 2006",
+	"2-Jan-06", "2-Jan-2006", "Jan 2006", "January 2006", "Jan-06",
+	time.RFC1123, time.RFC1123Z,
+}
+
+var (
+	// AutoTimeFormat detects the format of each column from its values
+	AutoTimeFormat = TimeFormat{Name: "auto"}
+
+	// ISODates are written year first, e.g. 2024-01-31 or 2024-01-31T08:00:00Z
+	ISODates = TimeFormat{Name: "yyyy-mm-dd", layouts: append([]string{
+		time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04",
+		"2006-01-02 15:04", "2006-01-02", "2006/01/02 15:04:05", "2006/01/02", "2006-01",
+	}, monthNameLayouts...)}
+
+	// MonthFirst dates are used in the US, e.g. 01/31/2024. Excel formats
+	// its default date style as 01-31-24.
+	MonthFirst = TimeFormat{Name: "mm/dd/yyyy", layouts: append([]string{
+		"1/2/2006", "1/2/2006 15:04", "1/2/2006 15:04:05", "1/2/2006 3:04 PM", "1/2/2006 3:04:05 PM",
+		"1/2/06", "1/2/06 15:04", "01-02-06", "1-2-2006",
+	}, monthNameLayouts...)}
+
+	// DayFirst dates are used in most other countries, e.g. 31/01/2024 or 31.01.2024
+	DayFirst = TimeFormat{Name: "dd/mm/yyyy", layouts: append([]string{
+		"2/1/2006", "2/1/2006 15:04", "2/1/2006 15:04:05", "2/1/06",
+		"2.1.2006", "2.1.2006 15:04", "2.1.2006 15:04:05", "2.1.06", "2-1-2006",
+	}, monthNameLayouts...)}
+
+	// UnixSeconds and UnixMillis count from 1970-01-01 UTC
+	UnixSeconds = TimeFormat{Name: "unix seconds", unit: time.Second, epoch: time.Unix(0, 0).UTC(), min: 315532800, max: 4102444800}
+	UnixMillis  = TimeFormat{Name: "unix milliseconds", unit: time.Millisecond, epoch: time.Unix(0, 0).UTC(), min: 315532800e3, max: 4102444800e3}
+
+	// ExcelSerial counts days since 1899-12-30, as spreadsheets store dates
+	ExcelSerial = TimeFormat{Name: "excel serial", unit: 24 * time.Hour, epoch: time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC), min: 18264, max: 73051}
+
+	// TimeFormats lists the formats offered to users
+	TimeFormats = []TimeFormat{AutoTimeFormat, ISODates, MonthFirst, DayFirst, UnixSeconds, UnixMillis, ExcelSerial}
+
+	textTimeFormats    = []TimeFormat{ISODates, MonthFirst, DayFirst}
+	numericTimeFormats = []TimeFormat{UnixSeconds, UnixMillis, ExcelSerial}
+)
+
+// timeNameWords are the words of headers of columns likely to hold
+// timestamps, so numbers in them are tried as epochs and Excel serial dates
+var timeNameWords = map[string]bool{
+	"date": true, "dates": true, "time": true, "day": true, "epoch": true,
+	"timestamp": true, "datetime": true, "ts": true,
+}
+
+// isTimeName reports whether a header names a column likely to hold
+// timestamps: one of its words is a timeNameWords word, or it ends in "at"
+// as in created_at. Words are split at spaces, punctuation and case
+// changes, so "OrderDate" is a time name and "Updates" or "Runtime" not.
+func isTimeName(name string) bool {
+	words := nameWords(name)
+	for _, word := range words {
+		if timeNameWords[word] {
+			return true
+		}
+	}
+	return len(words) > 1 && words[len(words)-1] == "at"
+}
+
+// nameWords splits a header into lower case words at characters other than
+// letters and digits and where camelCase starts a new word
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+				word = word[:0]
+			}
+			continue
+		}
+		// A capital starts a word after a lower case letter, and ends a run
+		// of capitals when a lower case letter follows, as in HTTPDate
+		if len(word) > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				words = append(words, strings.ToLower(string(word)))
+				word = word[:0]
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+	return words
+}
+
+// ParseTimeFormat returns the time format with the given name. Besides the
+// format names it accepts iso, mdy, dmy, unix, unix-ms and excel.
+func ParseTimeFormat(name string) (TimeFormat, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, f := range TimeFormats {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	switch name {
+	case "iso":
+		return ISODates, nil
+	case "mdy", "us":
+		return MonthFirst, nil
+	case "dmy", "eu":
+		return DayFirst, nil
+	case "unix":
+		return UnixSeconds, nil
+	case "unix-ms":
+		return UnixMillis, nil
+	case "excel":
+		return ExcelSerial, nil
+	}
+	return AutoTimeFormat, fmt.Errorf("unknown time format %q, use auto, iso, mdy, dmy, unix, unix-ms or excel", name)
+}
+
+// Auto reports whether the format is detected from the values
+func (f TimeFormat) Auto() bool {
+	return f.layouts == nil && f.unit == 0
+}
+
+// Parse converts a formatted date or time. Times without a zone are UTC.
+// AutoTimeFormat tries the text formats in the order of TimeFormats.
+func (f TimeFormat) Parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if f.Auto() {
+		for _, text := range textTimeFormats {
+			if t, err := text.Parse(value); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid time value: %s", value)
+	}
+
+	if f.unit != 0 {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n < f.min || n > f.max {
+			return time.Time{}, fmt.Errorf("invalid %s value: %s", f.Name, value)
+		}
+		whole, fraction := math.Modf(n)
+		t := f.epoch.Add(time.Duration(whole) * f.unit).Add(time.Duration(fraction * float64(f.unit)))
+		return t.Round(time.Millisecond), nil
+	}
+
+	for _, layout := range f.layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s value: %s", f.Name, value)
+}
+
+// DetectTimeFormat returns the format parsing the most values and the share
+// of values it parses. Ambiguous dates such as 01/02/2024 are read month
+// first unless a value only fits day first. Numeric timestamps are only
+// considered when numeric is set.
+func DetectTimeFormat(values []string, numeric bool) (TimeFormat, float64) {
+	candidates := textTimeFormats
+	if numeric {
+		candidates = append(append([]TimeFormat(nil), textTimeFormats...), numericTimeFormats...)
+	}
+
+	best, bestCount := AutoTimeFormat, 0
+	for _, f := range candidates {
+		count := 0
+		for _, v := range values {
+			if _, err := f.Parse(v); err == nil {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = f, count
+		}
+	}
+	if len(values) == 0 {
+		return best, 0
+	}
+	return best, float64(bestCount) / float64(len(values))
+}
@@ -0,0 +1,120 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormatParse(t *testing.T) {
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	noon := time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		format TimeFormat
+		value  string
+		want   time.Time
+	}{
+		{ISODates, "2024-01-31", date},
+		{ISODates, "2024-01-31T12:30:00Z", noon},
+		{ISODates, "2024-01-31 12:30", noon},
+		{ISODates, "31 Jan 2024", date},
+		{MonthFirst, "01/31/2024", date},
+		{MonthFirst, "1/31/2024 12:30", noon},
+		{MonthFirst, "01-31-24", date},
+		{DayFirst, "31/01/2024", date},
+		{DayFirst, "31.01.2024 12:30", noon},
+		{UnixSeconds, "1706704200", noon},
+		{UnixMillis, "1706704200000", noon},
+		{ExcelSerial, "45322", date},
+		{ExcelSerial, "45322.5208333333", noon},
+		{AutoTimeFormat, "Jan 31, 2024", date},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.Parse(tt.value)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s.Parse(%q) = %v, %v, want %v", tt.format.Name, tt.value, got, err, tt.want)
+		}
+	}
+
+	if _, err := MonthFirst.Parse("31/01/2024"); err == nil {
+		t.Errorf("MonthFirst accepted day 31 as a month")
+	}
+	if _, err := UnixSeconds.Parse("42"); err == nil {
+		t.Errorf("UnixSeconds accepted an implausible timestamp")
+	}
+}
+
+func TestDetectTimeFormat(t *testing.T) {
+	tests := []struct {
+		values  []string
+		numeric bool
+		want    TimeFormat
+	}{
+		{[]string{"2024-01-02", "2024-01-03"}, false, ISODates},
+		{[]string{"01/02/2024", "03/04/2024"}, false, MonthFirst},
+		{[]string{"01/02/2024", "25/04/2024"}, false, DayFirst},
+		{[]string{"1706704200", "1706790600"}, true, UnixSeconds},
+		{[]string{"45322", "45323"}, true, ExcelSerial},
+	}
+	for _, tt := range tests {
+		if got, share := DetectTimeFormat(tt.values, tt.numeric); got.Name != tt.want.Name || share != 1 {
+			t.Errorf("DetectTimeFormat(%q) = %s at %v, want %s", tt.values, got.Name, share, tt.want.Name)
+		}
+	}
+	if got, _ := DetectTimeFormat([]string{"45322"}, false); !got.Auto() {
+		t.Errorf("numbers detected as %s without numeric", got.Name)
+	}
+}
+
+func TestTimeColumns(t *testing.T) {
+	data, err := New([]string{"Date", "Timestamp", "Count"}, [][]string{
+		{"31/01/2024", "1706704200", "45322"},
+		{"01/02/2024", "1706790600", "45323"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for _, name := range []string{"Date", "Timestamp"} {
+		if col := data.Column(name); col.Kind != KindTime {
+			t.Errorf("column %s detected as %s, want time", name, col.Kind)
+		}
+	}
+	if v, _ := data.Column("Date").Time(1); v.Month() != time.February {
+		t.Errorf("Date row 1 = %v, want 1 February", v)
+	}
+	if col := data.Column("Count"); col.Kind != KindInt {
+		t.Errorf("Count without a time header detected as %s", col.Kind)
+	}
+
+	serial, err := data.SetTimeFormat(2, ExcelSerial)
+	if err != nil {
+		t.Fatalf("SetTimeFormat failed: %v", err)
+	}
+	if v, _ := serial.Column("Count").Time(0); v.Year() != 2024 || !serial.Column("Count").Overridden {
+		t.Errorf("Count as Excel serial = %v", v)
+	}
+}
+
+func TestIsTimeName(t *testing.T) {
+	for _, name := range []string{"Date", "order_date", "OrderDate", "HTTPDate", "Timestamp", "event-time", "Unix Epoch", "TS", "created_at", "createdAt"} {
+		if !isTimeName(name) {
+			t.Errorf("%q is not taken for a time name", name)
+		}
+	}
+	for _, name := range []string{"Updates", "Candidates", "Runtime", "Holidays", "Chat", "Count", "at"} {
+		if isTimeName(name) {
+			t.Errorf("%q is taken for a time name", name)
+		}
+	}
+
+	// Counts under such headers stay numbers even in the Excel serial range
+	data, err := New([]string{"Updates", "Candidates", "Runtime"}, [][]string{{"45322", "18264", "73051"}, {"45323", "20000", "70000"}}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for _, col := range data.Columns {
+		if col.Kind != KindInt {
+			t.Errorf("column %s detected as %s, want int", col.Name, col.Kind)
+		}
+	}
+}
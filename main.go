@@ -1,6 +1,7 @@
 package main
 
 import (
+	"graph-viewer/cli"
 	"graph-viewer/ui"
 	"os"
 	"runtime/pprof"
@@ -10,6 +11,11 @@ import (
 )
 
 func main() {
+	// Any arguments select a headless subcommand instead of the GUI
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// CPU profiling
 	cpuFile, _ := os.Create("cpu.prof")
 	pprof.StartCPUProfile(cpuFile)
@@ -0,0 +1,423 @@
+// Package ods reads OpenDocument spreadsheets (.ods) as saved by LibreOffice
+// and OpenOffice.
+//
+// Only cell values are read: each sheet becomes rows of cell text, written
+// the way package xls writes Excel cells, with numbers and currencies in full
+// without grouping, percentages with a percent sign and dates as ISO 8601.
+package ods
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespaces of the elements and attributes read from content.xml
+const (
+	tableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	officeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	textNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	configNS = "urn:oasis:names:tc:opendocument:xmlns:config:1.0"
+)
+
+// maxRows and maxColumns bound the cells a sheet may expand to, as large as
+// LibreOffice sheets get
+const (
+	maxRows    = 1 << 20
+	maxColumns = 1 << 14
+)
+
+// Workbook holds the sheets of an ODS file
+type Workbook struct {
+	Sheets []*Sheet // sheets in workbook order
+	Active int      // index into Sheets of the sheet shown when the file was saved
+}
+
+// Sheet is a sheet with the text of its cells. Rows end at their last
+// non-empty cell and the rows after the last non-empty row are left out.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// ErrNotODS is returned for files that are not OpenDocument spreadsheets
+var ErrNotODS = errors.New("not an OpenDocument spreadsheet")
+
+// Open reads the workbook at path
+func Open(path string) (*Workbook, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Read(file, stat.Size())
+}
+
+// Read reads a workbook from the zip archive in r
+func Read(r io.ReaderAt, size int64) (*Workbook, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotODS, err)
+	}
+
+	var content, settings *zip.File
+	for _, file := range archive.File {
+		switch file.Name {
+		case "content.xml":
+			content = file
+		case "settings.xml":
+			settings = file
+		}
+	}
+	if content == nil {
+		return nil, fmt.Errorf("%w: no content.xml", ErrNotODS)
+	}
+
+	book, err := readXML(content, parseContent)
+	if err != nil {
+		return nil, fmt.Errorf("reading content.xml: %w", err)
+	}
+	if len(book.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook contains no sheets")
+	}
+
+	// The active sheet is only a view setting, so it is not worth failing for
+	if settings != nil {
+		if active, err := readXML(settings, parseActiveSheet); err == nil {
+			if _, index, ok := book.sheet(active); ok {
+				book.Active = index
+			}
+		}
+	}
+	return book, nil
+}
+
+// readXML parses a file of the archive
+func readXML[T any](file *zip.File, parse func(*xml.Decoder) (T, error)) (T, error) {
+	r, err := file.Open()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer r.Close()
+	return parse(xml.NewDecoder(r))
+}
+
+// Sheet returns the sheet with the given name, ignoring case
+func (w *Workbook) Sheet(name string) (*Sheet, bool) {
+	sheet, _, ok := w.sheet(name)
+	return sheet, ok
+}
+
+func (w *Workbook) sheet(name string) (*Sheet, int, bool) {
+	for i, sheet := range w.Sheets {
+		if strings.EqualFold(sheet.Name, name) {
+			return sheet, i, true
+		}
+	}
+	return nil, 0, false
+}
+
+// SheetNames returns the names of the sheets in workbook order
+func (w *Workbook) SheetNames() []string {
+	names := make([]string, len(w.Sheets))
+	for i, sheet := range w.Sheets {
+		names[i] = sheet.Name
+	}
+	return names
+}
+
+// parseContent reads the sheets of content.xml. Rows may be grouped in
+// header rows and row groups, which are read as if they were not.
+func parseContent(decoder *xml.Decoder) (*Workbook, error) {
+	book := &Workbook{}
+	var sheet *sheetBuilder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return book, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch {
+			case element.Name.Space != tableNS:
+			case element.Name.Local == "table":
+				sheet = &sheetBuilder{sheet: &Sheet{Name: attr(element, tableNS, "name")}}
+			case element.Name.Local == "table-row" && sheet != nil:
+				row, err := parseRow(decoder, element)
+				if err == nil {
+					err = sheet.add(row, repeat(element, "number-rows-repeated"))
+				}
+				if err != nil {
+					return nil, fmt.Errorf("sheet %s: %w", sheet.sheet.Name, err)
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Space == tableNS && element.Name.Local == "table" && sheet != nil {
+				book.Sheets = append(book.Sheets, sheet.sheet)
+				sheet = nil
+			}
+		}
+	}
+}
+
+// sheetBuilder adds rows to a sheet, holding back empty rows until a row
+// with values follows so the empty rows padding the end of a sheet, often
+// repeated a million times, are never expanded
+type sheetBuilder struct {
+	sheet *Sheet
+	empty int // empty rows held back
+}
+
+func (b *sheetBuilder) add(row []string, repeated int) error {
+	if len(row) == 0 {
+		b.empty += repeated
+		return nil
+	}
+	if len(b.sheet.Rows)+b.empty+repeated > maxRows {
+		return fmt.Errorf("sheet has more than %d rows", maxRows)
+	}
+	for ; b.empty > 0; b.empty-- {
+		b.sheet.Rows = append(b.sheet.Rows, nil)
+	}
+	for i := 0; i < repeated; i++ {
+		b.sheet.Rows = append(b.sheet.Rows, row)
+	}
+	return nil
+}
+
+// parseRow reads the cells of a table-row element up to its end. Empty
+// cells are held back like empty rows, so the row ends at its last value.
+func parseRow(decoder *xml.Decoder, start xml.StartElement) ([]string, error) {
+	var row []string
+	empty := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Space != tableNS || (element.Name.Local != "table-cell" && element.Name.Local != "covered-table-cell") {
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			text, err := parseCell(decoder, element)
+			if err != nil {
+				return nil, err
+			}
+			repeated := repeat(element, "number-columns-repeated")
+			if text == "" {
+				empty += repeated
+				continue
+			}
+			if len(row)+empty+repeated > maxColumns {
+				return nil, fmt.Errorf("row has more than %d columns", maxColumns)
+			}
+			for ; empty > 0; empty-- {
+				row = append(row, "")
+			}
+			for i := 0; i < repeated; i++ {
+				row = append(row, text)
+			}
+		case xml.EndElement:
+			if element.Name == start.Name {
+				return row, nil
+			}
+		}
+	}
+}
+
+// parseCell returns the text of a cell and reads up to its end. Typed
+// cells are written from their value rather than their displayed text.
+func parseCell(decoder *xml.Decoder, start xml.StartElement) (string, error) {
+	text, err := cellText(decoder, start)
+	if err != nil {
+		return "", err
+	}
+
+	value := attr(start, officeNS, "value")
+	switch attr(start, officeNS, "value-type") {
+	case "float", "currency":
+		return formatFloat(value, 1, "", text), nil
+	case "percentage":
+		return formatFloat(value, 100, "%", text), nil
+	case "date":
+		return formatDate(attr(start, officeNS, "date-value"), text), nil
+	case "time":
+		return formatTime(attr(start, officeNS, "time-value"), text), nil
+	case "boolean":
+		if b, err := strconv.ParseBool(attr(start, officeNS, "boolean-value")); err == nil {
+			return strings.ToUpper(strconv.FormatBool(b)), nil
+		}
+		return text, nil
+	case "string":
+		if value := attr(start, officeNS, "string-value"); value != "" {
+			return value, nil
+		}
+		return text, nil
+	default:
+		return text, nil
+	}
+}
+
+// cellText returns the displayed text of a cell: its paragraphs joined by
+// line breaks, with spaces, tabs and line breaks expanded and annotations
+// left out
+func cellText(decoder *xml.Decoder, start xml.StartElement) (string, error) {
+	var b strings.Builder
+	paragraphs := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch {
+			case element.Name.Space == officeNS && element.Name.Local == "annotation":
+				if err := decoder.Skip(); err != nil {
+					return "", err
+				}
+			case element.Name.Space != textNS:
+			case element.Name.Local == "p" || element.Name.Local == "h":
+				if paragraphs > 0 {
+					b.WriteByte('\n')
+				}
+				paragraphs++
+			case element.Name.Local == "s":
+				b.WriteString(strings.Repeat(" ", repeat(element, "c")))
+			case element.Name.Local == "tab":
+				b.WriteByte('\t')
+			case element.Name.Local == "line-break":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			b.Write(element)
+		case xml.EndElement:
+			if element.Name == start.Name {
+				return b.String(), nil
+			}
+		}
+	}
+}
+
+// formatFloat writes value times factor in full followed by suffix, or
+// returns text when value is not a number
+func formatFloat(value string, factor float64, suffix, text string) string {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return text
+	}
+	if factor != 1 {
+		// Round away the binary error of scaling, e.g. 0.07 * 100
+		f, _ = strconv.ParseFloat(strconv.FormatFloat(f*factor, 'g', 15, 64), 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64) + suffix
+}
+
+// formatDate writes a date value as ISO 8601, leaving out the time of day
+// when it is midnight, or returns text when value is not a date
+func formatDate(value, text string) string {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04:05.999999999Z07:00", "2006-01-02"} {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return text
+}
+
+// formatTime writes a duration such as PT10H30M00S as a time of day, or
+// returns text when value is not a duration
+func formatTime(value, text string) string {
+	rest, ok := strings.CutPrefix(value, "PT")
+	if !ok {
+		return text
+	}
+	var total time.Duration
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"H", time.Hour}, {"M", time.Minute}, {"S", time.Second}} {
+		number, after, found := strings.Cut(rest, unit.suffix)
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return text
+		}
+		total += time.Duration(n * float64(unit.size))
+		rest = after
+	}
+	if rest != "" {
+		return text
+	}
+	return time.Time{}.Add(total.Round(time.Second)).Format("15:04:05")
+}
+
+// parseActiveSheet returns the name of the active sheet in settings.xml
+func parseActiveSheet(decoder *xml.Decoder) (string, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Space != configNS || element.Name.Local != "config-item" || attr(element, configNS, "name") != "ActiveTable" {
+			continue
+		}
+		var name string
+		if err := decoder.DecodeElement(&name, &element); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+}
+
+// attr returns the value of an attribute, empty when it is missing
+func attr(element xml.StartElement, space, local string) string {
+	for _, a := range element.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// repeat returns a repetition count attribute, 1 when it is missing
+func repeat(element xml.StartElement, local string) int {
+	space := tableNS
+	if local == "c" {
+		space = textNS
+	}
+	n, err := strconv.Atoi(attr(element, space, local))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package ods
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const testContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+  xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+  xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+ <office:body><office:spreadsheet>
+  <table:table table:name="Data">
+   <table:table-column table:number-columns-repeated="4"/>
+   <table:table-header-rows>
+    <table:table-row>
+     <table:table-cell office:value-type="string"><text:p>Region</text:p></table:table-cell>
+     <table:table-cell office:value-type="string"><text:p>Day</text:p></table:table-cell>
+     <table:table-cell office:value-type="string"><text:p>Share</text:p></table:table-cell>
+     <table:table-cell office:value-type="string"><text:p>Revenue</text:p></table:table-cell>
+    </table:table-row>
+   </table:table-header-rows>
+   <table:table-row table:number-rows-repeated="2">
+    <table:table-cell office:value-type="string"><text:p>North<text:s text:c="2"/>East</text:p></table:table-cell>
+    <table:table-cell office:value-type="date" office:date-value="2024-01-31"><text:p>31/01/24</text:p></table:table-cell>
+    <table:table-cell office:value-type="percentage" office:value="0.07"><text:p>7%</text:p></table:table-cell>
+    <table:table-cell office:value-type="currency" office:currency="EUR" office:value="1234.5"><text:p>1.234,50 €</text:p></table:table-cell>
+    <table:table-cell table:number-columns-repeated="1020"/>
+   </table:table-row>
+   <table:table-row table:number-rows-repeated="3"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+   <table:table-row>
+    <table:table-cell table:number-columns-repeated="2"/>
+    <table:table-cell office:value-type="float" office:value="1E3"><text:p>1.000</text:p>
+     <office:annotation><text:p>checked</text:p></office:annotation></table:table-cell>
+    <table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>TRUE</text:p></table:table-cell>
+   </table:table-row>
+   <table:table-row>
+    <table:table-cell office:value-type="date" office:date-value="2024-02-01T12:30:00"><text:p>01/02/24 12:30</text:p></table:table-cell>
+    <table:table-cell office:value-type="time" office:time-value="PT10H05M30S"><text:p>10:05:30</text:p></table:table-cell>
+    <table:table-cell><text:p>two</text:p><text:p>lines</text:p></table:table-cell>
+   </table:table-row>
+   <table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+  </table:table>
+  <table:table table:name="Notes">
+   <table:table-row><table:table-cell office:value-type="string"><text:p>Note</text:p></table:table-cell></table:table-row>
+  </table:table>
+ </office:spreadsheet></office:body>
+</office:document-content>`
+
+const testSettings = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-settings xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+  xmlns:config="urn:oasis:names:tc:opendocument:xmlns:config:1.0">
+ <office:settings><config:config-item-set config:name="ooo:view-settings">
+  <config:config-item config:name="ActiveTable" config:type="string">Notes</config:config-item>
+ </config:config-item-set></office:settings>
+</office:document-settings>`
+
+// testFile zips the files into an ODS archive
+func testFile(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(b.Bytes())
+}
+
+func TestRead(t *testing.T) {
+	file := testFile(t, map[string]string{
+		"mimetype":     "application/vnd.oasis.opendocument.spreadsheet",
+		"content.xml":  testContent,
+		"settings.xml": testSettings,
+	})
+	book, err := Read(file, file.Size())
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if got := book.SheetNames(); !reflect.DeepEqual(got, []string{"Data", "Notes"}) {
+		t.Errorf("sheets = %v, want [Data Notes]", got)
+	}
+	if book.Active != 1 {
+		t.Errorf("active sheet = %d, want 1", book.Active)
+	}
+
+	data, ok := book.Sheet("data")
+	if !ok {
+		t.Fatal("sheet Data not found")
+	}
+	want := [][]string{
+		{"Region", "Day", "Share", "Revenue"},
+		{"North  East", "2024-01-31", "7%", "1234.5"},
+		{"North  East", "2024-01-31", "7%", "1234.5"},
+		nil, nil, nil,
+		{"", "", "1000", "TRUE"},
+		{"2024-02-01 12:30:00", "10:05:30", "two\nlines"},
+	}
+	if !reflect.DeepEqual(data.Rows, want) {
+		t.Errorf("rows = %q, want %q", data.Rows, want)
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("Region,Revenue\n")), 15); err == nil {
+		t.Errorf("Read accepted a CSV file")
+	}
+	file := testFile(t, map[string]string{"word/document.xml": "<document/>"})
+	if _, err := Read(file, file.Size()); err == nil {
+		t.Errorf("Read accepted an archive without content.xml")
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	for value, want := range map[string]string{"PT10H05M30S": "10:05:30", "PT0H0M1.6S": "00:00:02", "PT45M": "00:45:00", "P1D": "P1D", "PT1X": "PT1X"} {
+		if got := formatTime(value, value); got != want {
+			t.Errorf("formatTime(%s) = %s, want %s", value, got, want)
+		}
+	}
+}
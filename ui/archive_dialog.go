@@ -0,0 +1,54 @@
+﻿package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showArchiveEntries asks which data file of a zip archive to open and
+// passes the path it is opened by to callback. An archive holding one data
+// file opens it without asking.
+func showArchiveEntries(window fyne.Window, filePath string, callback func(string)) {
+	entries, err := listArchiveEntries(filePath)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	switch len(entries) {
+	case 0:
+		dialog.ShowError(fmt.Errorf("%s holds no data files", filepath.Base(filePath)), window)
+		return
+	case 1:
+		callback(archiveEntryPath(filePath, entries[0]))
+		return
+	}
+
+	entrySelector := widget.NewRadioGroup(entries, nil)
+	entrySelector.Required = true
+	entrySelector.SetSelected(entries[0])
+	content := container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("%s holds %d data files", filepath.Base(filePath), len(entries))), nil, nil, nil,
+		container.NewVScroll(entrySelector),
+	)
+
+	archiveDialog := dialog.NewCustomConfirm(
+		"Open File in Archive",
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if confirmed {
+				callback(archiveEntryPath(filePath, entrySelector.Selected))
+			}
+		},
+		window,
+	)
+
+	archiveDialog.Resize(fyne.NewSize(500, 400))
+	archiveDialog.Show()
+}
@@ -0,0 +1,146 @@
+﻿package ui
+
+import (
+	"fmt"
+	"graph-viewer/dataset"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newColumnTypesPanel lists every column with its inferred type and lets the
+// user override it, along with the format numbers are written in. onChange
+// receives the data with the changes applied.
+func newColumnTypesPanel(data *dataset.Dataset, window fyne.Window, onChange func(*dataset.Dataset)) fyne.CanvasObject {
+	grid := container.NewGridWithColumns(3)
+
+	var fillRows func()
+	update := func(updated *dataset.Dataset) {
+		data = updated
+		fillRows()
+		onChange(data)
+	}
+
+	fillRows = func() {
+		grid.RemoveAll()
+		for i, col := range data.Columns {
+			grid.Add(widget.NewLabel(col.Name))
+			grid.Add(newTypeSelector(data, i, window, update))
+			grid.Add(widget.NewLabel(describeInference(col)))
+		}
+	}
+	fillRows()
+
+	formatNames := make([]string, len(dataset.NumberFormats))
+	for i, f := range dataset.NumberFormats {
+		formatNames[i] = f.Name
+	}
+	numberFormat := widget.NewSelect(formatNames, nil)
+	numberFormat.SetSelected(dataset.AutoNumberFormat.Name)
+	numberFormat.OnChanged = func(name string) {
+		f, err := dataset.ParseNumberFormat(name)
+		if err == nil {
+			var updated *dataset.Dataset
+			if updated, err = data.WithNumberFormat(f); err == nil {
+				update(updated)
+				return
+			}
+		}
+		dialog.ShowError(err, window)
+	}
+
+	scroll := container.NewVScroll(grid)
+	scroll.SetMinSize(fyne.NewSize(0, 150))
+	content := container.NewBorder(widget.NewForm(widget.NewFormItem("Numbers", numberFormat)), nil, nil, nil, scroll)
+	return widget.NewAccordion(widget.NewAccordionItem("Column Types", content))
+}
+
+// timeTypePrefix starts the type options naming a time format
+const timeTypePrefix = "date/time: "
+
+// typeOptions lists the semantic types, with date/time offered once per
+// time format
+func typeOptions() []string {
+	var options []string
+	for _, t := range dataset.SemanticTypes {
+		options = append(options, t.String())
+		if t != dataset.TypeDateTime {
+			continue
+		}
+		for _, f := range dataset.TimeFormats {
+			if !f.Auto() {
+				options = append(options, timeTypePrefix+f.Name)
+			}
+		}
+	}
+	return options
+}
+
+// typeOption returns the option describing the type of col
+func typeOption(col *dataset.Column) string {
+	if col.Type == dataset.TypeDateTime && col.Kind == dataset.KindTime && !col.TimeFormat.Auto() {
+		return timeTypePrefix + col.TimeFormat.Name
+	}
+	return col.Type.String()
+}
+
+// newTypeSelector creates the type selection of the column at index. A type
+// the values do not convert to is reported and the selection reverted.
+func newTypeSelector(data *dataset.Dataset, index int, window fyne.Window, update func(*dataset.Dataset)) *widget.Select {
+	current := typeOption(data.Columns[index])
+	selector := widget.NewSelect(typeOptions(), nil)
+	selector.SetSelected(current)
+	selector.OnChanged = func(name string) {
+		if name == current {
+			return
+		}
+
+		updated, err := setColumnType(data, index, name)
+		if err == nil {
+			update(updated)
+			return
+		}
+
+		dialog.ShowError(err, window)
+		selector.SetSelected(current)
+	}
+	return selector
+}
+
+// setColumnType applies a type option to the column at index
+func setColumnType(data *dataset.Dataset, index int, option string) (*dataset.Dataset, error) {
+	if name, ok := strings.CutPrefix(option, timeTypePrefix); ok {
+		f, err := dataset.ParseTimeFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		return data.SetTimeFormat(index, f)
+	}
+
+	t, err := dataset.ParseSemanticType(option)
+	if err != nil {
+		return nil, err
+	}
+	return data.SetType(index, t)
+}
+
+// describeInference explains where a column's type comes from
+func describeInference(col *dataset.Column) string {
+	switch {
+	case col.Overridden:
+		return "set manually"
+	case col.Declared:
+		return "declared by the source"
+	case col.Confidence < dataset.MinConfidence:
+		return fmt.Sprintf("%.0f%% of sample, not applied", col.Confidence*100)
+	case col.Kind == dataset.KindString && col.Type != dataset.TypeText:
+		return fmt.Sprintf("%.0f%% of sample, some rows differ", col.Confidence*100)
+	case col.Type.Numeric():
+		return fmt.Sprintf("%.0f%% of sample, %s", col.Confidence*100, col.Number.Name)
+	default:
+		return fmt.Sprintf("%.0f%% of sample", col.Confidence*100)
+	}
+}
@@ -0,0 +1,323 @@
+﻿package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"graph-viewer/dataset"
+)
+
+// isCombined reports whether a path names several files to read as one: a
+// folder, whose data files are read, or a glob pattern such as
+// reports/2026-*.csv
+func isCombined(filePath string) bool {
+	stat, err := os.Stat(filePath)
+	if err == nil {
+		return stat.IsDir()
+	}
+	return strings.ContainsAny(filePath, "*?[")
+}
+
+// isDataFile reports whether a file has the extension of a format read as
+// a stream of rows, so it is picked from folders and archives
+func isDataFile(name string) bool {
+	return isDelimited(name) || isLog(name) || isJSON(name) || isWorkbook(name) || isParquet(name)
+}
+
+// listCombinedFiles returns the files a folder or glob pattern names, in
+// name order. Hidden files are left out, and so are logs in a folder as
+// they need a line format; a pattern such as *.log reads them.
+func listCombinedFiles(filePath string) ([]string, error) {
+	var files []string
+	if stat, err := os.Stat(filePath); err == nil && stat.IsDir() {
+		entries, err := os.ReadDir(filePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") && isDataFile(entry.Name()) && !isLog(entry.Name()) {
+				files = append(files, filepath.Join(filePath, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s holds no data files", filePath)
+		}
+		return files, nil
+	}
+
+	matches, err := filepath.Glob(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file pattern %s: %w", filePath, err)
+	}
+	for _, match := range matches {
+		if stat, err := os.Stat(match); err == nil && stat.Mode().IsRegular() && !strings.HasPrefix(filepath.Base(match), ".") {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", filePath)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// combinedRoot returns the folder the files of a folder or glob pattern are
+// named relative to in the source column: the folder itself, or the
+// deepest folder of the pattern without wildcards
+func combinedRoot(filePath string) string {
+	if stat, err := os.Stat(filePath); err == nil && stat.IsDir() {
+		return filePath
+	}
+	root := filepath.Dir(filePath)
+	for strings.ContainsAny(root, "*?[") {
+		root = filepath.Dir(root)
+	}
+	return root
+}
+
+// sampleFile returns the file whose start tells how the files named by
+// filePath are written: the first of several combined files, else filePath
+func sampleFile(filePath string) (string, error) {
+	if !isCombined(filePath) {
+		return filePath, nil
+	}
+	files, err := listCombinedFiles(filePath)
+	if err != nil {
+		return "", err
+	}
+	return files[0], nil
+}
+
+// columnKey names a column across files: its header and which of the
+// columns with that header in its file it is
+type columnKey struct {
+	name  string
+	index int
+}
+
+// columnKeys returns the keys of the columns of a header row
+func columnKeys(headers []string) []columnKey {
+	keys := make([]columnKey, len(headers))
+	seen := make(map[string]int, len(headers))
+	for i, header := range headers {
+		name := strings.TrimSpace(header)
+		keys[i] = columnKey{name, seen[name]}
+		seen[name]++
+	}
+	return keys
+}
+
+// openCombined opens the files named by a folder or glob pattern to be read
+// as one. Each file is read as options say, and its columns are matched to
+// those of the others by header: the header row holds every column in the
+// order the files first have them, and a file's rows are empty in the
+// columns it lacks. options.SourceColumn adds a column naming the file each
+// row comes from.
+func openCombined(filePath string, options ReadOptions) (rowSource, error) {
+	files, err := listCombinedFiles(filePath)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	rows := &combinedRows{
+		root:    combinedRoot(filePath),
+		options: options,
+		columns: make(map[columnKey]int),
+		counter: &countingReader{},
+	}
+	combined := rowSource{rows: rows, counter: rows.counter, close: rows.close}
+	types := make(map[columnKey]dataset.SemanticType)
+	conflicts := make(map[columnKey]bool)
+	totals := true
+	for _, file := range files {
+		source, err := openRows(file, options)
+		if err != nil {
+			return rowSource{}, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		headers, err := source.rows.Read()
+		source.close()
+		if err == io.EOF {
+			continue // an empty file adds no columns or rows
+		}
+		if err != nil {
+			return rowSource{}, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+
+		keys := columnKeys(headers)
+		for i, key := range keys {
+			if _, ok := rows.columns[key]; !ok {
+				rows.columns[key] = len(rows.headers)
+				rows.headers = append(rows.headers, headers[i])
+			}
+			// A type declared differently by two files is inferred instead
+			if t, ok := source.types[i]; ok {
+				if previous, seen := types[key]; seen && previous != t {
+					conflicts[key] = true
+				}
+				types[key] = t
+			}
+		}
+		rows.files = append(rows.files, file)
+		combined.size += source.size
+		combined.total += max(source.total-1, 0)
+		totals = totals && source.total > 0
+	}
+	if len(rows.files) == 0 {
+		return rowSource{}, fmt.Errorf("the files matching %s are empty", filePath)
+	}
+
+	for key, t := range types {
+		if !conflicts[key] {
+			if combined.types == nil {
+				combined.types = make(map[int]dataset.SemanticType)
+			}
+			combined.types[rows.columns[key]] = t
+		}
+	}
+	if options.SourceColumn != "" {
+		if _, ok := rows.columns[columnKey{options.SourceColumn, 0}]; ok {
+			return rowSource{}, fmt.Errorf("the files already have a column %q, choose another name for the source column", options.SourceColumn)
+		}
+		rows.headers = append(rows.headers, options.SourceColumn)
+	}
+	if totals {
+		combined.total++ // the header row
+	} else {
+		combined.total = 0
+	}
+	return combined, nil
+}
+
+// combinedRows reads the rows of one file after another, aligned to the
+// columns of all of them. Rows with more or fewer fields than the header
+// of their file are repaired there, as options.Ragged says.
+type combinedRows struct {
+	root    string
+	options ReadOptions
+	files   []string
+	headers []string
+	columns map[columnKey]int // index of each column in headers
+
+	started bool
+	next    int       // index of the next file to open
+	current rowSource // file being read, rows is nil between files
+	name    string    // name of the current file in the source column
+	width   int       // columns of the current file
+	align   []int     // index in headers of each column of the current file
+	row     int       // rows read from the current file, counting the header
+	done    int64     // bytes read from the files finished
+	counter *countingReader
+}
+
+// Line returns the line or row the last row was read from in its file
+func (r *combinedRows) Line() int {
+	if lines, ok := r.current.rows.(lineReader); ok {
+		return lines.Line()
+	}
+	return r.row
+}
+
+func (r *combinedRows) Read() ([]string, error) {
+	if !r.started {
+		r.started = true
+		return r.headers, nil
+	}
+
+	for {
+		if r.current.rows == nil {
+			if r.next == len(r.files) {
+				return nil, io.EOF
+			}
+			r.next++
+			if err := r.open(r.files[r.next-1]); err != nil {
+				return nil, err
+			}
+			continue // an empty file is left unopened, so the next is tried
+		}
+
+		row, err := r.current.rows.Read()
+		if r.current.counter != nil {
+			r.counter.n = r.done + r.current.counter.n
+		}
+		if err == io.EOF {
+			r.done += r.current.size
+			r.close()
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(r.files[r.next-1]), err)
+		}
+		r.row++
+		return r.aligned(row)
+	}
+}
+
+// open starts reading a file, past its header row
+func (r *combinedRows) open(file string) error {
+	source, err := openRows(file, r.options)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(file), err)
+	}
+	headers, err := source.rows.Read()
+	if err == io.EOF {
+		source.close()
+		return nil // empty files were left out of the header
+	}
+	if err != nil {
+		source.close()
+		return fmt.Errorf("%s: %w", filepath.Base(file), err)
+	}
+
+	r.current, r.width, r.row = source, len(headers), 1
+	r.align = r.align[:0]
+	for _, key := range columnKeys(headers) {
+		r.align = append(r.align, r.columns[key])
+	}
+	r.name = file
+	if rel, err := filepath.Rel(r.root, file); err == nil {
+		r.name = filepath.ToSlash(rel)
+	}
+	return nil
+}
+
+// aligned repairs a row of the current file and moves its cells to their
+// columns in the combined header
+func (r *combinedRows) aligned(row []string) ([]string, error) {
+	if len(row) < r.width && r.current.sparse {
+		row = append(row, make([]string, r.width-len(row))...)
+	}
+	if len(row) != r.width {
+		line := r.Line()
+		repaired, action, ok := repairRow(row, r.width, r.options.Ragged, r.current.separator)
+		if !ok {
+			return nil, fmt.Errorf("%s: row %d has %d columns, expected %d", filepath.Base(r.name), line, len(row), r.width)
+		}
+		if r.options.OnRepair != nil {
+			r.options.OnRepair(Repair{Line: line, Action: action, Fields: len(row), Expected: r.width})
+		}
+		row = repaired
+	}
+
+	cells := make([]string, len(r.headers))
+	for i, cell := range row {
+		cells[r.align[i]] = cell
+	}
+	if r.options.SourceColumn != "" {
+		cells[len(cells)-1] = r.name
+	}
+	return cells, nil
+}
+
+// close closes the file being read
+func (r *combinedRows) close() error {
+	if r.current.rows == nil {
+		return nil
+	}
+	err := r.current.close()
+	r.current = rowSource{}
+	return err
+}
@@ -0,0 +1,117 @@
+﻿package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultSourceColumn names the column holding the file of each row
+const defaultSourceColumn = "Source File"
+
+// showCombineOptions asks which files of a folder to read as one, by a
+// pattern such as 2026-*.csv, and whether to add a column naming the file
+// of each row. The callback gets the folder or pattern to read.
+func showCombineOptions(window fyne.Window, folder string, callback func(string, ReadOptions)) {
+	patternEntry := widget.NewEntry()
+	patternEntry.SetPlaceHolder("All data files, or e.g. 2026-*.csv")
+	sourceEntry := widget.NewEntry()
+	sourceEntry.SetText(defaultSourceColumn)
+	sourceCheck := widget.NewCheck("Add a column naming the file of each row", nil)
+
+	// selection returns the folder or pattern to read and how
+	selection := func() (string, ReadOptions) {
+		path := folder
+		if pattern := strings.TrimSpace(patternEntry.Text); pattern != "" {
+			path = filepath.Join(folder, pattern)
+		}
+		var options ReadOptions
+		if sourceCheck.Checked {
+			options.SourceColumn = strings.TrimSpace(sourceEntry.Text)
+		}
+		return path, options
+	}
+
+	if previous := rememberedOptions(folder); previous.SourceColumn != "" {
+		sourceEntry.SetText(previous.SourceColumn)
+		sourceCheck.SetChecked(true)
+	} else {
+		sourceEntry.Disable()
+	}
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	updatePreview := func() {
+		previewData = nil
+		defer preview.Refresh()
+
+		path, options := selection()
+		files, err := listCombinedFiles(path)
+		if err == nil {
+			var source rowSource
+			if source, err = openCombined(path, options); err == nil {
+				previewData, err = readSourceRows(source, previewRows)
+			}
+		}
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		names := make([]string, len(files))
+		for i, file := range files {
+			names[i] = filepath.Base(file)
+		}
+		status.SetText(fmt.Sprintf("%d files: %s", len(files), strings.Join(names, ", ")))
+	}
+	patternEntry.OnChanged = func(string) { updatePreview() }
+	sourceEntry.OnChanged = func(string) { updatePreview() }
+	sourceCheck.OnChanged = func(checked bool) {
+		if checked {
+			sourceEntry.Enable()
+		} else {
+			sourceEntry.Disable()
+		}
+		updatePreview()
+	}
+	updatePreview()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Files", patternEntry),
+		widget.NewFormItem("", sourceCheck),
+		widget.NewFormItem("Column name", sourceEntry),
+	)
+	form.Items[0].HintText = "Columns are matched by header; files without a column leave it empty"
+	content := container.NewBorder(container.NewVBox(form, status), nil, nil, nil, preview)
+
+	combineDialog := dialog.NewCustomConfirm(
+		"Combine Files in "+filepath.Base(folder),
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			path, options := selection()
+			if sourceCheck.Checked && options.SourceColumn == "" {
+				dialog.ShowError(fmt.Errorf("the source column needs a name"), window)
+				return
+			}
+			rememberOptions(folder, options)
+			callback(path, options)
+		},
+		window,
+	)
+
+	combineDialog.Resize(fyne.NewSize(700, 550))
+	combineDialog.Show()
+}
@@ -0,0 +1,90 @@
+﻿package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeReports writes monthly reports to a temporary folder
+func writeReports(t *testing.T, files map[string]string) string {
+	t.Helper()
+	folder := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return folder
+}
+
+func TestReadDataCombinesFiles(t *testing.T) {
+	folder := writeReports(t, map[string]string{
+		"2026-01.csv":  "Region,Units\nNorth,3\nSouth,4\n",
+		"2026-02.csv":  "Units;Region;Price\n5;North;1.5\n",
+		"2026-03.json": `[{"Region": "East", "Returns": 1}]`,
+		"2026-04.csv":  "",
+		"notes.md":     "not data",
+		"app.log":      "INFO started",
+		".hidden.csv":  "Region\nHidden\n",
+	})
+
+	data, err := readData(folder, ReadOptions{SourceColumn: "File"})
+	if err != nil {
+		t.Fatalf("reading the folder failed: %v", err)
+	}
+	want := [][]string{
+		{"Region", "Units", "Price", "Returns", "File"},
+		{"North", "3", "", "", "2026-01.csv"},
+		{"South", "4", "", "", "2026-01.csv"},
+		{"North", "5", "1.5", "", "2026-02.csv"},
+		{"East", "", "", "1", "2026-03.json"},
+	}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	// Rows keep the line they come from in their file
+	if want := []int{2, 3, 2, 1}; !reflect.DeepEqual(data.SourceRows, want) {
+		t.Errorf("source rows = %v, want %v", data.SourceRows, want)
+	}
+
+	data, err = readData(filepath.Join(folder, "2026-0[12].csv"), ReadOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("reading a pattern failed: %v", err)
+	}
+	if got, want := data.Records(), [][]string{{"Region", "Units", "Price"}, {"North", "3", ""}, {"South", "4", ""}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+
+	if _, err := readData(folder, ReadOptions{SourceColumn: "Region"}); err == nil {
+		t.Errorf("a source column named like a data column was accepted")
+	}
+	if _, err := readData(filepath.Join(folder, "2025-*.csv"), ReadOptions{}); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("reading a pattern without matches gave %v", err)
+	}
+}
+
+func TestReadDataCombinedRepairsPerFile(t *testing.T) {
+	folder := writeReports(t, map[string]string{
+		"a.csv": "Region,Units\nNorth,3\nSouth,4,extra\nEast,5\n",
+		"b.csv": "Units\n4\n",
+	})
+
+	var report RepairReport
+	data, err := readData(folder, ReadOptions{Ragged: RepairTruncate, OnRepair: report.Add})
+	if err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+	if got, want := data.Records(), [][]string{{"Region", "Units"}, {"North", "3"}, {"South", "4"}, {"East", "5"}, {"", "4"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	if report.Len() != 1 || report.Repairs[0].Line != 3 || report.Repairs[0].Expected != 2 {
+		t.Errorf("repairs = %+v, want the long row of a.csv", report.Repairs)
+	}
+
+	if _, err := readData(folder, ReadOptions{}); err == nil || !strings.Contains(err.Error(), "a.csv") {
+		t.Errorf("a ragged row gave %v, want an error naming its file", err)
+	}
+}
@@ -0,0 +1,265 @@
+﻿package ui
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes starting compressed data
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// compressedExts are the extensions of compressed files, left out to find
+// the format of the data they hold
+var compressedExts = map[string]bool{".gz": true, ".gzip": true, ".zst": true, ".zstd": true}
+
+// dataExt returns the lowercase extension telling the format of the data in
+// a file, which for a compressed file such as sales.csv.gz is that of the
+// file it holds
+func dataExt(filePath string) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if compressedExts[ext] {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(filePath, filepath.Ext(filePath))))
+	}
+	return ext
+}
+
+// isArchive reports whether a file is a zip archive of data files. Its files
+// are opened by paths inside it, such as exports.zip/sales.csv.
+func isArchive(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".zip"
+}
+
+// archiveEntryPath returns the path a file in an archive is opened by
+func archiveEntryPath(archive, entry string) string {
+	return filepath.Join(archive, filepath.FromSlash(entry))
+}
+
+// splitArchivePath splits the path of a file in an archive into the path of
+// the archive and the name of the file in it. Paths of files on disk are not
+// split.
+func splitArchivePath(filePath string) (archive, entry string, ok bool) {
+	if _, err := os.Stat(filePath); err == nil {
+		return "", "", false
+	}
+	for dir := filepath.Dir(filePath); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if !isArchive(dir) {
+			continue
+		}
+		if stat, err := os.Stat(dir); err == nil && stat.Mode().IsRegular() {
+			rel, err := filepath.Rel(dir, filePath)
+			return dir, filepath.ToSlash(rel), err == nil
+		}
+	}
+	return "", "", false
+}
+
+// listArchiveEntries returns the names of the files in a zip archive that
+// can be read as data, in name order
+func listArchiveEntries(filePath string) ([]string, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading archive: %w", err)
+	}
+	defer archive.Close()
+
+	var entries []string
+	for _, file := range archive.File {
+		name := file.Name
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(filepath.Base(name), ".") {
+			continue
+		}
+		if isDataFile(name) {
+			entries = append(entries, name)
+		}
+	}
+	sort.Strings(entries)
+	return entries, nil
+}
+
+// resolveArchive returns the path of the data to read from filePath: that
+// of the only data file when filePath is an archive, else filePath itself
+func resolveArchive(filePath string) (string, error) {
+	if !isArchive(filePath) || isCombined(filePath) {
+		return filePath, nil
+	}
+	entries, err := listArchiveEntries(filePath)
+	if err != nil {
+		return "", err
+	}
+	switch len(entries) {
+	case 0:
+		return "", fmt.Errorf("%s holds no data files", filepath.Base(filePath))
+	case 1:
+		return archiveEntryPath(filePath, entries[0]), nil
+	default:
+		return "", fmt.Errorf("%s holds %d data files, choose one by its path in the archive, e.g. %s; files: %s",
+			filepath.Base(filePath), len(entries), archiveEntryPath(filePath, entries[0]), strings.Join(entries, ", "))
+	}
+}
+
+// inputFile is a data file opened for reading. Reads return its data
+// decompressed, while the counter it was opened with counts the bytes read
+// as they are stored, out of size.
+type inputFile struct {
+	io.Reader
+	size    int64
+	closers []io.Closer // closed last to first
+}
+
+func (f *inputFile) Close() error {
+	var err error
+	for i := len(f.closers) - 1; i >= 0; i-- {
+		if closeErr := f.closers[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// openInput opens a data file, or a file in an archive, to be read as a
+// stream. Data compressed with gzip or zstd, told by its first bytes rather
+// than its name, is decompressed as it is read. Reads from the file as it is
+// stored go through counter, which may be nil.
+func openInput(filePath string, counter *countingReader) (*inputFile, error) {
+	if counter == nil {
+		counter = &countingReader{}
+	}
+
+	in := &inputFile{}
+	if archivePath, entry, ok := splitArchivePath(filePath); ok {
+		archive, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %w", err)
+		}
+		in.closers = append(in.closers, archive)
+		var file *zip.File
+		for _, f := range archive.File {
+			if f.Name == entry {
+				file = f
+				break
+			}
+		}
+		if file == nil {
+			in.Close()
+			return nil, fmt.Errorf("%s has no file %s", filepath.Base(archivePath), entry)
+		}
+		r, err := file.Open()
+		if err != nil {
+			in.Close()
+			return nil, fmt.Errorf("reading %s from archive: %w", entry, err)
+		}
+		counter.r, in.size = r, int64(file.UncompressedSize64)
+		in.closers = append(in.closers, r)
+	} else {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		in.closers = append(in.closers, file)
+		stat, err := file.Stat()
+		if err != nil {
+			in.Close()
+			return nil, err
+		}
+		counter.r, in.size = file, stat.Size()
+	}
+
+	buffered := bufio.NewReader(counter)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		r, err := gzip.NewReader(buffered)
+		if err != nil {
+			in.Close()
+			return nil, fmt.Errorf("decompressing %s: %w", filepath.Base(filePath), err)
+		}
+		in.Reader = r
+		in.closers = append(in.closers, r)
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			in.Close()
+			return nil, fmt.Errorf("decompressing %s: %w", filepath.Base(filePath), err)
+		}
+		r := decoder.IOReadCloser()
+		in.Reader = r
+		in.closers = append(in.closers, r)
+	case bytes.HasPrefix(magic, zipMagic) && !isWorkbook(filePath):
+		in.Close()
+		return nil, fmt.Errorf("%s is a zip archive, open it as a .zip file to choose a file in it", filepath.Base(filePath))
+	default:
+		in.Reader = buffered
+	}
+	return in, nil
+}
+
+// isPacked reports whether a file is compressed or in an archive, so formats
+// read at random rather than as a stream must be decompressed first
+func isPacked(filePath string) bool {
+	if _, _, ok := splitArchivePath(filePath); ok {
+		return true
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	magic := make([]byte, len(zstdMagic))
+	n, _ := io.ReadFull(file, magic)
+	return bytes.HasPrefix(magic[:n], gzipMagic) || bytes.HasPrefix(magic[:n], zstdMagic)
+}
+
+// readInput reads a whole data file into memory, decompressed
+func readInput(filePath string) (*bytes.Reader, error) {
+	in, err := openInput(filePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing %s: %w", filepath.Base(filePath), err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// inputSize returns the size of a data file as it is stored, or for a file
+// in an archive its size decompressed. The size of combined files is the
+// sum of theirs.
+func inputSize(filePath string) (int64, error) {
+	if isCombined(filePath) {
+		files, err := listCombinedFiles(filePath)
+		if err != nil {
+			return 0, err
+		}
+		var total int64
+		for _, file := range files {
+			size, err := inputSize(file)
+			if err != nil {
+				return 0, err
+			}
+			total += size
+		}
+		return total, nil
+	}
+	in, err := openInput(filePath, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	return in.size, nil
+}
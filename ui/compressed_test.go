@@ -0,0 +1,130 @@
+﻿package ui
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const testSales = "Region,Units\nNorth,3\nSouth,4\n"
+
+// gzipData compresses data with gzip
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// writeZip creates a zip archive of the files
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, "exports.zip", b.String())
+}
+
+func TestReadDataDecompresses(t *testing.T) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer encoder.Close()
+	book, err := os.ReadFile(writeWorkbook(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		options ReadOptions
+		want    [][]string
+	}{
+		{"gzip", writeFile(t, "sales.csv.gz", string(gzipData(t, []byte(testSales)))), ReadOptions{},
+			[][]string{{"Region", "Units"}, {"North", "3"}, {"South", "4"}}},
+		// Compression is told by the first bytes, not the name
+		{"gzip without extension", writeFile(t, "sales.csv", string(gzipData(t, []byte(testSales)))), ReadOptions{},
+			[][]string{{"Region", "Units"}, {"North", "3"}, {"South", "4"}}},
+		{"zstd", writeFile(t, "sales.ndjson.zst", string(encoder.EncodeAll([]byte(`{"Region":"North","Units":3}`+"\n"), nil))), ReadOptions{},
+			[][]string{{"Region", "Units"}, {"North", "3"}}},
+		{"workbook", writeFile(t, "book.xlsx.gz", string(gzipData(t, book))), ReadOptions{Table: "Sales"},
+			[][]string{{"Region", "Revenue", "Units"}, {"North", "10", "1"}, {"South", "20", "2"}, {"East", "30", "3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := readData(tt.path, tt.options)
+			if err != nil {
+				t.Fatalf("reading failed: %v", err)
+			}
+			if got := data.Records(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDataFromArchive(t *testing.T) {
+	path := writeZip(t, map[string]string{
+		"2024/north.csv":    testSales,
+		"2024/south.csv.gz": string(gzipData(t, []byte("Region,Units\nSouth,4\n"))),
+		"README":            "not data",
+		"__MACOSX/x.csv":    "",
+	})
+
+	entries, err := listArchiveEntries(path)
+	if err != nil {
+		t.Fatalf("listing failed: %v", err)
+	}
+	if want := []string{"2024/north.csv", "2024/south.csv.gz"}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %v, want %v", entries, want)
+	}
+	if _, err := readData(path, ReadOptions{}); err == nil || !strings.Contains(err.Error(), "2024/north.csv") {
+		t.Errorf("reading an archive of several files gave %v, want an error listing them", err)
+	}
+
+	data, err := readData(archiveEntryPath(path, "2024/south.csv.gz"), ReadOptions{})
+	if err != nil {
+		t.Fatalf("reading a compressed file in the archive failed: %v", err)
+	}
+	if got, want := data.Records(), [][]string{{"Region", "Units"}, {"South", "4"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	if _, err := readData(archiveEntryPath(path, "2024/east.csv"), ReadOptions{}); err == nil {
+		t.Errorf("reading a file missing from the archive succeeded")
+	}
+
+	// The only data file of an archive is read without naming it
+	single := writeZip(t, map[string]string{"sales.csv": testSales})
+	if data, err := readData(single, ReadOptions{}); err != nil || data.Len() != 2 {
+		t.Errorf("reading the only file of an archive gave %v", err)
+	}
+}
+
+func TestDataExt(t *testing.T) {
+	for path, want := range map[string]string{"sales.CSV.GZ": ".csv", "sales.json.zst": ".json", "sales.gz": "", "book.xlsx": ".xlsx"} {
+		if got := dataExt(path); got != want {
+			t.Errorf("dataExt(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -1,101 +1,53 @@
 ﻿package ui
 
 import (
-	"fmt"
-	"strconv"
+	"graph-viewer/charts"
+	"graph-viewer/dataset"
 )
 
-// validateNumeric checks if a string can be parsed as a float
-func validateNumeric(value string) error {
-	_, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return fmt.Errorf("value '%s' is not a valid number", value)
-	}
-	return nil
-}
-
-// extractSelectedData3D extracts data for 3D visualizations
-func extractSelectedData3D(xAxis, yAxis, zAxis string, headers []string, rows [][]string, limits map[string]int) ([][]string, error) {
-	xIndex, yIndex, zIndex := -1, -1, -1
-
-	// Find column indices
-	for i, header := range headers {
-		switch header {
-		case xAxis:
-			xIndex = i
-		case yAxis:
-			yIndex = i
-		case zAxis:
-			zIndex = i
+// extractSelectedData extracts the columns selected in spec for the roles of
+// a chart type, in role order. Columns of numeric and time roles are
+// converted so invalid values are handled under spec.Invalid, and reported
+// with their row, before anything is rendered.
+func extractSelectedData(chartType charts.ChartType, spec charts.ChartSpec, data *dataset.Dataset, limits map[string]int) (*dataset.Dataset, error) {
+	columns := spec.Columns
+	if err := charts.ValidateColumns(chartType, columns); err != nil {
+		return nil, err
+	}
+
+	// Collect the selected columns in role order
+	var (
+		names []string
+		types []charts.ValueType
+	)
+	for _, role := range chartType.Roles() {
+		for _, column := range columns[role.Name] {
+			if data.Index(column) == -1 {
+				return nil, &charts.MissingColumnError{Column: column, Role: role.Name}
+			}
+			names = append(names, column)
+			types = append(types, role.Type)
 		}
 	}
 
-	if xIndex == -1 || yIndex == -1 || zIndex == -1 {
-		return nil, fmt.Errorf("invalid column selection for X, Y, or Z axis")
-	}
-
-	// Apply row limits
-	maxRows := len(rows)
-	if limit, ok := limits["X"]; ok && limit > 0 && limit < maxRows {
-		maxRows = limit
-	}
-
-	selectedData := make([][]string, 0, maxRows)
-	for i, row := range rows {
-		if i >= maxRows {
-			break
-		}
-
-		// Validate numeric data
-		if err := validateNumeric(row[yIndex]); err != nil {
-			return nil, fmt.Errorf("row %d: %v", i+1, err)
-		}
-		if err := validateNumeric(row[zIndex]); err != nil {
-			return nil, fmt.Errorf("row %d: %v", i+1, err)
-		}
-
-		selectedData = append(selectedData, []string{row[xIndex], row[yIndex], row[zIndex]})
-	}
-
-	return selectedData, nil
-}
-
-// extractSelectedData extracts data for 2D visualizations
-func extractSelectedData(xAxis, yAxis string, headers []string, rows [][]string, limits map[string]int) ([][]string, error) {
-	xIndex, yIndex := -1, -1
-
-	// Find column indices
-	for i, header := range headers {
-		switch header {
-		case xAxis:
-			xIndex = i
-		case yAxis:
-			yIndex = i
-		}
-	}
-
-	if xIndex == -1 || yIndex == -1 {
-		return nil, fmt.Errorf("invalid column selection for X or Y axis")
+	selectedData, err := data.Select(names)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply row limits
-	maxRows := len(rows)
-	if limit, ok := limits["X"]; ok && limit > 0 && limit < maxRows {
-		maxRows = limit
+	if limit, ok := limits["X"]; ok && limit > 0 {
+		selectedData = selectedData.Head(limit)
 	}
 
-	selectedData := make([][]string, 0, maxRows)
-	for i, row := range rows {
-		if i >= maxRows {
-			break
+	// Validate numeric and time data for roles that need it
+	for i, valueType := range types {
+		if valueType.Holds(selectedData.Columns[i]) {
+			continue
 		}
-
-		// Validate numeric data for Y axis
-		if err := validateNumeric(row[yIndex]); err != nil {
-			return nil, fmt.Errorf("row %d: %v", i+1, err)
+		if selectedData, err = charts.ConvertValues(selectedData, i, valueType, spec.Invalid, spec.Diagnostics); err != nil {
+			return nil, err
 		}
-
-		selectedData = append(selectedData, []string{row[xIndex], row[yIndex]})
 	}
 
 	return selectedData, nil
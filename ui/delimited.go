@@ -0,0 +1,424 @@
+﻿package ui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffSize is the number of bytes at the start of a file used to detect its
+// dialect
+const sniffSize = 64 << 10
+
+// delimiterCandidates are the field separators tried when detecting a dialect
+var delimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// Dialect describes how a delimited text file is written
+type Dialect struct {
+	Encoding  string // character encoding such as utf-8 or shift_jis, utf-8 when empty
+	Delimiter rune   // field separator
+	Quote     rune   // quote character, 0 when fields are never quoted
+	Comment   rune   // lines starting with it are skipped, 0 for none
+	SkipLines int    // lines above the header, e.g. a report title
+}
+
+// String describes the dialect, e.g. delimiter ';', quote '"', comment '#'
+func (d Dialect) String() string {
+	var parts []string
+	if d.Encoding != "" {
+		parts = append(parts, "encoding "+d.Encoding)
+	}
+	parts = append(parts, "delimiter "+dialectCharName(d.Delimiter), "quote "+dialectCharName(d.Quote))
+	if d.Comment != 0 {
+		parts = append(parts, "comment "+dialectCharName(d.Comment))
+	}
+	if d.SkipLines > 0 {
+		parts = append(parts, fmt.Sprintf("%d line(s) above the header skipped", d.SkipLines))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dialectCharName(r rune) string {
+	switch r {
+	case 0:
+		return "none"
+	case '\t':
+		return "tab"
+	case ' ':
+		return "space"
+	default:
+		return fmt.Sprintf("%q", r)
+	}
+}
+
+// ParseDialectChar parses a delimiter, quote or comment character given as
+// the character itself or by a name such as tab, semicolon or none
+func ParseDialectChar(value string) (rune, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "none", "":
+		return 0, nil
+	case "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "pipe":
+		return '|', nil
+	case "space":
+		return ' ', nil
+	case "double", "double quote":
+		return '"', nil
+	case "single", "single quote":
+		return '\'', nil
+	}
+	if value == " " || value == "\t" {
+		return rune(value[0]), nil
+	}
+	value = strings.TrimSpace(value)
+	if r, size := utf8.DecodeRuneInString(value); size == len(value) && r != '\n' && r != '\r' {
+		return r, nil
+	}
+	return 0, fmt.Errorf("%q is not a single character or a name such as tab, semicolon or none", value)
+}
+
+// isDelimited reports whether a file is delimited text
+func isDelimited(filePath string) bool {
+	switch dataExt(filePath) {
+	case ".csv", ".tsv", ".txt":
+		return true
+	default:
+		return false
+	}
+}
+
+// DetectDialect detects how a delimited text file is written from its start:
+// the character encoding, then the dialect of the text
+func DetectDialect(filePath string) (Dialect, error) {
+	return DetectDialectWithEncoding(filePath, "")
+}
+
+// DetectDialectWithEncoding detects the dialect of a delimited text file in
+// the named encoding, or a detected one when name is empty
+func DetectDialectWithEncoding(filePath string, name string) (Dialect, error) {
+	filePath, err := sampleFile(filePath)
+	if err != nil {
+		return Dialect{}, err
+	}
+	file, err := openInput(filePath, nil)
+	if err != nil {
+		return Dialect{}, err
+	}
+	defer file.Close()
+
+	sample := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Dialect{}, err
+	}
+	sample = sample[:n]
+
+	if name == "" {
+		name = detectEncoding(sample)
+	}
+	text, err := decodeText(bytes.NewReader(sample), name)
+	if err != nil {
+		return Dialect{}, err
+	}
+	// A character cut off at the end of the sample does not decode
+	decoded, _ := io.ReadAll(text)
+	if n == sniffSize {
+		// Leave out the last line, which is probably cut off
+		if i := bytes.LastIndexByte(decoded, '\n'); i > 0 {
+			decoded = decoded[:i+1]
+		}
+	}
+
+	dialect := sniffDialect(decoded, dataExt(filePath) == ".tsv")
+	dialect.Encoding = name
+	return dialect, nil
+}
+
+// sniffDialect detects the dialect of a sample of delimited text. The
+// delimiter is the candidate splitting the most rows into the same number of
+// fields, and the header is the first row with that number of fields. Lines
+// starting with # are comments unless one of them below the header has as
+// many fields as the rows, so values such as #123 or #ff0000 are kept.
+func sniffDialect(sample []byte, tabs bool) Dialect {
+	dialect := Dialect{Delimiter: ',', Quote: sniffQuote(sample)}
+	if tabs {
+		dialect.Delimiter = '\t'
+	}
+	dialect, fields := sniffDelimiter(sample, dialect, tabs)
+
+	comments := false
+	for i, line := range bytes.Split(sample, []byte("\n")) {
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
+		if i+1 > dialect.SkipLines+1 {
+			row, err := newDelimitedReader(bytes.NewReader(line), dialect).Read()
+			if err == nil && len(row) == fields {
+				return dialect // a data row starting with #
+			}
+		}
+		comments = true
+	}
+	if !comments {
+		return dialect
+	}
+	dialect.Comment = '#'
+	dialect, _ = sniffDelimiter(sample, dialect, tabs)
+	return dialect
+}
+
+// sniffDelimiter detects the delimiter and the header of a sample read in
+// the dialect, returning them with the number of fields of the rows
+func sniffDelimiter(sample []byte, dialect Dialect, tabs bool) (Dialect, int) {
+	candidates := delimiterCandidates
+	if tabs {
+		candidates = append([]rune{'\t'}, candidates...)
+	}
+	bestRows, bestFields := 0, 1
+	for _, delimiter := range candidates {
+		candidate := dialect
+		candidate.Delimiter = delimiter
+
+		var counts, lines []int
+		reader := newDelimitedReader(bytes.NewReader(sample), candidate)
+		for {
+			row, err := reader.Read()
+			if err != nil {
+				break
+			}
+			counts = append(counts, len(row))
+			lines = append(lines, reader.Line())
+		}
+
+		fields, rows := mode(counts)
+		if fields < 2 || rows <= bestRows {
+			continue
+		}
+		bestRows, bestFields = rows, fields
+		dialect.Delimiter = delimiter
+		for i, count := range counts {
+			if count == fields {
+				dialect.SkipLines = lines[i] - 1
+				break
+			}
+		}
+	}
+	return dialect, bestFields
+}
+
+// sniffQuote returns the quote character opening the most fields, preferring
+// double quotes
+func sniffQuote(sample []byte) rune {
+	opened := func(quote byte) int {
+		count := 0
+		for i, b := range sample {
+			if b != quote {
+				continue
+			}
+			if i == 0 || bytes.IndexByte([]byte("\n,;\t|"), sample[i-1]) != -1 {
+				count++
+			}
+		}
+		return count
+	}
+	if opened('\'') > opened('"') {
+		return '\''
+	}
+	return '"'
+}
+
+// mode returns the most frequent value and how often it occurs, preferring
+// larger values on ties
+func mode(values []int) (value, count int) {
+	counts := make(map[int]int)
+	for _, v := range values {
+		counts[v]++
+		if counts[v] > count || (counts[v] == count && v > value) {
+			value, count = v, counts[v]
+		}
+	}
+	return value, count
+}
+
+// openDelimited opens a delimited text file to be read one record at a time
+// in the dialect of options, or the detected one. The text is converted to
+// UTF-8 as it is read.
+func openDelimited(filePath string, options ReadOptions) (rowSource, error) {
+	dialect := options.Dialect
+	if dialect == nil {
+		detected, err := DetectDialect(filePath)
+		if err != nil {
+			return rowSource{}, err
+		}
+		dialect = &detected
+	}
+
+	counter := &countingReader{}
+	file, err := openInput(filePath, counter)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	text, err := decodeText(file, dialect.Encoding)
+	if err != nil {
+		file.Close()
+		return rowSource{}, err
+	}
+	reader := newDelimitedReader(text, *dialect)
+	if err := reader.skipLines(dialect.SkipLines); err != nil {
+		file.Close()
+		return rowSource{}, err
+	}
+	return rowSource{
+		rows:      reader,
+		counter:   counter,
+		size:      file.size,
+		close:     file.Close,
+		separator: string(dialect.Delimiter),
+	}, nil
+}
+
+// delimitedReader reads records of delimited text. Quoted fields may hold
+// delimiters, line breaks and doubled quotes; text after a closing quote is
+// kept. Blank lines and comment lines are skipped.
+type delimitedReader struct {
+	r       *bufio.Reader
+	dialect Dialect
+	line    int // number of the last line read
+	start   int // line the last record started on
+
+	// Fields are collected in one buffer and split at the ends, so each
+	// record takes a single allocation
+	buffer []byte
+	ends   []int
+}
+
+func newDelimitedReader(r io.Reader, dialect Dialect) *delimitedReader {
+	return &delimitedReader{r: bufio.NewReaderSize(r, 64<<10), dialect: dialect}
+}
+
+// Line returns the line the last record read started on
+func (d *delimitedReader) Line() int {
+	return d.start
+}
+
+// skipLines reads past n lines
+func (d *delimitedReader) skipLines(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := d.readLine(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLine returns the next line without its line break. The line is only
+// valid until the next read.
+func (d *delimitedReader) readLine() ([]byte, error) {
+	line, err := d.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		long := append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull {
+			line, err = d.r.ReadSlice('\n')
+			long = append(long, line...)
+		}
+		line = long
+	}
+	if len(line) == 0 && err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	d.line++
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), nil
+}
+
+func (d *delimitedReader) Read() ([]string, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if d.dialect.Comment != 0 && bytes.HasPrefix(line, []byte(string(d.dialect.Comment))) {
+			continue
+		}
+		d.start = d.line
+		return d.parseRecord(line)
+	}
+}
+
+// parseRecord splits a line into fields, reading further lines while a
+// quoted field is open
+func (d *delimitedReader) parseRecord(line []byte) ([]string, error) {
+	delimiter := []byte(string(d.dialect.Delimiter))
+	var quote []byte
+	if d.dialect.Quote != 0 {
+		quote = []byte(string(d.dialect.Quote))
+	}
+	d.buffer, d.ends = d.buffer[:0], d.ends[:0]
+
+	for done := false; !done; {
+		if quote != nil && bytes.HasPrefix(line, quote) {
+			line = line[len(quote):]
+			for {
+				i := bytes.Index(line, quote)
+				if i == -1 {
+					// The quoted field goes on in the next line
+					d.buffer = append(d.buffer, line...)
+					d.buffer = append(d.buffer, '\n')
+					next, err := d.readLine()
+					if err == io.EOF {
+						return nil, fmt.Errorf("line %d: quoted field is not closed", d.start)
+					}
+					if err != nil {
+						return nil, err
+					}
+					line = next
+					continue
+				}
+
+				d.buffer = append(d.buffer, line[:i]...)
+				line = line[i+len(quote):]
+				if bytes.HasPrefix(line, quote) {
+					d.buffer = append(d.buffer, quote...)
+					line = line[len(quote):]
+					continue
+				}
+				break
+			}
+		}
+
+		i := bytes.Index(line, delimiter)
+		if i == -1 {
+			d.buffer = append(d.buffer, line...)
+			done = true
+		} else {
+			d.buffer = append(d.buffer, line[:i]...)
+			line = line[i+len(delimiter):]
+		}
+		d.ends = append(d.ends, len(d.buffer))
+	}
+
+	text := string(d.buffer)
+	fields := make([]string, len(d.ends))
+	start := 0
+	for i, end := range d.ends {
+		fields[i], start = text[start:end], end
+	}
+	return fields, nil
+}
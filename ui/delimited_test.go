@@ -0,0 +1,107 @@
+﻿package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSniffDialect(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		tabs   bool
+		want   Dialect
+	}{
+		{"comma", "a,b\n1,2\n3,4\n", false, Dialect{Delimiter: ',', Quote: '"'}},
+		{"semicolon with decimal commas", "Region;Revenue\nNorth;1,5\nSouth;2,25\n", false, Dialect{Delimiter: ';', Quote: '"'}},
+		{"tab", "a\tb\tc\n1\t2\t3\n", false, Dialect{Delimiter: '\t', Quote: '"'}},
+		{"pipe", "a|b\n1|2\n", false, Dialect{Delimiter: '|', Quote: '"'}},
+		{"single quotes", "'a';'b'\n'x;y';'2'\n", false, Dialect{Delimiter: ';', Quote: '\''}},
+		{"quoted delimiters", "\"Name, full\",Age\n\"Doe, Jane\",40\n\"Roe, Rick\",31\n", false, Dialect{Delimiter: ',', Quote: '"'}},
+		{"title and comments", "Sales report 2024\n# exported by the shop\nRegion;Revenue;Units\nNorth;10;1\n# subtotal\nSouth;20;2\n", false,
+			Dialect{Delimiter: ';', Quote: '"', Comment: '#', SkipLines: 2}},
+		{"single column", "Value\n1\n2\n", true, Dialect{Delimiter: '\t', Quote: '"'}},
+		{"values starting with #", "Ticket,Colour\n#123,#ff0000\n#124,#00ff00\n", false, Dialect{Delimiter: ',', Quote: '"'}},
+		{"comment above values starting with #", "# exported by the tracker\nTicket,Colour\n#123,#ff0000\n125,#0000ff\n", false,
+			Dialect{Delimiter: ',', Quote: '"', SkipLines: 1}},
+	}
+	for _, tt := range tests {
+		if got := sniffDialect([]byte(tt.sample), tt.tabs); got != tt.want {
+			t.Errorf("%s: sniffDialect = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDelimitedReader(t *testing.T) {
+	text := "a;\"b;c\";\"say \"\"hi\"\"\"\r\n\n# note\n\"multi\nline\";x;\"y\"z\n"
+	reader := newDelimitedReader(strings.NewReader(text), Dialect{Delimiter: ';', Quote: '"', Comment: '#'})
+
+	want := []struct {
+		fields []string
+		line   int
+	}{
+		{[]string{"a", "b;c", `say "hi"`}, 1},
+		{[]string{"multi\nline", "x", "yz"}, 4},
+	}
+	for _, w := range want {
+		row, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !reflect.DeepEqual(row, w.fields) || reader.Line() != w.line {
+			t.Errorf("Read = %q at line %d, want %q at line %d", row, reader.Line(), w.fields, w.line)
+		}
+	}
+	if _, err := reader.Read(); err == nil {
+		t.Errorf("expected the end of the file")
+	}
+
+	reader = newDelimitedReader(strings.NewReader("\"open\n"), Dialect{Delimiter: ',', Quote: '"'})
+	if _, err := reader.Read(); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("unclosed quote returned %v, want an error at line 1", err)
+	}
+}
+
+func TestReadDataDetectsDialect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.txt")
+	text := "\xEF\xBB\xBFQuarterly export\n\nRegion|Revenue\nNorth|10\n# excluded|0\nSouth|20\n"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := readData(path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("readData failed: %v", err)
+	}
+	// A line starting with # that has the fields of a row is data
+	want := [][]string{{"Region", "Revenue"}, {"North", "10"}, {"# excluded", "0"}, {"South", "20"}}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(data.SourceRows, []int{4, 5, 6}) {
+		t.Errorf("source rows = %v, want the lines [4 5 6]", data.SourceRows)
+	}
+
+	// Overriding the dialect reads the file as written
+	data, err = readData(path, ReadOptions{Dialect: &Dialect{Delimiter: '|', SkipLines: 2}})
+	if err != nil {
+		t.Fatalf("readData with a dialect failed: %v", err)
+	}
+	if got := data.Records(); len(got) != 4 || got[2][0] != "# excluded" {
+		t.Errorf("records without comments = %q", got)
+	}
+}
+
+func TestParseDialectChar(t *testing.T) {
+	for value, want := range map[string]rune{"tab": '\t', `\t`: '\t', ";": ';', "pipe": '|', "none": 0, "'": '\'', "space": ' '} {
+		if got, err := ParseDialectChar(value); err != nil || got != want {
+			t.Errorf("ParseDialectChar(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseDialectChar("ab"); err == nil {
+		t.Errorf("expected an error for two characters")
+	}
+}
@@ -0,0 +1,81 @@
+﻿package ui
+
+import (
+	"fmt"
+	"graph-viewer/charts"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// valuePolicyKey is the preference holding the policy last chosen for
+// invalid values
+const valuePolicyKey = "invalidValues"
+
+// valuePolicies and valuePolicyLabels offer the policies for invalid values
+// in the same order
+var (
+	valuePolicies     = []charts.ValuePolicy{charts.SkipInvalid, charts.FailInvalid, charts.CoerceInvalid}
+	valuePolicyLabels = []string{"Skip the row", "Stop with an error", "Chart numbers as zero"}
+)
+
+// newValuePolicySelect offers the policies for invalid values, starting with
+// the one chosen last
+func newValuePolicySelect() *widget.Select {
+	selector := widget.NewSelect(valuePolicyLabels, nil)
+	selector.SetSelectedIndex(0)
+	if app := fyne.CurrentApp(); app != nil {
+		if policy, err := charts.ParseValuePolicy(app.Preferences().String(valuePolicyKey)); err == nil {
+			for i, p := range valuePolicies {
+				if p == policy {
+					selector.SetSelectedIndex(i)
+				}
+			}
+		}
+	}
+	return selector
+}
+
+// rememberValuePolicy stores the policy chosen for invalid values
+func rememberValuePolicy(policy charts.ValuePolicy) {
+	if app := fyne.CurrentApp(); app != nil {
+		app.Preferences().SetString(valuePolicyKey, policy.String())
+	}
+}
+
+// showDiagnostics lists the rows left out of a chart or changed to fit it,
+// and lets the user export the list
+func showDiagnostics(window fyne.Window, diagnostics *charts.Diagnostics) {
+	lines := widget.NewList(
+		func() int { return len(diagnostics.Items) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			diagnostic := diagnostics.Items[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s: %v", diagnostic.Action(), diagnostic.Err))
+		},
+	)
+
+	summary := "Some rows did not have valid values for the chart: " + diagnostics.Summary() + "."
+	if diagnostics.Len() > len(diagnostics.Items) {
+		summary += fmt.Sprintf("\nThe first %d are listed.", len(diagnostics.Items))
+	}
+
+	export := widget.NewButton("Export List...", func() {
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := diagnostics.WriteCSV(writer); err != nil {
+				dialog.ShowError(err, window)
+			}
+		}, window)
+	})
+
+	content := container.NewBorder(widget.NewLabel(summary), export, nil, nil, lines)
+	diagnosticsDialog := dialog.NewCustom("Skipped Rows", "Close", content, window)
+	diagnosticsDialog.Resize(fyne.NewSize(500, 400))
+	diagnosticsDialog.Show()
+}
@@ -1,9 +1,11 @@
 ﻿package ui
 
 import (
-	"errors"
 	"fmt"
+	"graph-viewer/charts"
+	"graph-viewer/dataset"
 	"graph-viewer/logger"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -12,72 +14,180 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-// GraphTypeInfo holds metadata about different graph types
-type GraphTypeInfo struct {
-	name        string
-	needs3D     bool
-	description string
+// noColumn is offered by the selectors of optional roles to leave them empty
+const noColumn = "(none)"
+
+// roleSelector lets the user pick the column(s) for one role of a chart type
+type roleSelector struct {
+	role   charts.Role
+	single *widget.Select     // used when the role takes at most one column
+	multi  *widget.CheckGroup // used when the role takes several columns
+}
+
+// roleOptions returns the columns whose type fits the role
+func roleOptions(role charts.Role, data *dataset.Dataset) []string {
+	var options []string
+	for _, col := range data.Columns {
+		if role.Type.Accepts(col) {
+			options = append(options, col.Name)
+		}
+	}
+	return options
+}
+
+// newRoleSelector creates the widget matching the role's cardinality
+func newRoleSelector(role charts.Role, headers []string) *roleSelector {
+	if role.Multiple() {
+		return &roleSelector{role: role, multi: widget.NewCheckGroup(headers, nil)}
+	}
+
+	options := headers
+	if role.Optional() {
+		options = append([]string{noColumn}, headers...)
+	}
+	return &roleSelector{role: role, single: widget.NewSelect(options, nil)}
+}
+
+func (s *roleSelector) widget() fyne.CanvasObject {
+	if s.multi != nil {
+		return s.multi
+	}
+	return s.single
 }
 
-// Available graph types and their metadata
-var graphTypeInfos = map[string]GraphTypeInfo{
-	"Bar":        {"Bar Chart", false, "Simple bar chart for comparing categories"},
-	"Heatmap":    {"Heat Map", false, "Visualize data density and patterns"},
-	"Scatter3D":  {"3D Scatter Plot", true, "Three-dimensional scatter visualization"},
-	"Bar3D":      {"3D Bar Chart", true, "Three-dimensional bar visualization"},
-	"Pie":        {"Pie Chart", false, "Show proportion between categories"},
-	"Sankey":     {"Sankey Diagram", false, "Visualize flow between categories"},
-	"ThemeRiver": {"Theme River", false, "Show changes over time"},
+// selected returns the chosen columns in header order
+func (s *roleSelector) selected() []string {
+	if s.multi != nil {
+		return s.multi.Selected
+	}
+	if s.single.Selected == "" || s.single.Selected == noColumn {
+		return nil
+	}
+	return []string{s.single.Selected}
 }
 
-// ShowHeaderSelection creates and shows the graph type and axis selection dialog
-func ShowHeaderSelection(headers []string, window fyne.Window, callback func(graphType string, xAxis string, yAxis string, zAxis string, limits map[string]int)) {
+// restore reapplies previously chosen columns that are still offered
+func (s *roleSelector) restore(columns []string) {
+	var options []string
+	if s.multi != nil {
+		options = s.multi.Options
+	} else {
+		options = s.single.Options
+	}
+
+	var kept []string
+	for _, column := range columns {
+		for _, option := range options {
+			if option == column {
+				kept = append(kept, column)
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return
+	}
+
+	if s.multi != nil {
+		s.multi.SetSelected(kept)
+		return
+	}
+	s.single.SetSelected(kept[0])
+}
+
+// hint describes the role's cardinality and type constraint
+func (s *roleSelector) hint() string {
+	var parts []string
+	if s.role.Description != "" {
+		parts = append(parts, s.role.Description)
+	}
+	if s.role.Type != charts.AnyValue {
+		parts = append(parts, s.role.Type.String())
+	}
+	if s.role.Optional() {
+		parts = append(parts, "optional")
+	}
+	if s.role.Multiple() {
+		parts = append(parts, "select one or more")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ShowHeaderSelection creates and shows the graph type and column selection dialog.
+// Each role only offers columns whose type fits it, and the column types can be
+// overridden in the dialog. The callback receives a chart spec with the columns
+// selected for each role and the data with the chosen column types applied.
+func ShowHeaderSelection(data *dataset.Dataset, window fyne.Window, callback func(spec charts.ChartSpec, data *dataset.Dataset, limits map[string]int)) {
 	// Create UI components
 	previewContainer := container.New(layout.NewHBoxLayout(),
 		widget.NewLabel("Select a graph type to see preview"))
 	descriptionLabel := widget.NewLabel("")
-	axisSelectors := container.New(layout.NewVBoxLayout())
+	roleSelectors := container.New(layout.NewVBoxLayout())
+	stackCheck := widget.NewCheck("Stack series instead of grouping them", nil)
+	invalidSelect := newValuePolicySelect()
 
 	// Create selectors
 	graphTypeSelector := createGraphTypeSelector()
-	xAxisSelector := widget.NewSelect(headers, nil)
-	yAxisSelector := widget.NewSelect(headers, nil)
-	zAxisSelector := widget.NewSelect(headers, nil)
+	var columnSelectors []*roleSelector
 
 	// Update form based on graph type selection
 	updateForm := func(graphType string) {
-		if graphType == "" {
+		chartType, ok := charts.Lookup(graphType)
+		if !ok {
 			return
 		}
 
-		graphInfo := graphTypeInfos[graphType]
-		descriptionLabel.SetText(graphInfo.description)
+		descriptionLabel.SetText(chartType.Description())
 
-		updateAxisSelectors(axisSelectors, xAxisSelector, yAxisSelector, zAxisSelector, graphInfo.needs3D)
-		updatePreviewImage(graphType, previewContainer)
+		columnSelectors = updateRoleSelectors(roleSelectors, chartType.Roles(), data, columnSelectors)
+		if charts.CanStack(chartType) {
+			stackCheck.Show()
+		} else {
+			stackCheck.Hide()
+		}
+		updatePreviewImage(chartType.Preview(), previewContainer)
 	}
 
 	// Set up callbacks
 	graphTypeSelector.OnChanged = updateForm
+	updateForm(graphTypeSelector.Selected)
+
+	// Changing a column type changes which columns fit each role
+	columnTypes := newColumnTypesPanel(data, window, func(updated *dataset.Dataset) {
+		data = updated
+		updateForm(graphTypeSelector.Selected)
+	})
 
 	// Create dialog layout
 	form := container.New(layout.NewVBoxLayout(),
 		widget.NewForm(widget.NewFormItem("Graph Type", graphTypeSelector)),
 		descriptionLabel,
-		axisSelectors,
+		roleSelectors,
+		stackCheck,
+		widget.NewForm(widget.NewFormItem("Invalid values", invalidSelect)),
+		columnTypes,
 		previewContainer,
 	)
 
 	// Create and show dialog
-	showSelectionDialog(window, form, graphTypeSelector, xAxisSelector, yAxisSelector, zAxisSelector, callback)
+	showSelectionDialog(window, form, graphTypeSelector, stackCheck, invalidSelect, func() []*roleSelector { return columnSelectors },
+		func(spec charts.ChartSpec, limits map[string]int) {
+			callback(spec, data, limits)
+		})
 }
 
-func updatePreviewImage(graphType string, container *fyne.Container) {
-	img, err := loadImageFromCache(graphType)
+func updatePreviewImage(preview string, container *fyne.Container) {
+	if preview == "" {
+		container.RemoveAll()
+		container.Add(widget.NewLabel("No preview available"))
+		container.Refresh()
+		return
+	}
+
+	img, err := loadImageFromCache(preview)
 	if err != nil {
 		logger.LogErrorWithTrace(fmt.Errorf("failed to update preview image: %w", err))
 		container.RemoveAll()
-		container.Add(widget.NewLabel(fmt.Sprintf("No preview available for %s", graphType)))
+		container.Add(widget.NewLabel(fmt.Sprintf("No preview available for %s", preview)))
 		container.Refresh()
 		return
 	}
@@ -90,36 +200,54 @@ func updatePreviewImage(graphType string, container *fyne.Container) {
 // Helper functions
 func createGraphTypeSelector() *widget.Select {
 	var types []string
-	for gType := range graphTypeInfos {
-		types = append(types, gType)
+	for _, chartType := range charts.Types() {
+		types = append(types, chartType.Name())
 	}
 	selector := widget.NewSelect(types, nil)
 	selector.SetSelected("Bar")
 	return selector
 }
 
-func updateAxisSelectors(container *fyne.Container, x, y, z *widget.Select, needs3D bool) {
-	container.RemoveAll()
-	container.Add(widget.NewForm(
-		&widget.FormItem{Text: "X Axis", Widget: x},
-		&widget.FormItem{Text: "Y Axis", Widget: y},
-	))
-	if needs3D {
-		container.Add(widget.NewForm(
-			&widget.FormItem{Text: "Z Axis", Widget: z},
-		))
+// updateRoleSelectors rebuilds one column selector per role, keeping
+// selections made for the previous graph type where possible
+func updateRoleSelectors(box *fyne.Container, roles []charts.Role, data *dataset.Dataset, previous []*roleSelector) []*roleSelector {
+	selectors := make([]*roleSelector, len(roles))
+	form := widget.NewForm()
+	for i, role := range roles {
+		selectors[i] = newRoleSelector(role, roleOptions(role, data))
+		if i < len(previous) && previous[i].role.Multiple() == role.Multiple() {
+			selectors[i].restore(previous[i].selected())
+		}
+
+		item := widget.NewFormItem(role.Name, selectors[i].widget())
+		item.HintText = selectors[i].hint()
+		form.AppendItem(item)
 	}
-	container.Refresh()
+
+	box.RemoveAll()
+	if len(roles) > 0 && roles[len(roles)-1].Multiple() {
+		// Long lists of check boxes need to scroll
+		scroll := container.NewVScroll(form)
+		scroll.SetMinSize(fyne.NewSize(0, 250))
+		box.Add(scroll)
+	} else {
+		box.Add(form)
+	}
+	box.Refresh()
+	return selectors
 }
 
 func showSelectionDialog(
 	window fyne.Window,
 	content *fyne.Container,
-	graphType, xAxis, yAxis, zAxis *widget.Select,
-	callback func(string, string, string, string, map[string]int),
+	graphType *widget.Select,
+	stacked *widget.Check,
+	invalid *widget.Select,
+	columnSelectors func() []*roleSelector,
+	callback func(charts.ChartSpec, map[string]int),
 ) {
 	dialog := dialog.NewCustomConfirm(
-		"Select Graph Type and Axes",
+		"Select Graph Type and Columns",
 		"Create Graph",
 		"Cancel",
 		content,
@@ -128,18 +256,20 @@ func showSelectionDialog(
 				return
 			}
 
-			if err := validateSelections(graphType, xAxis, yAxis, zAxis); err != nil {
+			columns, err := validateSelections(graphType, columnSelectors())
+			if err != nil {
 				dialog.ShowError(err, window)
 				return
 			}
 
-			callback(
-				graphType.Selected,
-				xAxis.Selected,
-				yAxis.Selected,
-				zAxis.Selected,
-				nil,
-			)
+			policy := valuePolicies[invalid.SelectedIndex()]
+			rememberValuePolicy(policy)
+			callback(charts.ChartSpec{
+				Type:    graphType.Selected,
+				Columns: columns,
+				Stacked: stacked.Visible() && stacked.Checked,
+				Invalid: policy,
+			}, nil)
 		},
 		window,
 	)
@@ -148,15 +278,27 @@ func showSelectionDialog(
 	dialog.Show()
 }
 
-func validateSelections(graphType, xAxis, yAxis, zAxis *widget.Select) error {
-	if graphType.Selected == "" || xAxis.Selected == "" || yAxis.Selected == "" {
-		return errors.New("please select graph type, X axis, and Y axis")
+func validateSelections(graphType *widget.Select, selectors []*roleSelector) (map[string][]string, error) {
+	chartType, ok := charts.Lookup(graphType.Selected)
+	if !ok {
+		return nil, fmt.Errorf("please select a graph type")
+	}
+
+	columns := make(map[string][]string)
+	for _, selector := range selectors {
+		if selected := selector.selected(); len(selected) > 0 {
+			columns[selector.role.Name] = selected
+		}
 	}
 
-	graphInfo := graphTypeInfos[graphType.Selected]
-	if graphInfo.needs3D && zAxis.Selected == "" {
-		return errors.New("please select Z axis for 3D visualization")
+	for _, role := range chartType.Roles() {
+		if !role.Optional() && len(columns[role.Name]) == 0 {
+			return nil, fmt.Errorf("please select a column for %s", role.Name)
+		}
 	}
 
-	return nil
+	if err := charts.ValidateColumns(chartType, columns); err != nil {
+		return nil, err
+	}
+	return columns, nil
 }
@@ -0,0 +1,82 @@
+﻿package ui
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"graph-viewer/dataset"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dialogSelects returns the selects of the dialog shown over a window
+func dialogSelects(window fyne.Window) []*widget.Select {
+	var selects []*widget.Select
+	for _, object := range test.LaidOutObjects(window.Canvas().Overlays().Top()) {
+		if s, ok := object.(*widget.Select); ok {
+			selects = append(selects, s)
+		}
+	}
+	return selects
+}
+
+// roleSelects returns the selects of the dialog offering columns of data,
+// in role order
+func roleSelects(window fyne.Window, data *dataset.Dataset) []*widget.Select {
+	var selects []*widget.Select
+	for _, s := range dialogSelects(window) {
+		columns := slices.DeleteFunc(slices.Clone(s.Options), func(option string) bool { return option == noColumn })
+		if len(columns) > 0 && !slices.ContainsFunc(columns, func(option string) bool { return data.Index(option) == -1 }) {
+			selects = append(selects, s)
+		}
+	}
+	return selects
+}
+
+func TestHeaderSelectionKeepsRolesAcrossChanges(t *testing.T) {
+	test.NewTempApp(t)
+	window := test.NewWindow(nil)
+	defer window.Close()
+
+	data, err := dataset.FromRecords([][]string{
+		{"Region", "Units", "Price"},
+		{"North", "3", "1.5"},
+		{"South", "4", "2.5"},
+		{"East", "5", "3.5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ShowHeaderSelection(data, window, nil)
+
+	var graphType *widget.Select
+	var typeSelectors []*widget.Select
+	for _, s := range dialogSelects(window) {
+		switch {
+		case s.Selected == "Bar":
+			graphType = s
+		case reflect.DeepEqual(s.Options, typeOptions()):
+			typeSelectors = append(typeSelectors, s)
+		}
+	}
+	if graphType == nil || len(typeSelectors) != len(data.Columns) {
+		t.Fatalf("found graph type %v and %d type selectors in the dialog", graphType, len(typeSelectors))
+	}
+	roleSelects(window, data)[0].SetSelected("Region")
+
+	// Both changes rebuild the role selectors, keeping what was selected
+	graphType.SetSelected("Pie")
+	roleSelects(window, data)[1].SetSelected("Units")
+	typeSelectors[2].SetSelected(dataset.TypeCategory.String())
+
+	var selected []string
+	for _, s := range roleSelects(window, data) {
+		selected = append(selected, s.Selected)
+	}
+	if want := []string{"Region", "Units"}; !reflect.DeepEqual(selected, want) {
+		t.Errorf("Pie roles = %q, want %q", selected, want)
+	}
+}
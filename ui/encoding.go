@@ -0,0 +1,150 @@
+﻿package ui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// Encodings lists the character encodings offered to users. Any other
+// encoding known to web browsers can be named as well.
+var Encodings = []string{
+	"utf-8", "utf-16le", "utf-16be", "windows-1252", "iso-8859-15", "windows-1250",
+	"windows-1251", "shift_jis", "euc-jp", "gb18030", "big5", "euc-kr",
+}
+
+// byteOrderMarks start text in the encoding they are keyed by
+var byteOrderMarks = map[string][]byte{
+	"utf-8":    {0xEF, 0xBB, 0xBF},
+	"utf-16le": {0xFF, 0xFE},
+	"utf-16be": {0xFE, 0xFF},
+}
+
+// ParseEncoding returns the canonical name of a character encoding, e.g.
+// shift_jis for sjis
+func ParseEncoding(name string) (string, error) {
+	e, err := htmlindex.Get(strings.TrimSpace(name))
+	if err != nil {
+		return "", fmt.Errorf("unknown character encoding %q, use e.g. %s", name, strings.Join(Encodings[:6], ", "))
+	}
+	return htmlindex.Name(e)
+}
+
+// decodeText returns the text read from r converted from the named encoding
+// to UTF-8, without a byte order mark
+func decodeText(r io.Reader, name string) (io.Reader, error) {
+	if name == "" {
+		name = "utf-8"
+	}
+	e, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown character encoding %q", name)
+	}
+
+	buffered := bufio.NewReader(r)
+	if bom, ok := byteOrderMarks[name]; ok {
+		if start, err := buffered.Peek(len(bom)); err == nil && bytes.Equal(start, bom) {
+			buffered.Discard(len(bom))
+		}
+	}
+	if e == encoding.Nop || name == "utf-8" {
+		return buffered, nil
+	}
+	return transform.NewReader(buffered, e.NewDecoder()), nil
+}
+
+// detectEncoding names the encoding of a sample of text from its byte order
+// mark, or else from its bytes: UTF-16 has a zero byte in most ASCII
+// characters, and text that is not UTF-8 is decoded as Windows-1252 and
+// Shift-JIS to keep the encoding giving the more plausible characters.
+func detectEncoding(sample []byte) string {
+	for _, name := range []string{"utf-8", "utf-16le", "utf-16be"} {
+		if bytes.HasPrefix(sample, byteOrderMarks[name]) {
+			return name
+		}
+	}
+
+	// Zero bytes at odd offsets are the high bytes of little endian
+	// ASCII characters
+	even, odd := 0, 0
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	switch {
+	case odd > len(sample)/4 && odd > 2*even:
+		return "utf-16le"
+	case even > len(sample)/4 && even > 2*odd:
+		return "utf-16be"
+	}
+
+	if validUTF8(sample) {
+		return "utf-8"
+	}
+	best, bestScore := "windows-1252", plausibility(sample, "windows-1252")
+	for _, name := range []string{"shift_jis"} {
+		if score := plausibility(sample, name); score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	return best
+}
+
+// validUTF8 reports whether the sample is UTF-8, allowing a character cut
+// off at its end
+func validUTF8(sample []byte) bool {
+	if utf8.Valid(sample) {
+		return true
+	}
+	for cut := 1; cut < utf8.UTFMax && cut < len(sample); cut++ {
+		if utf8.Valid(sample[:len(sample)-cut]) {
+			return !utf8.FullRune(sample[len(sample)-cut:])
+		}
+	}
+	return false
+}
+
+// plausibility decodes the sample and returns the share of its non-ASCII
+// characters that are likely in text of that encoding, less the share of
+// bytes that do not decode
+func plausibility(sample []byte, name string) float64 {
+	e, err := htmlindex.Get(name)
+	if err != nil {
+		return -1
+	}
+	decoded, _ := io.ReadAll(transform.NewReader(bytes.NewReader(sample), e.NewDecoder()))
+
+	likely, total := 0, 0
+	for _, r := range string(decoded) {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		total++
+		switch {
+		case r == utf8.RuneError:
+			likely--
+		case name == "shift_jis" && (unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) ||
+			(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF5E)):
+			likely++
+		case name == "windows-1252" && (unicode.IsLetter(r) || strings.ContainsRune("€–—‘’“”•°±²³µ§½¼¾·", r)):
+			likely++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(likely) / float64(total)
+}
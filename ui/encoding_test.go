@@ -0,0 +1,89 @@
+﻿package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	japaneseText = "地域,売上\n東京,100\n大阪,200\nさっぽろ,300\n"
+	germanText   = "Straße;Menge\nMünchen;1\nKöln;2\nDüsseldorf;3\n"
+)
+
+func encode(t *testing.T, text string, encoder interface {
+	String(string) (string, error)
+}) []byte {
+	t.Helper()
+	encoded, err := encoder.String(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(encoded)
+}
+
+func TestDetectEncoding(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	tests := []struct {
+		name   string
+		sample []byte
+		want   string
+	}{
+		{"utf-8", []byte(germanText), "utf-8"},
+		{"utf-8 cut off", []byte(germanText[:18]), "utf-8"},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "a,b\n"...), "utf-8"},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, encode(t, "a,b\n", utf16.NewEncoder())...), "utf-16le"},
+		{"utf-16le", encode(t, germanText, utf16.NewEncoder()), "utf-16le"},
+		{"utf-16be", encode(t, germanText, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder()), "utf-16be"},
+		{"shift_jis", encode(t, japaneseText, japanese.ShiftJIS.NewEncoder()), "shift_jis"},
+		{"windows-1252", encode(t, germanText, charmap.Windows1252.NewEncoder()), "windows-1252"},
+	}
+	for _, tt := range tests {
+		if got := detectEncoding(tt.sample); got != tt.want {
+			t.Errorf("%s: detectEncoding = %s", tt.name, got)
+		}
+	}
+}
+
+func TestReadDataConvertsEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sales.csv")
+	if err := os.WriteFile(path, encode(t, japaneseText, japanese.ShiftJIS.NewEncoder()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := readData(path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("readData failed: %v", err)
+	}
+	if got := data.Headers(); !reflect.DeepEqual(got, []string{"地域", "売上"}) {
+		t.Errorf("headers = %q", got)
+	}
+	if got := data.Columns[0].Raw; !reflect.DeepEqual(got, []string{"東京", "大阪", "さっぽろ"}) {
+		t.Errorf("labels = %q", got)
+	}
+
+	// Reading the file in another encoding gives other labels
+	data, err = readData(path, ReadOptions{Dialect: &Dialect{Encoding: "windows-1252", Delimiter: ','}})
+	if err != nil {
+		t.Fatalf("readData as windows-1252 failed: %v", err)
+	}
+	if data.Headers()[0] == "地域" {
+		t.Errorf("the encoding override was ignored")
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for name, want := range map[string]string{"UTF-8": "utf-8", "sjis": "shift_jis", "latin1": "windows-1252", "utf-16le": "utf-16le"} {
+		if got, err := ParseEncoding(name); err != nil || got != want {
+			t.Errorf("ParseEncoding(%s) = %s, %v, want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseEncoding("klingon"); err == nil {
+		t.Errorf("expected an error for an unknown encoding")
+	}
+}
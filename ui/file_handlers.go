@@ -1,84 +1,104 @@
 ﻿package ui
 
 import (
+	"context"
 	"fmt"
-	"graph-viewer/logger"
-	"os"
-	"path/filepath"
-
-	"encoding/csv"
-
-	"github.com/xuri/excelize/v2"
+	"graph-viewer/dataset"
+	"io"
 )
 
-// readData parses the file and returns data for charting
-func readData(filePath string) ([]string, [][]string, error) {
-	ext := filepath.Ext(filePath)
-	var data [][]string
-	var err error
-
-	if ext == ".csv" {
-		data, err = readCSV(filePath)
-	} else if ext == ".xlsx" {
-		data, err = readXLSX(filePath)
-	} else {
-		return nil, nil, fmt.Errorf("unsupported file type: %s", ext)
-	}
-
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if len(data) < 2 {
-		return nil, nil, fmt.Errorf("insufficient rows in file")
-	}
-
-	headers := data[0]
-	rows := data[1:]
-
-	if len(headers) == 0 {
-		return nil, nil, fmt.Errorf("file contains no headers")
-	}
-
-	// Validate row lengths
-	for i, row := range rows {
-		if len(row) != len(headers) {
-			return nil, nil, fmt.Errorf("row %d has %d columns, expected %d", i+1, len(row), len(headers))
-		}
-	}
+// ReadOptions selects the part of a file that is read and which of its rows
+// are kept. Sheet and Range only apply to workbooks, Table to workbooks and
+// databases, Query only to databases, Dialect only to delimited text, Lines
+// only to other text, Arrays only to JSON and Columns only to Parquet.
+type ReadOptions struct {
+	Sheet string // worksheet to read, the active sheet when empty
+	Table string // table or defined name to read instead of a sheet
+	Range string // A1-style cell range within Sheet, e.g. B2:F100
+	Query string // SQL query whose result is read instead of a table
+
+	Dialect *Dialect      // how delimited text is written, detected when nil
+	Lines   *LineFormat   // how text that is not delimited splits into fields
+	Arrays  ArrayHandling // how arrays in JSON records become cells
+	Columns []string      // columns of a Parquet file to read, all when empty
+
+	// SourceColumn names a column added to the files of a folder or glob
+	// pattern read as one, holding the file each row comes from
+	SourceColumn string
+
+	// Ragged chooses what happens to rows with more or fewer fields than
+	// the header, and OnRepair is told about each row repaired
+	Ragged   RowRepair
+	OnRepair func(Repair) `json:"-"`
+
+	Limit  int // keep only the first Limit rows, 0 for all
+	Sample int // keep a random sample of Sample rows spread over the file
+
+	// Aggregate summarizes the rows while they are read instead of keeping
+	// them, so files larger than the memory budget can be charted
+	Aggregate *dataset.Aggregation `json:"-"`
+
+	MemoryBudget int64              `json:"-"` // bytes of cell text kept, DefaultMemoryBudget when 0
+	Progress     func(ReadProgress) `json:"-"` // called periodically while reading
+}
 
-	return headers, rows, nil
+// readData parses the file and returns data for charting
+func readData(filePath string, options ReadOptions) (*dataset.Dataset, error) {
+	return readDataContext(context.Background(), filePath, options)
 }
 
-// readCSV reads data from a CSV file
-func readCSV(filePath string) ([][]string, error) {
-	file, err := os.Open(filePath)
+// readDataContext is readData stopping early when ctx is canceled
+func readDataContext(ctx context.Context, filePath string, options ReadOptions) (*dataset.Dataset, error) {
+	source, err := openRows(filePath, options)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	defer source.close()
 
-	// Check file size
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
+	return collectRows(ctx, source, options)
+}
 
-	if stat.Size() > 100*1024*1024 {
-		logger.LogWithTrace("Warning: Processing large file, this may take some time")
+// openRows opens a file to be read row by row
+func openRows(filePath string, options ReadOptions) (rowSource, error) {
+	switch {
+	case isCombined(filePath):
+		return openCombined(filePath, options)
+	case isArchive(filePath):
+		entry, err := resolveArchive(filePath)
+		if err != nil {
+			return rowSource{}, err
+		}
+		return openRows(entry, options)
+	case options.Lines != nil:
+		return openLines(filePath, *options.Lines)
+	case isLog(filePath):
+		return rowSource{}, fmt.Errorf("%s files need a fixed-width or pattern line format to be read", dataExt(filePath))
+	case isDelimited(filePath):
+		return openDelimited(filePath, options)
+	case isWorkbook(filePath):
+		return openWorkbookRows(filePath, options)
+	case isJSON(filePath):
+		return openJSON(filePath, options)
+	case isParquet(filePath):
+		return openParquet(filePath, options)
+	case isSQLite(filePath):
+		return openSQLiteRows(filePath, options)
+	default:
+		return rowSource{}, fmt.Errorf("unsupported file type: %s", dataExt(filePath))
 	}
-
-	reader := csv.NewReader(file)
-	return reader.ReadAll()
 }
 
-// readXLSX reads data from an XLSX file
-func readXLSX(filePath string) ([][]string, error) {
-	file, err := excelize.OpenFile(filePath)
+// readHeaders returns the header row of a file
+func readHeaders(filePath string, options ReadOptions) ([]string, error) {
+	source, err := openRows(filePath, options)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	defer source.close()
 
-	return file.GetRows(file.GetSheetName(file.GetActiveSheetIndex()))
+	headers, err := source.rows.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file contains no headers")
+	}
+	return headers, err
 }
@@ -0,0 +1,99 @@
+﻿package ui
+
+import (
+	"fmt"
+	"graph-viewer/charts"
+	"graph-viewer/dataset"
+)
+
+// The functions below expose the data pipeline behind the GUI so it can be
+// driven without a window, e.g. from the command line.
+
+// ReadData parses a delimited or line formatted text, XLSX, XLS, ODS, JSON or
+// Parquet file or an SQLite database into a typed dataset. Files may be
+// compressed with gzip or zstd or be in a zip archive, e.g. exports.zip/sales.csv.
+// A folder or glob pattern such as reports/2026-*.csv reads its files as one.
+func ReadData(filePath string, options ReadOptions) (*dataset.Dataset, error) {
+	return readData(filePath, options)
+}
+
+// ListWorkbook returns the sheets, tables and named ranges of an XLSX, XLS
+// or ODS file
+func ListWorkbook(filePath string) (WorkbookContents, error) {
+	book, err := openWorkbook(filePath)
+	if err != nil {
+		return WorkbookContents{}, err
+	}
+	defer book.Close()
+	return book.contents(), nil
+}
+
+// ResolveArchive returns the path of the data to read from a file: the
+// path inside a zip archive of its only data file, else the path itself
+func ResolveArchive(filePath string) (string, error) {
+	return resolveArchive(filePath)
+}
+
+// IsCombined reports whether a path is a folder or glob pattern naming
+// several files that are read as one
+func IsCombined(filePath string) bool {
+	return isCombined(filePath)
+}
+
+// ListCombined returns the files a folder or glob pattern names, in the
+// order they are read
+func ListCombined(filePath string) ([]string, error) {
+	return listCombinedFiles(filePath)
+}
+
+// IsDelimited reports whether a file is delimited text such as CSV or TSV
+func IsDelimited(filePath string) bool {
+	return isDelimited(filePath)
+}
+
+// IsLog reports whether a file is text that is usually not delimited, such
+// as a log, and needs a line format to be read
+func IsLog(filePath string) bool {
+	return isLog(filePath)
+}
+
+// IsJSON reports whether a file holds JSON records, as an array or one
+// record per line
+func IsJSON(filePath string) bool {
+	return isJSON(filePath)
+}
+
+// IsParquet reports whether a file is an Apache Parquet file
+func IsParquet(filePath string) bool {
+	return isParquet(filePath)
+}
+
+// ListParquet returns the columns of a Parquet file and its number of rows
+func ListParquet(filePath string) ([]ParquetColumn, int64, error) {
+	return listParquetColumns(filePath)
+}
+
+// IsSQLite reports whether a file is an SQLite database
+func IsSQLite(filePath string) bool {
+	return isSQLite(filePath)
+}
+
+// ListTables returns the tables and views of an SQLite database
+func ListTables(filePath string) ([]string, error) {
+	return listSQLiteTables(filePath)
+}
+
+// IsWorkbook reports whether a file is a workbook with sheets to choose from
+func IsWorkbook(filePath string) bool {
+	return isWorkbook(filePath)
+}
+
+// ExtractGraphData selects and validates the columns chosen in spec for each
+// role of its graph type
+func ExtractGraphData(spec charts.ChartSpec, data *dataset.Dataset, limits map[string]int) (*dataset.Dataset, error) {
+	chartType, ok := charts.Lookup(spec.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported graph type: %s", spec.Type)
+	}
+	return extractSelectedData(chartType, spec, data, limits)
+}
@@ -3,19 +3,17 @@ package ui
 
 import (
 	"fmt"
+	"graph-viewer/charts"
 	"testing"
 )
 
 func TestEmbeddedFilesPresent(t *testing.T) {
-	// List of expected Images
-	expectedPNGs := []string{
-		"Bar.png",
-		"Heatmap.png",
-		"Scatter3D.png",
-		"Bar3D.png",
-		"Pie.png",
-		"Sankey.png",
-		"ThemeRiver.png",
+	// Every registered chart type with a preview needs an image
+	var expectedPNGs []string
+	for _, chartType := range charts.Types() {
+		if chartType.Preview() != "" {
+			expectedPNGs = append(expectedPNGs, chartType.Preview()+".png")
+		}
 	}
 
 	// Get all embedded files
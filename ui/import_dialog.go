@@ -0,0 +1,169 @@
+﻿package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showImportOptions asks how a delimited text file is written, starting from
+// the settings last used for the file or else the detected ones. A preview
+// shows the rows as they will be read.
+func showImportOptions(window fyne.Window, filePath string, callback func(ReadOptions)) {
+	detected, err := DetectDialect(filePath)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	dialect, ragged := detected, RepairTruncate
+	if previous := rememberedOptions(filePath); previous.Dialect != nil {
+		dialect, ragged = *previous.Dialect, previous.Ragged
+	}
+
+	encodingEntry := widget.NewSelectEntry(Encodings)
+	encodingEntry.SetText(dialect.Encoding)
+	delimiterEntry := widget.NewSelectEntry([]string{"comma", "semicolon", "tab", "pipe", "space"})
+	delimiterEntry.SetText(dialectCharLabel(dialect.Delimiter))
+	quoteSelector := widget.NewSelect([]string{"double quote", "single quote", "none"}, nil)
+	quoteSelector.SetSelected(map[rune]string{'"': "double quote", '\'': "single quote", 0: "none"}[dialect.Quote])
+	commentEntry := widget.NewEntry()
+	commentEntry.SetPlaceHolder("No comment lines")
+	if dialect.Comment != 0 {
+		commentEntry.SetText(string(dialect.Comment))
+	}
+	skipEntry := widget.NewEntry()
+	skipEntry.SetText(strconv.Itoa(dialect.SkipLines))
+
+	raggedSelector := widget.NewSelect(raggedLabels, nil)
+	raggedSelector.SetSelected(raggedLabels[ragged])
+
+	status := widget.NewLabel("")
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	// selection returns the dialect set in the form
+	selection := func() (Dialect, error) {
+		var d Dialect
+		var err error
+		if d.Encoding, err = ParseEncoding(encodingEntry.Text); err != nil {
+			return d, err
+		}
+		if d.Delimiter, err = ParseDialectChar(delimiterEntry.Text); err != nil || d.Delimiter == 0 {
+			return d, fmt.Errorf("delimiter: %s is not a character or a name such as tab", delimiterEntry.Text)
+		}
+		d.Quote, _ = ParseDialectChar(quoteSelector.Selected)
+		if d.Comment, err = ParseDialectChar(commentEntry.Text); err != nil {
+			return d, fmt.Errorf("comment: %v", err)
+		}
+		if d.SkipLines, err = strconv.Atoi(strings.TrimSpace(skipEntry.Text)); err != nil || d.SkipLines < 0 {
+			return d, fmt.Errorf("lines to skip must be a number of 0 or more")
+		}
+		return d, nil
+	}
+
+	updatePreview := func() {
+		previewData = nil
+		defer preview.Refresh()
+
+		d, err := selection()
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		source, err := openDelimited(filePath, ReadOptions{Dialect: &d})
+		if err == nil {
+			previewData, err = readSourceRows(source, previewRows)
+		}
+		if err != nil {
+			status.SetText(err.Error())
+			return
+		}
+		status.SetText("Detected " + detected.String())
+	}
+
+	encodingEntry.OnChanged = func(string) { updatePreview() }
+	delimiterEntry.OnChanged = func(string) { updatePreview() }
+	quoteSelector.OnChanged = func(string) { updatePreview() }
+	commentEntry.OnChanged = func(string) { updatePreview() }
+	skipEntry.OnChanged = func(string) { updatePreview() }
+	updatePreview()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Encoding", encodingEntry),
+		widget.NewFormItem("Delimiter", delimiterEntry),
+		widget.NewFormItem("Quote", quoteSelector),
+		widget.NewFormItem("Comment", commentEntry),
+		widget.NewFormItem("Skip lines", skipEntry),
+		widget.NewFormItem("Ragged rows", raggedSelector),
+	)
+	form.Items[4].HintText = "Lines above the header, e.g. a report title"
+	// Text that is not delimited, such as a report or a log, is split by a
+	// line format instead
+	var importDialog *dialog.ConfirmDialog
+	linesButton := widget.NewButton("Fixed Width or Pattern...", func() {
+		importDialog.Hide()
+		showLineFormat(window, filePath, callback)
+	})
+	content := container.NewBorder(container.NewVBox(form, container.NewHBox(linesButton), status), nil, nil, nil, preview)
+
+	importDialog = dialog.NewCustomConfirm(
+		"Import Options",
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			d, err := selection()
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			options := ReadOptions{Dialect: &d, Ragged: raggedPolicy(raggedSelector.Selected)}
+			rememberOptions(filePath, options)
+			callback(options)
+		},
+		window,
+	)
+
+	importDialog.Resize(fyne.NewSize(700, 550))
+	importDialog.Show()
+}
+
+// raggedLabels describe the row repairs in the order of RowRepairs
+var raggedLabels = []string{"fail", "pad short rows, truncate long ones", "pad short rows, merge long ones"}
+
+// raggedPolicy returns the row repair with the given label
+func raggedPolicy(label string) RowRepair {
+	for i, l := range raggedLabels {
+		if l == label {
+			return RowRepairs[i]
+		}
+	}
+	return RepairFail
+}
+
+// dialectCharLabel names a delimiter the way the delimiter entry offers it
+func dialectCharLabel(r rune) string {
+	switch r {
+	case ',':
+		return "comma"
+	case ';':
+		return "semicolon"
+	case '\t':
+		return "tab"
+	case '|':
+		return "pipe"
+	case ' ':
+		return "space"
+	default:
+		return string(r)
+	}
+}
@@ -0,0 +1,194 @@
+﻿package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"graph-viewer/dataset"
+	"graph-viewer/logger"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showJoinStep tells how much data was loaded and offers to join another
+// file to it on key columns before charting. next gets the data, joined or
+// not, when the user continues.
+func showJoinStep(window fyne.Window, data *dataset.Dataset, next func(*dataset.Dataset)) {
+	summary := widget.NewLabel(fmt.Sprintf(
+		"Loaded %d rows and %d columns.\n\nJoin another file to add its columns to the rows whose key columns match, or continue to choose a graph.",
+		data.Len(), len(data.Columns)))
+	summary.Wrapping = fyne.TextWrapWord
+
+	joinDialog := dialog.NewCustomConfirm(
+		"Join Data",
+		"Join Another File...",
+		"Continue",
+		summary,
+		func(join bool) {
+			if !join {
+				next(data)
+				return
+			}
+
+			dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+				if err != nil {
+					logger.LogErrorWithTrace(fmt.Errorf("file selection error: %v", err))
+					dialog.ShowError(err, window)
+				}
+				if err != nil || reader == nil {
+					showJoinStep(window, data, next)
+					return
+				}
+
+				defer reader.Close()
+				rightPath := reader.URI().Path()
+				openFile(window, rightPath, func(right *dataset.Dataset) {
+					showJoinOptions(window, data, right, filepath.Base(rightPath), func(joined *dataset.Dataset) {
+						showJoinStep(window, joined, next)
+					})
+				})
+			}, window)
+		},
+		window,
+	)
+
+	joinDialog.Resize(fyne.NewSize(450, 220))
+	joinDialog.Show()
+}
+
+// defaultJoinKey returns the first column of left that right also has, or
+// the first column of each when they share none
+func defaultJoinKey(left, right *dataset.Dataset) (string, string) {
+	for _, name := range left.Headers() {
+		if right.Index(name) != -1 {
+			return name, name
+		}
+	}
+	return left.Columns[0].Name, right.Columns[0].Name
+}
+
+// showJoinOptions asks how to join the right dataset, read from the file
+// named rightName, to the left one: which rows to keep and which key
+// columns to match. The size of the result and the unmatched and duplicated
+// keys are shown as the options change. callback gets the joined data, or
+// the left data when no join is made.
+func showJoinOptions(window fyne.Window, left, right *dataset.Dataset, rightName string, callback func(*dataset.Dataset)) {
+	kinds := make([]string, len(dataset.JoinKinds))
+	for i, kind := range dataset.JoinKinds {
+		kinds[i] = kind.String()
+	}
+	kindSelector := widget.NewRadioGroup(kinds, nil)
+	kindSelector.Horizontal = true
+	kindSelector.Required = true
+	kindSelector.SetSelected(dataset.JoinInner.String())
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	type keyPair struct{ left, right *widget.Select }
+	var pairs []keyPair
+	keyRows := container.NewVBox()
+
+	var joined *dataset.Dataset
+	var joinErr error
+	// spec returns the join the options describe
+	spec := func() dataset.Join {
+		kind, _ := dataset.ParseJoinKind(kindSelector.Selected)
+		join := dataset.Join{Kind: kind}
+		for _, pair := range pairs {
+			join.LeftKeys = append(join.LeftKeys, pair.left.Selected)
+			join.RightKeys = append(join.RightKeys, pair.right.Selected)
+		}
+		return join
+	}
+	updatePreview := func() {
+		previewData = nil
+		defer preview.Refresh()
+
+		var report dataset.JoinReport
+		joined, report, joinErr = left.Join(right, spec())
+		if joinErr != nil {
+			status.SetText(joinErr.Error())
+			return
+		}
+		text := "The joined data has " + report.Summary() + "."
+		if warnings := report.Warnings(); len(warnings) > 0 {
+			text += "\n" + strings.Join(warnings, ".\n") + "."
+		}
+		status.SetText(text)
+		previewData = joined.Head(previewRows).Records()
+	}
+	kindSelector.OnChanged = func(string) { updatePreview() }
+
+	addKey := func(leftName, rightName string) {
+		pair := keyPair{
+			left:  widget.NewSelect(left.Headers(), func(string) { updatePreview() }),
+			right: widget.NewSelect(right.Headers(), func(string) { updatePreview() }),
+		}
+		pair.left.Selected, pair.right.Selected = leftName, rightName
+		pairs = append(pairs, pair)
+
+		var row *fyne.Container
+		remove := widget.NewButton("Remove", func() {
+			if len(pairs) == 1 {
+				return // a join needs a key
+			}
+			for i := range pairs {
+				if pairs[i] == pair {
+					pairs = append(pairs[:i], pairs[i+1:]...)
+					break
+				}
+			}
+			keyRows.Remove(row)
+			updatePreview()
+		})
+		row = container.NewBorder(nil, nil, nil, remove,
+			container.NewGridWithColumns(3, pair.left, widget.NewLabel("matches"), pair.right))
+		keyRows.Add(row)
+	}
+	addKey(defaultJoinKey(left, right))
+	updatePreview()
+
+	addButton := widget.NewButton("Add Key", func() {
+		addKey(defaultJoinKey(left, right))
+		updatePreview()
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem("Keep", kindSelector),
+		widget.NewFormItem("Keys", container.NewVBox(keyRows, container.NewHBox(addButton))),
+	)
+	form.Items[0].HintText = "inner: rows matched in both files, left: every loaded row, outer: every row of both"
+	form.Items[1].HintText = "Rows match when all their key columns have the same values"
+	content := container.NewBorder(container.NewVBox(form, status), nil, nil, nil, preview)
+
+	joinDialog := dialog.NewCustomConfirm(
+		"Join "+rightName,
+		"Join",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if !confirmed {
+				callback(left)
+				return
+			}
+			if joinErr != nil {
+				dialog.ShowError(joinErr, window)
+				callback(left)
+				return
+			}
+			logger.LogWithTrace(fmt.Sprintf("Joined %s: %s", rightName, status.Text))
+			callback(joined)
+		},
+		window,
+	)
+
+	joinDialog.Resize(fyne.NewSize(750, 600))
+	joinDialog.Show()
+}
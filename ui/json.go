@@ -0,0 +1,496 @@
+﻿package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ArrayHandling chooses how arrays in JSON records become cells
+type ArrayHandling int
+
+const (
+	JoinArrays    ArrayHandling = iota // join the elements into one cell, separated by commas
+	ExplodeArrays                      // repeat the record once per element
+)
+
+// ArrayHandlings lists the choices in the order offered to users
+var ArrayHandlings = []ArrayHandling{JoinArrays, ExplodeArrays}
+
+func (a ArrayHandling) String() string {
+	if a == ExplodeArrays {
+		return "explode"
+	}
+	return "join"
+}
+
+// ParseArrayHandling returns the array handling with the given name
+func ParseArrayHandling(name string) (ArrayHandling, error) {
+	for _, a := range ArrayHandlings {
+		if strings.EqualFold(a.String(), strings.TrimSpace(name)) {
+			return a, nil
+		}
+	}
+	return JoinArrays, fmt.Errorf("unknown array handling %q, use join or explode", name)
+}
+
+// arraySeparator separates the elements of joined arrays
+const arraySeparator = ", "
+
+// isJSON reports whether a file holds JSON records
+func isJSON(filePath string) bool {
+	switch dataExt(filePath) {
+	case ".json", ".ndjson", ".jsonl":
+		return true
+	default:
+		return false
+	}
+}
+
+// isJSONLines reports whether a file holds one JSON record per line
+func isJSONLines(filePath string) bool {
+	ext := dataExt(filePath)
+	return ext == ".ndjson" || ext == ".jsonl"
+}
+
+// openJSON opens a file of JSON records to be read as rows. Nested objects
+// become columns named by their path, e.g. customer.address.city, and arrays
+// are joined or exploded as options.Arrays chooses.
+//
+// Records may have different fields, so the file is read twice: once for the
+// columns and once for the rows. With options.Limit only the first records
+// are looked at for columns.
+func openJSON(filePath string, options ReadOptions) (rowSource, error) {
+	counter := &countingReader{}
+	headers, err := scanJSONColumns(filePath, counter, options.Limit)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	file, err := openInput(filePath, counter)
+	if err != nil {
+		return rowSource{}, err
+	}
+	records, err := newJSONRecords(file, isJSONLines(filePath))
+	if err != nil {
+		file.Close()
+		return rowSource{}, err
+	}
+
+	columns := make(map[string]int, len(headers))
+	for i, header := range headers {
+		columns[header] = i
+	}
+	return rowSource{
+		rows:    &jsonRows{records: records, headers: headers, columns: columns, arrays: options.Arrays},
+		counter: counter,
+		size:    2 * file.size, // both readings count
+		close:   file.Close,
+	}, nil
+}
+
+// scanJSONColumns returns the columns of the records in a JSON file in order
+// of appearance, looking at no more than limit records unless limit is 0.
+// Arrays add the same columns joined or exploded, so they are joined here.
+func scanJSONColumns(filePath string, counter *countingReader, limit int) ([]string, error) {
+	file, err := openInput(filePath, counter)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := newJSONRecords(file, isJSONLines(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []string
+	seen := make(map[string]bool)
+	for n := 0; limit <= 0 || n < limit; n++ {
+		record, _, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range flattenJSON("", record, JoinArrays)[0] {
+			if !seen[cell.key] {
+				seen[cell.key] = true
+				headers = append(headers, cell.key)
+			}
+		}
+	}
+	return headers, nil
+}
+
+// jsonField is a member of a JSON object
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonObject holds the members of a JSON object in the order of the file,
+// which gives the order of the columns
+type jsonObject []jsonField
+
+// decodeJSON reads the next JSON value. Objects are decoded to jsonObject,
+// arrays to []interface{} and numbers to json.Number.
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONFrom(dec, token)
+}
+
+// decodeJSONFrom reads the JSON value starting with token
+func decodeJSONFrom(dec *json.Decoder, token json.Token) (interface{}, error) {
+	switch token {
+	case json.Delim('{'):
+		var object jsonObject
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonField{key: key.(string), value: value})
+		}
+		_, err := dec.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := dec.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+// jsonRecord is a record decoded ahead of being read
+type jsonRecord struct {
+	value interface{}
+	line  int
+}
+
+// jsonRecords reads the records of a JSON file: the elements of a top-level
+// array, the elements of the first array of objects in a top-level object
+// such as {"data": [...]}, or each top-level value as in newline-delimited
+// JSON
+type jsonRecords struct {
+	dec     *json.Decoder
+	lines   *lineCounter
+	inArray bool         // reading the elements of an array
+	held    []jsonRecord // records decoded while looking for the array
+}
+
+// newJSONRecords starts reading records from r, which holds one record per
+// line when lines is set
+func newJSONRecords(r io.Reader, lines bool) (*jsonRecords, error) {
+	text, err := decodeText(r, "utf-8")
+	if err != nil {
+		return nil, err
+	}
+	counter := &lineCounter{r: text}
+	records := &jsonRecords{dec: json.NewDecoder(counter), lines: counter}
+	records.dec.UseNumber()
+	if lines {
+		return records, nil
+	}
+	if err := records.start(); err != nil && err != io.EOF {
+		return nil, records.wrap(err)
+	}
+	return records, nil
+}
+
+// start reads up to the first record of a JSON file
+func (r *jsonRecords) start() error {
+	token, err := r.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case json.Delim('['):
+		r.inArray = true
+		return nil
+	case json.Delim('{'):
+	default:
+		return fmt.Errorf("JSON data must be an array of objects, an object holding one, or one object per line")
+	}
+
+	// An object holding an array of objects wraps the records, any other
+	// object is a record itself
+	line := r.lines.lineAt(r.dec.InputOffset() - 1)
+	var object jsonObject
+	for r.dec.More() {
+		key, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		token, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		if token != json.Delim('[') || !r.dec.More() {
+			value, err := decodeJSONFrom(r.dec, token)
+			if err != nil {
+				return err
+			}
+			object = append(object, jsonField{key: key.(string), value: value})
+			continue
+		}
+
+		first, firstLine, err := r.decode()
+		if err != nil {
+			return err
+		}
+		if _, ok := first.(jsonObject); ok {
+			r.inArray = true
+			r.held = append(r.held, jsonRecord{value: first, line: firstLine})
+			return nil
+		}
+		array := []interface{}{first}
+		for r.dec.More() {
+			value, err := decodeJSON(r.dec)
+			if err != nil {
+				return err
+			}
+			array = append(array, value)
+		}
+		if _, err := r.dec.Token(); err != nil {
+			return err
+		}
+		object = append(object, jsonField{key: key.(string), value: array})
+	}
+	if _, err := r.dec.Token(); err != nil {
+		return err
+	}
+	// Further top-level values are records as well
+	r.held = append(r.held, jsonRecord{value: object, line: line})
+	return nil
+}
+
+// next returns the next record and the line it starts on, or io.EOF after
+// the last one. Anything following the array of records is ignored.
+func (r *jsonRecords) next() (interface{}, int, error) {
+	if len(r.held) > 0 {
+		record := r.held[0]
+		r.held = r.held[1:]
+		return record.value, record.line, nil
+	}
+	if r.inArray && !r.dec.More() {
+		return nil, 0, io.EOF
+	}
+	value, line, err := r.decode()
+	if err != nil && err != io.EOF {
+		return nil, 0, r.wrap(err)
+	}
+	return value, line, err
+}
+
+// decode reads the next value and the line it starts on
+func (r *jsonRecords) decode() (interface{}, int, error) {
+	token, err := r.dec.Token()
+	if err != nil {
+		return nil, 0, err
+	}
+	line := r.lines.lineAt(r.dec.InputOffset() - 1)
+	value, err := decodeJSONFrom(r.dec, token)
+	return value, line, err
+}
+
+// wrap adds the line of a syntax error to it
+func (r *jsonRecords) wrap(err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("line %d: %w", r.lines.lineAt(syntaxErr.Offset), err)
+	}
+	if err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("JSON data ends unexpectedly")
+	}
+	return err
+}
+
+// lineCounter tells the line of an offset in the text read through it.
+// Offsets must be asked in increasing order; only the line breaks between
+// the last offset asked and the end of the text read are kept.
+type lineCounter struct {
+	r       io.Reader
+	read    int64   // bytes read
+	pending []int64 // offsets of the line breaks not yet passed
+	line    int     // line breaks passed
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.pending = append(c.pending, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// lineAt returns the line of the byte at offset, counting from 1
+func (c *lineCounter) lineAt(offset int64) int {
+	passed := 0
+	for passed < len(c.pending) && c.pending[passed] < offset {
+		passed++
+	}
+	c.line += passed
+	c.pending = c.pending[passed:]
+	return c.line + 1
+}
+
+// jsonCell is a value of a flattened record and the column it belongs to
+type jsonCell struct {
+	key   string
+	value string
+}
+
+// flattenJSON turns a JSON value into rows of cells named by their path
+// below prefix. Exploding arrays gives one row per element, and one row per
+// combination when a record holds several arrays; otherwise the result is
+// a single row.
+func flattenJSON(prefix string, value interface{}, arrays ArrayHandling) [][]jsonCell {
+	switch v := value.(type) {
+	case jsonObject:
+		rows := [][]jsonCell{nil}
+		for _, field := range v {
+			path := field.key
+			if prefix != "" {
+				path = prefix + "." + field.key
+			}
+			rows = crossRows(rows, flattenJSON(path, field.value, arrays))
+		}
+		return rows
+	case []interface{}:
+		if arrays == JoinArrays {
+			return [][]jsonCell{joinJSON(prefix, v)}
+		}
+		if len(v) == 0 {
+			return [][]jsonCell{nil}
+		}
+		var rows [][]jsonCell
+		for _, element := range v {
+			rows = append(rows, flattenJSON(prefix, element, arrays)...)
+		}
+		return rows
+	default:
+		if prefix == "" {
+			prefix = "value"
+		}
+		return [][]jsonCell{{{key: prefix, value: jsonText(v)}}}
+	}
+}
+
+// joinJSON flattens the elements of an array and joins the values of each
+// column, so an array of objects gives one cell per field
+func joinJSON(prefix string, elements []interface{}) []jsonCell {
+	var joined []jsonCell
+	index := make(map[string]int)
+	for _, element := range elements {
+		for _, cell := range flattenJSON(prefix, element, JoinArrays)[0] {
+			i, ok := index[cell.key]
+			if !ok {
+				index[cell.key] = len(joined)
+				joined = append(joined, cell)
+				continue
+			}
+			joined[i].value += arraySeparator + cell.value
+		}
+	}
+	return joined
+}
+
+// crossRows returns every row combined with every row of more. The rows
+// are not shared, so a single row is extended in place.
+func crossRows(rows, more [][]jsonCell) [][]jsonCell {
+	if len(rows) == 1 && len(more) == 1 {
+		rows[0] = append(rows[0], more[0]...)
+		return rows
+	}
+	crossed := make([][]jsonCell, 0, len(rows)*len(more))
+	for _, row := range rows {
+		for _, cells := range more {
+			crossed = append(crossed, append(row[:len(row):len(row)], cells...))
+		}
+	}
+	return crossed
+}
+
+// jsonText returns the text of a JSON scalar
+func jsonText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonRows reads the records of a JSON file as rows of the given columns,
+// after a header row naming them
+type jsonRows struct {
+	records *jsonRecords
+	headers []string // returned by the first Read
+	started bool     // the headers were read
+	columns map[string]int
+	arrays  ArrayHandling
+
+	pending [][]string // rows of the last record not yet read
+	line    int        // line of the last record
+}
+
+func (j *jsonRows) Read() ([]string, error) {
+	if !j.started {
+		j.started = true
+		return j.headers, nil
+	}
+
+	for len(j.pending) == 0 {
+		record, line, err := j.records.next()
+		if err != nil {
+			return nil, err
+		}
+		j.line = line
+		for _, cells := range flattenJSON("", record, j.arrays) {
+			row := make([]string, len(j.columns))
+			for _, cell := range cells {
+				if i, ok := j.columns[cell.key]; ok {
+					row[i] = cell.value
+				}
+			}
+			j.pending = append(j.pending, row)
+		}
+	}
+	row := j.pending[0]
+	j.pending = j.pending[1:]
+	return row, nil
+}
+
+// Line returns the line the record of the last row starts on
+func (j *jsonRows) Line() int {
+	return j.line
+}
@@ -0,0 +1,75 @@
+﻿package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// arrayLabels describe the array handlings in the order of ArrayHandlings
+var arrayLabels = []string{"Join the elements into one cell", "One row per element"}
+
+// showJSONOptions asks how arrays in the records of a JSON file become cells,
+// starting from the choice last made for the file. A preview shows the
+// first rows as they will be read.
+func showJSONOptions(window fyne.Window, filePath string, callback func(ReadOptions)) {
+	arrays := rememberedOptions(filePath).Arrays
+
+	status := widget.NewLabel("")
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	updatePreview := func() {
+		previewData = nil
+		defer preview.Refresh()
+
+		source, err := openJSON(filePath, ReadOptions{Arrays: arrays, Limit: previewRows})
+		if err == nil {
+			previewData, err = readSourceRows(source, previewRows)
+		}
+		switch {
+		case err != nil:
+			status.SetText(err.Error())
+		case len(previewData) <= 1:
+			status.SetText("The file holds no records")
+		default:
+			status.SetText(fmt.Sprintf("Preview of the first %d rows, nested fields are named by their path", len(previewData)-1))
+		}
+	}
+
+	arraySelector := widget.NewRadioGroup(arrayLabels, func(label string) {
+		for i, l := range arrayLabels {
+			if l == label {
+				arrays = ArrayHandlings[i]
+			}
+		}
+		updatePreview()
+	})
+	arraySelector.Required = true
+	arraySelector.SetSelected(arrayLabels[arrays])
+
+	form := widget.NewForm(widget.NewFormItem("Arrays", arraySelector))
+	content := container.NewBorder(container.NewVBox(form, status), nil, nil, nil, preview)
+
+	jsonDialog := dialog.NewCustomConfirm(
+		"JSON Options",
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			options := ReadOptions{Arrays: arrays}
+			rememberOptions(filePath, options)
+			callback(options)
+		},
+		window,
+	)
+
+	jsonDialog.Resize(fyne.NewSize(700, 500))
+	jsonDialog.Show()
+}
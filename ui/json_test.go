@@ -0,0 +1,85 @@
+﻿package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes text to a file with the given name in a temporary
+// directory
+func writeFile(t *testing.T, name, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadJSONFlattensRecords(t *testing.T) {
+	orders := `[
+  {"id": 1, "customer": {"name": "Ann", "address": {"city": "Oslo"}}, "tags": ["new", "gift"],
+   "items": [{"sku": "a", "qty": 2}, {"sku": "b", "qty": 1}]},
+  {"id": 2, "customer": {"name": "Bo"}, "paid": true, "tags": [], "items": [{"sku": "c", "qty": 5}]}
+]`
+	tests := []struct {
+		name    string
+		file    string
+		text    string
+		arrays  ArrayHandling
+		headers []string
+		rows    [][]string
+		lines   []int
+	}{
+		{"joined", "orders.json", orders, JoinArrays,
+			[]string{"id", "customer.name", "customer.address.city", "tags", "items.sku", "items.qty", "paid"},
+			[][]string{{"1", "Ann", "Oslo", "new, gift", "a, b", "2, 1", ""}, {"2", "Bo", "", "", "c", "5", "true"}},
+			[]int{2, 4}},
+		{"exploded", "orders.json", orders, ExplodeArrays,
+			[]string{"id", "customer.name", "customer.address.city", "tags", "items.sku", "items.qty", "paid"},
+			[][]string{
+				{"1", "Ann", "Oslo", "new", "a", "2", ""}, {"1", "Ann", "Oslo", "new", "b", "1", ""},
+				{"1", "Ann", "Oslo", "gift", "a", "2", ""}, {"1", "Ann", "Oslo", "gift", "b", "1", ""},
+				{"2", "Bo", "", "", "c", "5", "true"},
+			},
+			[]int{2, 2, 2, 2, 4}},
+		{"wrapped", "response.json", `{"count": 2, "tags": ["x"], "data": [{"day": "2024-01-01", "n": 3}, {"day": "2024-01-02", "n": 4}], "next": null}`,
+			JoinArrays, []string{"day", "n"}, [][]string{{"2024-01-01", "3"}, {"2024-01-02", "4"}}, []int{1, 1}},
+		{"lines", "log.ndjson", "{\"level\": \"info\", \"ms\": 12}\n\n{\"level\": \"warn\", \"ms\": 40, \"user\": {\"id\": 7}}\n",
+			JoinArrays, []string{"level", "ms", "user.id"}, [][]string{{"info", "12", ""}, {"warn", "40", "7"}}, []int{1, 3}},
+	}
+	for _, tt := range tests {
+		data, err := readData(writeFile(t, tt.file, tt.text), ReadOptions{Arrays: tt.arrays})
+		if err != nil {
+			t.Errorf("%s: readData failed: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(data.Headers(), tt.headers) {
+			t.Errorf("%s: headers = %q, want %q", tt.name, data.Headers(), tt.headers)
+		}
+		if rows := data.Records()[1:]; !reflect.DeepEqual(rows, tt.rows) {
+			t.Errorf("%s: rows = %q, want %q", tt.name, rows, tt.rows)
+		}
+		if !reflect.DeepEqual(data.SourceRows, tt.lines) {
+			t.Errorf("%s: lines = %v, want %v", tt.name, data.SourceRows, tt.lines)
+		}
+	}
+}
+
+func TestReadJSONErrors(t *testing.T) {
+	for name, text := range map[string]string{
+		"broken.ndjson": "{\"a\": 1}\n{\"a\": 2,,}\n",
+		"broken.json":   "[{\"a\": 1},\n {\"a\": }]",
+		"scalar.json":   "42",
+	} {
+		_, err := readData(writeFile(t, name, text), ReadOptions{})
+		if err == nil {
+			t.Errorf("%s: readData succeeded, want an error", name)
+		} else if strings.HasPrefix(name, "broken") && !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("%s: error %q does not name line 2", name, err)
+		}
+	}
+}
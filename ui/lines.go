@@ -0,0 +1,331 @@
+﻿package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// suggestLines is the number of lines looked at to suggest fixed-width
+// column positions
+const suggestLines = 200
+
+// LineFormat splits the lines of text that is not delimited into fields:
+// at fixed character positions, as in mainframe reports, or by a regular
+// expression with a named group per column, as in application logs. Saved
+// as JSON it can be loaded again for other files written the same way.
+type LineFormat struct {
+	Encoding  string `json:",omitempty"` // character encoding, detected when empty
+	SkipLines int    `json:",omitempty"` // lines above the header or first record
+
+	// Starts are the character positions where fixed-width columns start,
+	// counted from 0. Headers names the columns; when empty the first line
+	// holds their names.
+	Starts  []int    `json:",omitempty"`
+	Headers []string `json:",omitempty"`
+
+	// Pattern is a regular expression matched against each line. Its named
+	// groups are the columns and lines it does not match are skipped.
+	Pattern string `json:",omitempty"`
+}
+
+// isLog reports whether a file is text that is usually not delimited, such
+// as a log or a printed report
+func isLog(filePath string) bool {
+	switch dataExt(filePath) {
+	case ".log", ".prn", ".dat":
+		return true
+	default:
+		return false
+	}
+}
+
+// fixedWidth reports whether the format splits lines at fixed positions
+func (f LineFormat) fixedWidth() bool {
+	return f.Pattern == ""
+}
+
+// Validate checks that the format defines either fixed-width columns or a
+// pattern with named groups
+func (f LineFormat) Validate() error {
+	if f.SkipLines < 0 {
+		return fmt.Errorf("lines to skip must not be negative")
+	}
+	if !f.fixedWidth() {
+		if len(f.Starts) > 0 || len(f.Headers) > 0 {
+			return fmt.Errorf("a line format has either column positions or a pattern, not both")
+		}
+		_, err := f.compile()
+		return err
+	}
+
+	if len(f.Starts) == 0 {
+		return fmt.Errorf("a line format needs column positions or a pattern")
+	}
+	for i, start := range f.Starts {
+		if start < 0 || (i > 0 && start <= f.Starts[i-1]) {
+			return fmt.Errorf("column positions must be increasing and not negative, got %v", f.Starts)
+		}
+	}
+	if len(f.Headers) > 0 && len(f.Headers) != len(f.Starts) {
+		return fmt.Errorf("%d column names for %d columns", len(f.Headers), len(f.Starts))
+	}
+	return nil
+}
+
+// compile compiles the pattern and checks its groups name the columns
+func (f LineFormat) compile() (*regexp.Regexp, error) {
+	pattern, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, name := range pattern.SubexpNames()[1:] {
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid pattern: group %s is named twice", name)
+		}
+		seen[name] = true
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("the pattern needs a named group such as (?P<value>\\d+) for each column")
+	}
+	return pattern, nil
+}
+
+// ParseColumnStarts parses fixed-width column positions written as a comma
+// separated list such as "0, 12, 30"
+func ParseColumnStarts(text string) ([]int, error) {
+	var starts []int
+	for _, field := range strings.Split(text, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		start, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("column position %q is not a number", field)
+		}
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// LoadLineFormat reads a line format saved with SaveLineFormat
+func LoadLineFormat(path string) (LineFormat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LineFormat{}, err
+	}
+	var format LineFormat
+	if err := json.Unmarshal(data, &format); err != nil {
+		return LineFormat{}, fmt.Errorf("reading line format %s: %w", filepath.Base(path), err)
+	}
+	if err := format.Validate(); err != nil {
+		return LineFormat{}, fmt.Errorf("line format %s: %w", filepath.Base(path), err)
+	}
+	return format, nil
+}
+
+// SaveLineFormat writes a line format as JSON
+func SaveLineFormat(path string, format LineFormat) error {
+	data, err := json.MarshalIndent(format, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// openText opens a text file converted to UTF-8 from the named encoding, or
+// the detected one when name is empty
+func openText(filePath, name string) (*inputFile, *countingReader, io.Reader, error) {
+	if name == "" {
+		detected, err := DetectDialect(filePath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		name = detected.Encoding
+	}
+
+	counter := &countingReader{}
+	file, err := openInput(filePath, counter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	text, err := decodeText(file, name)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	return file, counter, text, nil
+}
+
+// openLines opens a text file to be read line by line in the given format
+func openLines(filePath string, format LineFormat) (rowSource, error) {
+	if err := format.Validate(); err != nil {
+		return rowSource{}, err
+	}
+	file, counter, text, err := openText(filePath, format.Encoding)
+	if err != nil {
+		return rowSource{}, err
+	}
+	lines := newDelimitedReader(text, Dialect{})
+	if err := lines.skipLines(format.SkipLines); err != nil {
+		file.Close()
+		return rowSource{}, err
+	}
+	rows := &lineRows{lines: lines, starts: format.Starts, headers: format.Headers}
+	if !format.fixedWidth() {
+		rows.pattern, _ = format.compile()
+		for i, name := range rows.pattern.SubexpNames() {
+			if name != "" {
+				rows.groups = append(rows.groups, i)
+				rows.headers = append(rows.headers, name)
+			}
+		}
+	}
+	return rowSource{
+		rows:    rows,
+		counter: counter,
+		size:    file.size,
+		close:   file.Close,
+	}, nil
+}
+
+// lineRows splits lines into fields. The header row comes from the format
+// when it names the columns and from the first line otherwise.
+type lineRows struct {
+	lines   *delimitedReader
+	starts  []int
+	pattern *regexp.Regexp
+	groups  []int // indices of the named groups of pattern
+	headers []string
+	started bool
+}
+
+// Line returns the line the last row was read from
+func (r *lineRows) Line() int {
+	return r.lines.line
+}
+
+func (r *lineRows) Read() ([]string, error) {
+	if !r.started && r.headers != nil {
+		r.started = true
+		return r.headers, nil
+	}
+
+	for {
+		line, err := r.lines.readLine()
+		if err != nil {
+			return nil, err
+		}
+		text := string(line)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if r.pattern == nil {
+			fields := splitFixed(text, r.starts)
+			if !r.started {
+				r.started = true
+				for i, field := range fields {
+					if field == "" {
+						fields[i] = "Column " + strconv.Itoa(i+1)
+					}
+				}
+			}
+			return fields, nil
+		}
+
+		match := r.pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		fields := make([]string, len(r.groups))
+		for i, group := range r.groups {
+			fields[i] = strings.TrimSpace(match[group])
+		}
+		return fields, nil
+	}
+}
+
+// splitFixed cuts a line into trimmed fields starting at the character
+// positions in starts. Text before the first position is left out.
+func splitFixed(line string, starts []int) []string {
+	runes := []rune(line)
+	fields := make([]string, len(starts))
+	for i, start := range starts {
+		end := len(runes)
+		if i+1 < len(starts) {
+			end = min(starts[i+1], end)
+		}
+		if start < end {
+			fields[i] = strings.TrimSpace(string(runes[start:end]))
+		}
+	}
+	return fields
+}
+
+// SuggestColumns suggests where the fixed-width columns of a text file start
+// from how its first lines after the skipped ones line up: a column starts
+// wherever text follows a position that is blank in every line.
+func SuggestColumns(filePath string, format LineFormat) ([]int, error) {
+	filePath, err := sampleFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, _, text, err := openText(filePath, format.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := newDelimitedReader(text, Dialect{})
+	if err := lines.skipLines(format.SkipLines); err != nil {
+		return nil, err
+	}
+	var blank []bool // whether each position is blank in all lines so far
+	for n := 0; n < suggestLines; {
+		line, err := lines.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		runes := []rune(string(line))
+		if strings.TrimSpace(string(runes)) == "" {
+			continue
+		}
+		n++
+
+		for len(blank) < len(runes) {
+			// Positions past the end of the earlier lines were blank there
+			blank = append(blank, true)
+		}
+		for i, r := range runes {
+			if !unicode.IsSpace(r) {
+				blank[i] = false
+			}
+		}
+	}
+
+	var starts []int
+	for i := range blank {
+		if !blank[i] && (i == 0 || blank[i-1]) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("the file has no text to find columns in")
+	}
+	return starts, nil
+}
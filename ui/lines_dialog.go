@@ -0,0 +1,210 @@
+﻿package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	fixedWidthMode = "Fixed-width columns"
+	patternMode    = "Regular expression"
+)
+
+// showLineFormat asks how the lines of a text file that is not delimited
+// split into fields, starting from the format last used for the file or
+// else columns suggested from how the lines align. Formats can be saved to
+// a file and loaded to read other files written the same way.
+func showLineFormat(window fyne.Window, filePath string, callback func(ReadOptions)) {
+	var format LineFormat
+	if previous := rememberedOptions(filePath).Lines; previous != nil {
+		format = *previous
+	} else if starts, err := SuggestColumns(filePath, format); err == nil {
+		format.Starts = starts
+	}
+
+	modeSelector := widget.NewRadioGroup([]string{fixedWidthMode, patternMode}, nil)
+	modeSelector.Horizontal, modeSelector.Required = true, true
+	encodingEntry := widget.NewSelectEntry(Encodings)
+	encodingEntry.SetPlaceHolder("Detected")
+	skipEntry := widget.NewEntry()
+	startsEntry := widget.NewEntry()
+	startsEntry.SetPlaceHolder("e.g. 0, 12, 30")
+	headersEntry := widget.NewEntry()
+	headersEntry.SetPlaceHolder("From the first line")
+	patternEntry := widget.NewEntry()
+	patternEntry.SetPlaceHolder(`e.g. ^(?P<time>\S+ \S+) (?P<level>\w+) .* took (?P<ms>\d+)ms`)
+
+	// show fills the form from a format
+	show := func(f LineFormat) {
+		encodingEntry.SetText(f.Encoding)
+		skipEntry.SetText(strconv.Itoa(f.SkipLines))
+		startsEntry.SetText(joinInts(f.Starts))
+		headersEntry.SetText(strings.Join(f.Headers, ", "))
+		patternEntry.SetText(f.Pattern)
+		if f.fixedWidth() {
+			modeSelector.SetSelected(fixedWidthMode)
+		} else {
+			modeSelector.SetSelected(patternMode)
+		}
+	}
+
+	// selection returns the format set in the form
+	selection := func() (LineFormat, error) {
+		var f LineFormat
+		var err error
+		if strings.TrimSpace(encodingEntry.Text) != "" {
+			if f.Encoding, err = ParseEncoding(encodingEntry.Text); err != nil {
+				return f, err
+			}
+		}
+		if f.SkipLines, err = strconv.Atoi(strings.TrimSpace(skipEntry.Text)); err != nil {
+			return f, fmt.Errorf("lines to skip must be a number of 0 or more")
+		}
+		if modeSelector.Selected == patternMode {
+			f.Pattern = patternEntry.Text
+		} else {
+			if f.Starts, err = ParseColumnStarts(startsEntry.Text); err != nil {
+				return f, err
+			}
+			if names := strings.TrimSpace(headersEntry.Text); names != "" {
+				for _, name := range strings.Split(names, ",") {
+					f.Headers = append(f.Headers, strings.TrimSpace(name))
+				}
+			}
+		}
+		return f, f.Validate()
+	}
+
+	status := widget.NewLabel("")
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	updatePreview := func() {
+		previewData = nil
+		defer preview.Refresh()
+
+		f, err := selection()
+		if err == nil {
+			var source rowSource
+			if source, err = openLines(filePath, f); err == nil {
+				previewData, err = readSourceRows(source, previewRows)
+			}
+		}
+		switch {
+		case err != nil:
+			status.SetText(err.Error())
+		case len(previewData) <= 1:
+			status.SetText("No lines match")
+		default:
+			status.SetText(fmt.Sprintf("Preview of the first %d rows", len(previewData)-1))
+		}
+	}
+
+	fixedItems := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Column starts", container.NewBorder(nil, nil, nil, widget.NewButton("Suggest", func() {
+				f, _ := selection()
+				starts, err := SuggestColumns(filePath, f)
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				startsEntry.SetText(joinInts(starts))
+			}), startsEntry)),
+			widget.NewFormItem("Column names", headersEntry),
+		),
+	)
+	patternItems := widget.NewForm(widget.NewFormItem("Pattern", patternEntry))
+	modeSelector.OnChanged = func(mode string) {
+		fixedItems.Hidden, patternItems.Hidden = mode != fixedWidthMode, mode != patternMode
+		updatePreview()
+	}
+	for _, entry := range []*widget.Entry{skipEntry, startsEntry, headersEntry, patternEntry} {
+		entry.OnChanged = func(string) { updatePreview() }
+	}
+	encodingEntry.OnChanged = func(string) { updatePreview() }
+	show(format)
+
+	loadButton := widget.NewButton("Load Format...", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			loaded, err := LoadLineFormat(reader.URI().Path())
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			show(loaded)
+		}, window)
+	})
+	saveButton := widget.NewButton("Save Format...", func() {
+		f, err := selection()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			writer.Close()
+			if err := SaveLineFormat(writer.URI().Path(), f); err != nil {
+				dialog.ShowError(err, window)
+			}
+		}, window)
+		saveDialog.SetFileName("line-format.json")
+		saveDialog.Show()
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem("Encoding", encodingEntry),
+		widget.NewFormItem("Skip lines", skipEntry),
+	)
+	top := container.NewVBox(
+		modeSelector, form, fixedItems, patternItems,
+		container.NewHBox(loadButton, saveButton), status,
+	)
+	content := container.NewBorder(top, nil, nil, nil, preview)
+
+	linesDialog := dialog.NewCustomConfirm(
+		"Line Format",
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			f, err := selection()
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			options := ReadOptions{Lines: &f}
+			rememberOptions(filePath, options)
+			callback(options)
+		},
+		window,
+	)
+
+	linesDialog.Resize(fyne.NewSize(750, 600))
+	linesDialog.Show()
+}
+
+// joinInts writes numbers as a comma separated list
+func joinInts(values []int) string {
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(texts, ", ")
+}
@@ -0,0 +1,138 @@
+﻿package ui
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testReport = `DAILY SALES REPORT
+Region     Day          Units
+North      2024-01-01      12
+South East 2024-01-02       7
+
+West       2024-01-03     130
+`
+
+func TestReadFixedWidth(t *testing.T) {
+	path := writeFile(t, "sales.prn", testReport)
+
+	format := LineFormat{SkipLines: 1}
+	starts, err := SuggestColumns(path, format)
+	if err != nil {
+		t.Fatalf("SuggestColumns failed: %v", err)
+	}
+	// The space inside "South East" is not blank in the other lines
+	if want := []int{0, 11, 24}; !reflect.DeepEqual(starts, want) {
+		t.Fatalf("suggested columns = %v, want %v", starts, want)
+	}
+
+	format.Starts = starts
+	data, err := readData(path, ReadOptions{Lines: &format})
+	if err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+	want := [][]string{
+		{"Region", "Day", "Units"},
+		{"North", "2024-01-01", "12"},
+		{"South East", "2024-01-02", "7"},
+		{"West", "2024-01-03", "130"},
+	}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+
+	// Named columns make the first line a record
+	format = LineFormat{SkipLines: 2, Starts: []int{0, 11, 24}, Headers: []string{"region", "day", "units"}}
+	data, err = readData(path, ReadOptions{Lines: &format})
+	if err != nil {
+		t.Fatalf("reading with names failed: %v", err)
+	}
+	if got := data.Records(); !reflect.DeepEqual(got[:2], [][]string{{"region", "day", "units"}, {"North", "2024-01-01", "12"}}) {
+		t.Errorf("records = %q", got)
+	}
+}
+
+func TestReadPattern(t *testing.T) {
+	path := writeFile(t, "app.log", strings.Join([]string{
+		"2024-01-01 10:00:00 INFO request /home took 12ms",
+		"2024-01-01 10:00:01 WARN cache miss",
+		"2024-01-01 10:00:02 INFO request /cart took 30ms",
+		"",
+	}, "\n"))
+	format := LineFormat{Pattern: `^(?P<time>\S+ \S+) (?P<level>\w+) request (\S+) took (?P<ms>\d+)ms`}
+
+	source, err := openRows(path, ReadOptions{Lines: &format})
+	if err != nil {
+		t.Fatalf("opening failed: %v", err)
+	}
+	defer source.close()
+	rows := source.rows.(*lineRows)
+	var got [][]string
+	var lines []int
+	for {
+		row, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading failed: %v", err)
+		}
+		got, lines = append(got, row), append(lines, rows.Line())
+	}
+	want := [][]string{
+		{"time", "level", "ms"},
+		{"2024-01-01 10:00:00", "INFO", "12"},
+		{"2024-01-01 10:00:02", "INFO", "30"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(lines[1:], []int{1, 3}) {
+		t.Errorf("lines = %v, want [1 3]", lines[1:])
+	}
+
+	if _, err := readData(path, ReadOptions{}); err == nil {
+		t.Errorf("reading a log without a line format succeeded")
+	}
+}
+
+func TestSaveLineFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "format.json")
+	format := LineFormat{Encoding: "windows-1252", SkipLines: 2, Starts: []int{0, 10}, Headers: []string{"a", "b"}}
+	if err := SaveLineFormat(path, format); err != nil {
+		t.Fatalf("SaveLineFormat failed: %v", err)
+	}
+	loaded, err := LoadLineFormat(path)
+	if err != nil {
+		t.Fatalf("LoadLineFormat failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, format) {
+		t.Errorf("loaded %+v, want %+v", loaded, format)
+	}
+
+	if _, err := LoadLineFormat(writeFile(t, "bad.json", `{"Pattern": "(\\d+)"}`)); err == nil {
+		t.Errorf("loaded a pattern without named groups")
+	}
+}
+
+func TestLineFormatValidate(t *testing.T) {
+	for _, format := range []LineFormat{
+		{},
+		{Starts: []int{0, 10, 10}},
+		{Starts: []int{0, 10}, Headers: []string{"a"}},
+		{Starts: []int{0}, Pattern: `(?P<a>.*)`},
+		{Pattern: `(?P<a>.*) (?P<a>.*)`},
+		{Pattern: `(?P<a>`},
+		{Starts: []int{0}, SkipLines: -1},
+	} {
+		if err := format.Validate(); err == nil {
+			t.Errorf("%+v is valid", format)
+		}
+	}
+	if starts, err := ParseColumnStarts("0, 12,30"); err != nil || !reflect.DeepEqual(starts, []int{0, 12, 30}) {
+		t.Errorf("ParseColumnStarts = %v, %v", starts, err)
+	}
+}
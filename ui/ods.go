@@ -0,0 +1,41 @@
+﻿package ui
+
+import (
+	"fmt"
+	"graph-viewer/ods"
+	"strings"
+)
+
+// odsWorkbook reads OpenDocument spreadsheets, which are loaded whole
+type odsWorkbook struct {
+	book *ods.Workbook
+}
+
+func (w odsWorkbook) Close() error {
+	return nil
+}
+
+// contents returns the sheets; ODS files are not searched for named ranges
+func (w odsWorkbook) contents() WorkbookContents {
+	return WorkbookContents{
+		Sheets: w.book.SheetNames(),
+		Active: w.book.Sheets[w.book.Active].Name,
+	}
+}
+
+// open returns the rows selected by options, which are already in memory
+func (w odsWorkbook) open(options ReadOptions) (rowSource, error) {
+	if options.Table != "" {
+		return rowSource{}, fmt.Errorf("ODS files are read by sheet, select a sheet and cell range instead")
+	}
+
+	sheet := w.book.Sheets[w.book.Active]
+	if options.Sheet != "" {
+		var ok bool
+		if sheet, ok = w.book.Sheet(options.Sheet); !ok {
+			return rowSource{}, fmt.Errorf("workbook has no sheet %q, sheets: %s", options.Sheet, strings.Join(w.book.SheetNames(), ", "))
+		}
+	}
+
+	return sheetRows(sheet.Rows, options.Range)
+}
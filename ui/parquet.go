@@ -0,0 +1,401 @@
+﻿package ui
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetValueBuffer is the number of values read from a column at a time
+const parquetValueBuffer = 1024
+
+// julianUnixEpoch is the Julian day of 1970-01-01, which INT96 timestamps
+// count their days from
+const julianUnixEpoch = 2440588
+
+// isParquet reports whether a file is an Apache Parquet file
+func isParquet(filePath string) bool {
+	return dataExt(filePath) == ".parquet"
+}
+
+// ParquetColumn describes a column of a Parquet file
+type ParquetColumn struct {
+	Name string // path of the column, e.g. customer.city
+	Type string // physical or logical type, e.g. INT64 or DECIMAL(10,2)
+}
+
+// parquetLeaf is a column of a Parquet file holding values, as opposed to
+// the groups nesting them
+type parquetLeaf struct {
+	name   string
+	column *parquet.Column
+}
+
+// parquetLeaves returns the columns holding values below column, named by
+// their path after prefix. Lists name their elements after the list, so a
+// list of tags is the column tags rather than tags.list.element.
+func parquetLeaves(column *parquet.Column, prefix string) []parquetLeaf {
+	if column.Leaf() {
+		return []parquetLeaf{{name: prefix, column: column}}
+	}
+
+	children := column.Columns()
+	list := false
+	if logical := column.Type().LogicalType(); logical != nil && logical.List != nil && len(children) == 1 && !children[0].Leaf() {
+		children, list = children[0].Columns(), true
+	}
+
+	var leaves []parquetLeaf
+	for _, child := range children {
+		name := child.Name()
+		switch {
+		case list && len(children) == 1:
+			name = prefix
+		case prefix != "":
+			name = prefix + "." + name
+		}
+		leaves = append(leaves, parquetLeaves(child, name)...)
+	}
+	return leaves
+}
+
+// openParquetFile opens a Parquet file and returns its value columns.
+// Parquet is read at random, so a compressed file is read into memory.
+func openParquetFile(filePath string) (io.Closer, *parquet.File, []parquetLeaf, error) {
+	if isPacked(filePath) {
+		data, err := readInput(filePath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		parquetFile, err := parquet.OpenFile(data, data.Size(), parquet.SkipPageIndex(true), parquet.SkipBloomFilters(true))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("reading Parquet file: %w", err)
+		}
+		return io.NopCloser(data), parquetFile, parquetLeaves(parquetFile.Root(), ""), nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	parquetFile, err := parquet.OpenFile(file, stat.Size(), parquet.SkipPageIndex(true), parquet.SkipBloomFilters(true))
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, fmt.Errorf("reading Parquet file: %w", err)
+	}
+	return file, parquetFile, parquetLeaves(parquetFile.Root(), ""), nil
+}
+
+// listParquetColumns returns the columns of a Parquet file and its number
+// of rows
+func listParquetColumns(filePath string) ([]ParquetColumn, int64, error) {
+	file, parquetFile, leaves, err := openParquetFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	columns := make([]ParquetColumn, len(leaves))
+	for i, leaf := range leaves {
+		columns[i] = ParquetColumn{Name: leaf.name, Type: leaf.column.Type().String()}
+	}
+	return columns, parquetFile.NumRows(), nil
+}
+
+// openParquet opens a Parquet file to be read as rows. Only the column
+// chunks of options.Columns are read, or all columns when it is empty.
+// Logical types are written the way the data model infers them: timestamps
+// and dates in ISO form, decimals with their scale applied and dictionary
+// encoded strings as their text.
+func openParquet(filePath string, options ReadOptions) (rowSource, error) {
+	file, parquetFile, leaves, err := openParquetFile(filePath)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	selected := leaves
+	if len(options.Columns) > 0 {
+		wanted := make(map[string]bool, len(options.Columns))
+		for _, name := range options.Columns {
+			wanted[name] = true
+		}
+		selected = nil
+		for _, leaf := range leaves {
+			if wanted[leaf.name] {
+				selected = append(selected, leaf)
+				delete(wanted, leaf.name)
+			}
+		}
+		for _, name := range options.Columns {
+			if wanted[name] {
+				file.Close()
+				names := make([]string, len(leaves))
+				for i, leaf := range leaves {
+					names[i] = leaf.name
+				}
+				return rowSource{}, fmt.Errorf("column %q not found, available columns: %s", name, strings.Join(names, ", "))
+			}
+		}
+	}
+	if len(selected) == 0 {
+		file.Close()
+		return rowSource{}, fmt.Errorf("file contains no columns")
+	}
+
+	rows := &parquetRows{total: parquetFile.NumRows()}
+	for _, leaf := range selected {
+		rows.headers = append(rows.headers, leaf.name)
+		rows.columns = append(rows.columns, &parquetColumnReader{
+			name:  leaf.name,
+			pages: leaf.column.Pages(),
+			text:  parquetText(leaf.column.Type()),
+		})
+	}
+	return rowSource{
+		rows:  rows,
+		total: int(rows.total) + 1,
+		close: func() error {
+			for _, column := range rows.columns {
+				column.close()
+			}
+			return file.Close()
+		},
+	}, nil
+}
+
+// parquetRows assembles rows from the columns of a Parquet file, which are
+// stored one after the other
+type parquetRows struct {
+	headers []string
+	columns []*parquetColumnReader
+	total   int64
+	read    int64
+}
+
+func (r *parquetRows) Read() ([]string, error) {
+	if r.headers != nil {
+		headers := r.headers
+		r.headers = nil
+		return headers, nil
+	}
+	if r.read == r.total {
+		return nil, io.EOF
+	}
+
+	r.read++
+	row := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		cell, err := column.cell()
+		if err == io.EOF {
+			return nil, fmt.Errorf("column %s ends before row %d", column.name, r.read)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading column %s: %w", column.name, err)
+		}
+		row[i] = cell
+	}
+	return row, nil
+}
+
+// parquetColumnReader reads the values of a column page by page
+type parquetColumnReader struct {
+	name   string
+	pages  parquet.Pages
+	page   parquet.Page
+	values parquet.ValueReader
+	buffer []parquet.Value
+	next   int
+	text   func(parquet.Value) string
+}
+
+// peek returns the next value without consuming it
+func (c *parquetColumnReader) peek() (parquet.Value, error) {
+	for c.next == len(c.buffer) {
+		if c.values == nil {
+			page, err := c.pages.ReadPage()
+			if err != nil {
+				return parquet.Value{}, err
+			}
+			c.page, c.values = page, page.Values()
+		}
+		if c.buffer == nil {
+			c.buffer = make([]parquet.Value, parquetValueBuffer)
+		}
+		n, err := c.values.ReadValues(c.buffer[:cap(c.buffer)])
+		c.buffer, c.next = c.buffer[:n], 0
+		if n == 0 && err == io.EOF {
+			parquet.Release(c.page)
+			c.page, c.values = nil, nil
+		} else if n == 0 && err != nil {
+			return parquet.Value{}, err
+		}
+	}
+	return c.buffer[c.next], nil
+}
+
+// cell returns the text of the next row's value. The values of repeated
+// columns are joined like JSON arrays; nulls give empty cells.
+func (c *parquetColumnReader) cell() (string, error) {
+	var parts []string
+	for first := true; ; first = false {
+		value, err := c.peek()
+		if err == io.EOF && !first {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !first && value.RepetitionLevel() == 0 {
+			break
+		}
+		c.next++
+		if !value.IsNull() {
+			parts = append(parts, c.text(value))
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return strings.Join(parts, arraySeparator), nil
+}
+
+func (c *parquetColumnReader) close() {
+	if c.page != nil {
+		parquet.Release(c.page)
+	}
+	c.pages.Close()
+}
+
+// parquetText returns a function writing values of the type as text
+func parquetText(t parquet.Type) func(parquet.Value) string {
+	kind := t.Kind()
+	logical := t.LogicalType()
+	if logical == nil {
+		logical = &format.LogicalType{}
+	}
+
+	switch {
+	case logical.Timestamp != nil:
+		unit, layout := parquetTimeUnit(logical.Timestamp.Unit), "2006-01-02 15:04:05.999999999"
+		if logical.Timestamp.IsAdjustedToUTC {
+			layout = time.RFC3339Nano
+		}
+		return func(v parquet.Value) string {
+			return time.Unix(0, 0).UTC().Add(time.Duration(v.Int64()) * unit).Format(layout)
+		}
+	case logical.Date != nil:
+		return func(v parquet.Value) string {
+			return time.Unix(int64(v.Int32())*86400, 0).UTC().Format(time.DateOnly)
+		}
+	case logical.Time != nil:
+		unit := parquetTimeUnit(logical.Time.Unit)
+		return func(v parquet.Value) string {
+			ticks := v.Int64()
+			if kind == parquet.Int32 {
+				ticks = int64(v.Int32())
+			}
+			return time.Time{}.Add(time.Duration(ticks) * unit).Format("15:04:05.999999999")
+		}
+	case logical.Decimal != nil:
+		scale := int(logical.Decimal.Scale)
+		return func(v parquet.Value) string {
+			unscaled := new(big.Int)
+			switch kind {
+			case parquet.Int32:
+				unscaled.SetInt64(int64(v.Int32()))
+			case parquet.Int64:
+				unscaled.SetInt64(v.Int64())
+			default:
+				setTwosComplement(unscaled, v.ByteArray())
+			}
+			return formatDecimal(unscaled, scale)
+		}
+	case logical.Integer != nil && !logical.Integer.IsSigned:
+		return func(v parquet.Value) string {
+			if kind == parquet.Int32 {
+				return strconv.FormatUint(uint64(v.Uint32()), 10)
+			}
+			return strconv.FormatUint(v.Uint64(), 10)
+		}
+	case logical.UUID != nil:
+		return func(v parquet.Value) string {
+			b := v.ByteArray()
+			if len(b) != 16 {
+				return hex.EncodeToString(b)
+			}
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+		}
+	}
+
+	switch kind {
+	case parquet.Boolean:
+		return func(v parquet.Value) string { return strconv.FormatBool(v.Boolean()) }
+	case parquet.Int32:
+		return func(v parquet.Value) string { return strconv.FormatInt(int64(v.Int32()), 10) }
+	case parquet.Int64:
+		return func(v parquet.Value) string { return strconv.FormatInt(v.Int64(), 10) }
+	case parquet.Int96:
+		// Legacy timestamps: nanoseconds of the day and the Julian day
+		return func(v parquet.Value) string {
+			i := v.Int96()
+			nanos := int64(i[1])<<32 | int64(i[0])
+			days := int64(i[2]) - julianUnixEpoch
+			return time.Unix(days*86400, nanos).UTC().Format("2006-01-02 15:04:05.999999999")
+		}
+	case parquet.Float:
+		return func(v parquet.Value) string { return strconv.FormatFloat(float64(v.Float()), 'f', -1, 32) }
+	case parquet.Double:
+		return func(v parquet.Value) string { return strconv.FormatFloat(v.Double(), 'f', -1, 64) }
+	default:
+		return func(v parquet.Value) string { return string(v.ByteArray()) }
+	}
+}
+
+// parquetTimeUnit returns the duration of one tick of a time unit
+func parquetTimeUnit(unit format.TimeUnit) time.Duration {
+	switch {
+	case unit.Nanos != nil:
+		return time.Nanosecond
+	case unit.Micros != nil:
+		return time.Microsecond
+	default:
+		return time.Millisecond
+	}
+}
+
+// setTwosComplement sets n to the big-endian two's complement integer in b
+func setTwosComplement(n *big.Int, b []byte) {
+	n.SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+}
+
+// formatDecimal writes unscaled / 10^scale with scale fraction digits
+func formatDecimal(unscaled *big.Int, scale int) string {
+	if scale <= 0 {
+		return unscaled.String()
+	}
+	digits := new(big.Int).Abs(unscaled).String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	text := digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	if unscaled.Sign() < 0 {
+		text = "-" + text
+	}
+	return text
+}
@@ -0,0 +1,86 @@
+﻿package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showParquetColumns asks which columns of a Parquet file to read, starting
+// from the columns last read from the file. Only the chosen columns are read
+// from disk, which keeps wide files fast.
+func showParquetColumns(window fyne.Window, filePath string, callback func(ReadOptions)) {
+	columns, rows, err := listParquetColumns(filePath)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	labels := make([]string, len(columns))
+	names := make(map[string]string, len(columns))
+	for i, column := range columns {
+		labels[i] = fmt.Sprintf("%s (%s)", column.Name, column.Type)
+		names[labels[i]] = column.Name
+	}
+
+	remembered := make(map[string]bool)
+	for _, name := range rememberedOptions(filePath).Columns {
+		remembered[name] = true
+	}
+	var selected []string
+	for i, column := range columns {
+		if len(remembered) == 0 || remembered[column.Name] {
+			selected = append(selected, labels[i])
+		}
+	}
+	if len(selected) == 0 {
+		selected = labels
+	}
+
+	status := widget.NewLabel("")
+	updateStatus := func(checked []string) {
+		status.SetText(fmt.Sprintf("%d of %d columns selected, %d rows", len(checked), len(columns), rows))
+	}
+	columnSelector := widget.NewCheckGroup(labels, updateStatus)
+	columnSelector.SetSelected(selected)
+	updateStatus(selected)
+
+	selectAll := widget.NewButton("Select All", func() { columnSelector.SetSelected(labels) })
+	selectNone := widget.NewButton("Select None", func() { columnSelector.SetSelected(nil) })
+	content := container.NewBorder(
+		container.NewVBox(status, container.NewHBox(selectAll, selectNone)), nil, nil, nil,
+		container.NewVScroll(columnSelector),
+	)
+
+	parquetDialog := dialog.NewCustomConfirm(
+		"Parquet Columns",
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if len(columnSelector.Selected) == 0 {
+				dialog.ShowError(fmt.Errorf("select at least one column"), window)
+				return
+			}
+
+			var options ReadOptions
+			if len(columnSelector.Selected) < len(columns) {
+				for _, label := range columnSelector.Selected {
+					options.Columns = append(options.Columns, names[label])
+				}
+			}
+			rememberOptions(filePath, options)
+			callback(options)
+		},
+		window,
+	)
+
+	parquetDialog.Resize(fyne.NewSize(500, 500))
+	parquetDialog.Show()
+}
@@ -0,0 +1,115 @@
+﻿package ui
+
+import (
+	"graph-viewer/dataset"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type parquetSale struct {
+	Region   string   `parquet:"region,dict"`
+	Sold     int64    `parquet:"sold,timestamp(millisecond:utc)"`
+	Day      int32    `parquet:"day,date"`
+	Price    int64    `parquet:"price,decimal(2:10)"`
+	Units    *int32   `parquet:"units,optional"`
+	Tags     []string `parquet:"tags,list"`
+	Customer struct {
+		City string `parquet:"city"`
+	} `parquet:"customer"`
+}
+
+// writeParquet writes the sales to a Parquet file, two rows per row group
+func writeParquet(t *testing.T, sales []parquetSale) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sales.parquet")
+	if err := parquet.WriteFile(path, sales, parquet.MaxRowsPerRowGroup(2)); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadParquetMapsLogicalTypes(t *testing.T) {
+	units := int32(4)
+	sales := []parquetSale{
+		{Region: "North", Sold: 1704067200500, Day: 19723, Price: 1999, Units: &units, Tags: []string{"new", "online"}},
+		{Region: "South", Sold: 1704153600000, Day: 19724, Price: -5},
+		{Region: "North", Sold: 1704240000000, Day: 19725, Price: 100000, Units: &units, Tags: []string{"store"}},
+	}
+	sales[0].Customer.City = "Oslo"
+	path := writeParquet(t, sales)
+
+	data, err := readData(path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("readData failed: %v", err)
+	}
+	want := [][]string{
+		{"region", "sold", "day", "price", "units", "tags", "customer.city"},
+		{"North", "2024-01-01T00:00:00.5Z", "2024-01-01", "19.99", "4", "new, online", "Oslo"},
+		{"South", "2024-01-02T00:00:00Z", "2024-01-02", "-0.05", "", "", ""},
+		{"North", "2024-01-03T00:00:00Z", "2024-01-03", "1000.00", "4", "store", ""},
+	}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	for i, want := range map[int]dataset.SemanticType{1: dataset.TypeDateTime, 2: dataset.TypeDateTime, 3: dataset.TypeNumeric, 4: dataset.TypeInteger} {
+		if got := data.Columns[i].Type; got != want {
+			t.Errorf("column %s inferred as %s, want %s", data.Columns[i].Name, got, want)
+		}
+	}
+
+	// Only the selected columns are read, in file order
+	data, err = readData(path, ReadOptions{Columns: []string{"price", "region"}, Limit: 2})
+	if err != nil {
+		t.Fatalf("reading selected columns failed: %v", err)
+	}
+	want = [][]string{{"region", "price"}, {"North", "19.99"}, {"South", "-0.05"}}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("selected records = %q, want %q", got, want)
+	}
+
+	if _, err := readData(path, ReadOptions{Columns: []string{"revenue"}}); err == nil || !strings.Contains(err.Error(), "available columns") {
+		t.Errorf("unknown column returned %v", err)
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		bytes []byte
+		scale int
+		want  string
+	}{
+		{[]byte{0x04, 0xD2}, 2, "12.34"},
+		{[]byte{0xFF, 0x85}, 3, "-0.123"},
+		{[]byte{0x07}, 0, "7"},
+	}
+	for _, tt := range tests {
+		n := new(big.Int)
+		setTwosComplement(n, tt.bytes)
+		if got := formatDecimal(n, tt.scale); got != tt.want {
+			t.Errorf("formatDecimal(%x, %d) = %s, want %s", tt.bytes, tt.scale, got, tt.want)
+		}
+	}
+}
+
+func TestParquetTextWritesFloatsInFull(t *testing.T) {
+	tests := []struct {
+		typ   parquet.Type
+		value parquet.Value
+		want  string
+	}{
+		{parquet.DoubleType, parquet.DoubleValue(2500000), "2500000"},
+		{parquet.DoubleType, parquet.DoubleValue(0.000015), "0.000015"},
+		{parquet.FloatType, parquet.FloatValue(2500000), "2500000"},
+		{parquet.FloatType, parquet.FloatValue(1.5), "1.5"},
+	}
+	for _, tt := range tests {
+		if got := parquetText(tt.typ)(tt.value); got != tt.want {
+			t.Errorf("parquetText(%s)(%v) = %s, want %s", tt.typ, tt.value, got, tt.want)
+		}
+	}
+}
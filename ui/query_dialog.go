@@ -0,0 +1,97 @@
+﻿package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showQueryEditor lets the user browse the tables of an SQLite database or
+// write an SQL query, starting from the query last run on the file. Picking
+// a table fills in a query reading all of it, and Run previews the first
+// rows of the result.
+func showQueryEditor(window fyne.Window, filePath string, callback func(ReadOptions)) {
+	tables, err := listSQLiteTables(filePath)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	queryEntry := widget.NewMultiLineEntry()
+	queryEntry.SetPlaceHolder("SELECT ... FROM ...")
+	queryEntry.Wrapping = fyne.TextWrapWord
+	switch previous := rememberedOptions(filePath); {
+	case previous.Query != "":
+		queryEntry.SetText(previous.Query)
+	case len(tables) > 0:
+		queryEntry.SetText(tableQuery(tables[0]))
+	}
+
+	status := widget.NewLabel("")
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	runQuery := func() {
+		previewData = nil
+		defer preview.Refresh()
+
+		source, err := openSQLiteRows(filePath, ReadOptions{Query: queryEntry.Text})
+		if err == nil {
+			previewData, err = readSourceRows(source, previewRows)
+		}
+		switch {
+		case err != nil:
+			status.SetText(err.Error())
+		case len(previewData) <= 1:
+			status.SetText("The query returns no rows")
+		default:
+			status.SetText(fmt.Sprintf("Preview of the first %d rows", len(previewData)-1))
+		}
+	}
+
+	tableList := widget.NewList(
+		func() int { return len(tables) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(tables[id])
+		},
+	)
+	tableList.OnSelected = func(id widget.ListItemID) {
+		queryEntry.SetText(tableQuery(tables[id]))
+		runQuery()
+	}
+
+	editor := container.NewBorder(nil, container.NewHBox(widget.NewButton("Run", runQuery), status), nil, nil, queryEntry)
+	results := container.NewVSplit(editor, preview)
+	results.Offset = 0.3
+	browser := container.NewHSplit(container.NewBorder(widget.NewLabel("Tables"), nil, nil, nil, tableList), results)
+	browser.Offset = 0.25
+	runQuery()
+
+	queryDialog := dialog.NewCustomConfirm(
+		"SQLite Query",
+		"Open",
+		"Cancel",
+		browser,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if strings.TrimSpace(queryEntry.Text) == "" {
+				dialog.ShowError(fmt.Errorf("choose a table or write a query"), window)
+				return
+			}
+			options := ReadOptions{Query: queryEntry.Text}
+			rememberOptions(filePath, options)
+			callback(options)
+		},
+		window,
+	)
+
+	queryDialog.Resize(fyne.NewSize(800, 550))
+	queryDialog.Show()
+}
@@ -0,0 +1,176 @@
+﻿package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graph-viewer/dataset"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// largeFileSize is the size above which the user chooses which rows of
+	// a file to read before it is read
+	largeFileSize = 100 << 20
+
+	// progressDelay is how long a read runs before its progress is shown
+	progressDelay = 300 * time.Millisecond
+
+	readAllRows   = "All rows"
+	readFirstRows = "First rows"
+	readSample    = "Random sample"
+	readSummary   = "Summary by group"
+)
+
+// isLargeFile reports whether a file is too large to read whole without asking
+func isLargeFile(filePath string) bool {
+	size, err := inputSize(filePath)
+	return err == nil && size > largeFileSize
+}
+
+// showRowSelection asks which rows of a large file to read: all of them, the
+// first ones, a random sample or a summary of each group of rows
+func showRowSelection(window fyne.Window, filePath string, options ReadOptions, callback func(ReadOptions)) {
+	headers, err := readHeaders(filePath, options)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	size, err := inputSize(filePath)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	countEntry := widget.NewEntry()
+	countEntry.SetText("100000")
+	groupSelector := widget.NewSelect(append([]string{noColumn}, headers...), nil)
+	groupSelector.SetSelected(headers[0])
+	var functions []string
+	for _, f := range dataset.AggregateFunctions {
+		functions = append(functions, f.String())
+	}
+	functionSelector := widget.NewSelect(functions, nil)
+	functionSelector.SetSelected(dataset.AggregateSum.String())
+
+	modes := widget.NewRadioGroup([]string{readAllRows, readFirstRows, readSample, readSummary}, func(mode string) {
+		if mode == readFirstRows || mode == readSample {
+			countEntry.Enable()
+		} else {
+			countEntry.Disable()
+		}
+		if mode == readSummary {
+			groupSelector.Enable()
+			functionSelector.Enable()
+		} else {
+			groupSelector.Disable()
+			functionSelector.Disable()
+		}
+	})
+	modes.Required = true
+	modes.SetSelected(readSample)
+
+	explanation := widget.NewLabel(fmt.Sprintf(
+		"%s is %d MB. Reading all rows needs at least as much memory; a sample, the first rows or\n"+
+			"a summary of the rows of each group keep the memory use bounded.", filepath.Base(filePath), size>>20))
+
+	form := widget.NewForm(
+		widget.NewFormItem("Read", modes),
+		widget.NewFormItem("Rows", countEntry),
+		widget.NewFormItem("Group by", groupSelector),
+		widget.NewFormItem("Combine with", functionSelector),
+	)
+
+	rowDialog := dialog.NewCustomConfirm(
+		"Large File",
+		"Read",
+		"Cancel",
+		container.NewVBox(explanation, form),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			switch modes.Selected {
+			case readFirstRows, readSample:
+				count, err := strconv.Atoi(strings.TrimSpace(countEntry.Text))
+				if err != nil || count <= 0 {
+					dialog.ShowError(fmt.Errorf("the number of rows must be a positive number"), window)
+					return
+				}
+				if modes.Selected == readFirstRows {
+					options.Limit = count
+				} else {
+					options.Sample = count
+				}
+			case readSummary:
+				function, _ := dataset.ParseAggregateFunction(functionSelector.Selected)
+				aggregation := dataset.Aggregation{Function: function}
+				if groupSelector.Selected != noColumn {
+					aggregation.GroupBy = groupSelector.Selected
+				}
+				options.Aggregate = &aggregation
+			}
+			callback(options)
+		},
+		window,
+	)
+	rowDialog.Show()
+}
+
+// readWithProgress reads a file in the background while showing how far it
+// has come, and calls done with the data unless reading failed or the user
+// canceled it. Rows repaired to fit the header are listed first.
+func readWithProgress(window fyne.Window, filePath string, options ReadOptions, done func(*dataset.Dataset)) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel("Reading " + filepath.Base(filePath))
+	progressDialog := dialog.NewCustom("Reading File", "Cancel", container.NewVBox(status, bar), window)
+	progressDialog.SetOnClosed(cancel)
+
+	// Files that read quickly do not flash a dialog
+	show := time.AfterFunc(progressDelay, progressDialog.Show)
+
+	options.Progress = func(progress ReadProgress) {
+		if fraction := progress.Fraction(); fraction >= 0 {
+			bar.SetValue(fraction)
+		}
+		if progress.TotalRows > 0 {
+			status.SetText(fmt.Sprintf("Reading %s: %d of %d rows", filepath.Base(filePath), progress.Rows, progress.TotalRows))
+		} else {
+			status.SetText(fmt.Sprintf("Reading %s: %d rows", filepath.Base(filePath), progress.Rows))
+		}
+	}
+
+	report := &RepairReport{}
+	options.OnRepair = report.Add
+
+	go func() {
+		data, err := readDataContext(ctx, filePath, options)
+		show.Stop()
+		progressDialog.Hide()
+
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if report.Len() > 0 {
+			showRepairReport(window, report, func() { done(data) })
+			return
+		}
+		done(data)
+	}()
+}
@@ -0,0 +1,143 @@
+﻿package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RowRepair chooses what happens to rows with more or fewer fields than the
+// header
+type RowRepair int
+
+const (
+	RepairFail     RowRepair = iota // reject the file at the first such row
+	RepairTruncate                  // pad short rows with empty fields and drop the extra fields of long rows
+	RepairMerge                     // pad short rows and join the extra fields of long rows into their last field
+)
+
+// RowRepairs lists the policies in the order offered to users
+var RowRepairs = []RowRepair{RepairFail, RepairTruncate, RepairMerge}
+
+func (r RowRepair) String() string {
+	switch r {
+	case RepairTruncate:
+		return "truncate"
+	case RepairMerge:
+		return "merge"
+	default:
+		return "fail"
+	}
+}
+
+// ParseRowRepair returns the policy with the given name
+func ParseRowRepair(name string) (RowRepair, error) {
+	for _, r := range RowRepairs {
+		if strings.EqualFold(r.String(), strings.TrimSpace(name)) {
+			return r, nil
+		}
+	}
+	return RepairFail, fmt.Errorf("unknown row repair %q, use fail, truncate or merge", name)
+}
+
+// RepairAction is what was done to a row to fit the header
+type RepairAction int
+
+const (
+	Padded RepairAction = iota
+	Truncated
+	Merged
+)
+
+func (a RepairAction) String() string {
+	switch a {
+	case Truncated:
+		return "truncated"
+	case Merged:
+		return "merged"
+	default:
+		return "padded"
+	}
+}
+
+// Repair records a row changed to fit the header
+type Repair struct {
+	Line     int // source row, or the line the row starts on in text files
+	Action   RepairAction
+	Fields   int // fields the row had
+	Expected int // fields in the header
+}
+
+// maxRepairs is the number of repairs a report lists; further repairs are
+// only counted
+const maxRepairs = 10000
+
+// RepairReport collects the repairs made while reading a file. Its Add
+// method can be passed as ReadOptions.OnRepair.
+type RepairReport struct {
+	Repairs []Repair // the first repairs, at most maxRepairs
+	counts  [3]int   // repairs per action
+}
+
+// Add records a repair
+func (r *RepairReport) Add(repair Repair) {
+	r.counts[repair.Action]++
+	if len(r.Repairs) < maxRepairs {
+		r.Repairs = append(r.Repairs, repair)
+	}
+}
+
+// Len returns the number of repairs made
+func (r *RepairReport) Len() int {
+	return r.counts[Padded] + r.counts[Truncated] + r.counts[Merged]
+}
+
+// Summary describes the repairs, e.g. "12 rows padded, 3 rows truncated"
+func (r *RepairReport) Summary() string {
+	var parts []string
+	for _, action := range []RepairAction{Padded, Truncated, Merged} {
+		switch n := r.counts[action]; n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 row "+action.String())
+		default:
+			parts = append(parts, fmt.Sprintf("%d rows %s", n, action))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteCSV writes the listed repairs as CSV with a header row
+func (r *RepairReport) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"Line", "Repair", "Fields", "Expected"})
+	for _, repair := range r.Repairs {
+		out.Write([]string{
+			strconv.Itoa(repair.Line), repair.Action.String(),
+			strconv.Itoa(repair.Fields), strconv.Itoa(repair.Expected),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// repairRow makes row fit a header of width fields under the policy,
+// joining merged fields with separator. ok is false when the row cannot
+// be used.
+func repairRow(row []string, width int, policy RowRepair, separator string) (repaired []string, action RepairAction, ok bool) {
+	switch {
+	case policy == RepairFail:
+		return nil, 0, false
+	case len(row) < width:
+		padded := make([]string, width)
+		copy(padded, row)
+		return padded, Padded, true
+	case policy == RepairMerge:
+		merged := append(row[:width-1:width-1], strings.Join(row[width-1:], separator))
+		return merged, Merged, true
+	default:
+		return row[:width], Truncated, true
+	}
+}
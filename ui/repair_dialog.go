@@ -0,0 +1,47 @@
+﻿package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showRepairReport tells which rows were repaired to fit the header, lets the
+// user export the list and calls next when the dialog is closed
+func showRepairReport(window fyne.Window, report *RepairReport, next func()) {
+	lines := widget.NewList(
+		func() int { return len(report.Repairs) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			repair := report.Repairs[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("Line %d: %s, %d fields instead of %d",
+				repair.Line, repair.Action, repair.Fields, repair.Expected))
+		},
+	)
+
+	summary := "Rows that did not match the header were repaired: " + report.Summary() + "."
+	if report.Len() > len(report.Repairs) {
+		summary += fmt.Sprintf("\nThe first %d are listed.", len(report.Repairs))
+	}
+
+	export := widget.NewButton("Export List...", func() {
+		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := report.WriteCSV(writer); err != nil {
+				dialog.ShowError(err, window)
+			}
+		}, window)
+	})
+
+	content := container.NewBorder(widget.NewLabel(summary), export, nil, nil, lines)
+	reportDialog := dialog.NewCustom("Repaired Rows", "Continue", content, window)
+	reportDialog.SetOnClosed(next)
+	reportDialog.Resize(fyne.NewSize(500, 400))
+	reportDialog.Show()
+}
@@ -0,0 +1,70 @@
+﻿package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDataRepairsRaggedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ragged.csv")
+	text := "Name,Age,Note\nAnn,30,ok\nBob,41\nCid,25,late,again\n"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readData(path, ReadOptions{}); err == nil || !strings.Contains(err.Error(), "row 3") {
+		t.Errorf("strict read returned %v, want an error for row 3", err)
+	}
+
+	tests := []struct {
+		policy  RowRepair
+		note    string
+		summary string
+	}{
+		{RepairTruncate, "late", "1 row padded, 1 row truncated"},
+		{RepairMerge, "late,again", "1 row padded, 1 row merged"},
+	}
+	for _, tt := range tests {
+		report := &RepairReport{}
+		data, err := readData(path, ReadOptions{Ragged: tt.policy, OnRepair: report.Add})
+		if err != nil {
+			t.Fatalf("%s: readData failed: %v", tt.policy, err)
+		}
+		if got := data.Column("Note").Raw; !reflect.DeepEqual(got, []string{"ok", "", tt.note}) {
+			t.Errorf("%s: notes = %q", tt.policy, got)
+		}
+		if got := report.Summary(); got != tt.summary {
+			t.Errorf("%s: summary = %q, want %q", tt.policy, got, tt.summary)
+		}
+		if report.Repairs[0].Line != 3 || report.Repairs[1].Line != 4 {
+			t.Errorf("%s: repairs = %+v, want lines 3 and 4", tt.policy, report.Repairs)
+		}
+	}
+}
+
+func TestRepairReportWriteCSV(t *testing.T) {
+	report := &RepairReport{}
+	report.Add(Repair{Line: 7, Action: Truncated, Fields: 4, Expected: 3})
+
+	var b strings.Builder
+	if err := report.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Line,Repair,Fields,Expected\n7,truncated,4,3\n"; b.String() != want {
+		t.Errorf("WriteCSV = %q, want %q", b.String(), want)
+	}
+}
+
+func TestParseRowRepair(t *testing.T) {
+	for _, r := range RowRepairs {
+		if got, err := ParseRowRepair(r.String()); err != nil || got != r {
+			t.Errorf("ParseRowRepair(%s) = %s, %v", r, got, err)
+		}
+	}
+	if _, err := ParseRowRepair("ignore"); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,183 @@
+﻿package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	sheetPrefix = "Sheet: "
+	tablePrefix = "Table: "
+
+	// previewRows is the number of rows shown in the workbook preview
+	previewRows = 10
+)
+
+// showSheetSelection asks which sheet, table or cell range of a workbook to
+// read, starting from the selection last used for the file
+func showSheetSelection(window fyne.Window, filePath string, callback func(ReadOptions)) {
+	book, err := openWorkbook(filePath)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	contents := book.contents()
+	var sources []string
+	for _, sheet := range contents.Sheets {
+		sources = append(sources, sheetPrefix+sheet)
+	}
+	for _, table := range contents.Tables {
+		sources = append(sources, tablePrefix+table)
+	}
+
+	sourceSelector := widget.NewSelect(sources, nil)
+	rangeEntry := widget.NewEntry()
+	rangeEntry.SetPlaceHolder("Whole sheet, or a range such as A1:D20")
+	status := widget.NewLabel("")
+
+	var previewData [][]string
+	preview := newPreviewTable(&previewData)
+
+	selection := func() ReadOptions {
+		if table, ok := strings.CutPrefix(sourceSelector.Selected, tablePrefix); ok {
+			return ReadOptions{Table: table}
+		}
+		return ReadOptions{
+			Sheet: strings.TrimPrefix(sourceSelector.Selected, sheetPrefix),
+			Range: strings.TrimSpace(rangeEntry.Text),
+		}
+	}
+
+	updatePreview := func() {
+		rows, err := readRows(book, selection(), previewRows)
+		switch {
+		case err != nil:
+			previewData = nil
+			status.SetText(err.Error())
+		case len(rows) == 0:
+			previewData = nil
+			status.SetText("The selection is empty")
+		default:
+			previewData = rows
+			status.SetText(fmt.Sprintf("Preview of the first %d rows", len(rows)))
+		}
+		preview.Refresh()
+	}
+
+	sourceSelector.OnChanged = func(source string) {
+		// Tables and named ranges define their own cells
+		if strings.HasPrefix(source, tablePrefix) {
+			rangeEntry.Disable()
+		} else {
+			rangeEntry.Enable()
+		}
+		updatePreview()
+	}
+	rangeEntry.OnChanged = func(string) { updatePreview() }
+
+	// Start from the previous selection when it still exists
+	previous := rememberedOptions(filePath)
+	rangeEntry.SetText(previous.Range)
+	switch {
+	case previous.Table != "" && contains(sources, tablePrefix+previous.Table):
+		sourceSelector.SetSelected(tablePrefix + previous.Table)
+	case previous.Sheet != "" && contains(sources, sheetPrefix+previous.Sheet):
+		sourceSelector.SetSelected(sheetPrefix + previous.Sheet)
+	default:
+		sourceSelector.SetSelected(sheetPrefix + contents.Active)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Read", sourceSelector),
+		widget.NewFormItem("Cells", rangeEntry),
+	)
+	content := container.NewBorder(container.NewVBox(form, status), nil, nil, nil, preview)
+
+	sheetDialog := dialog.NewCustomConfirm(
+		"Select Sheet",
+		"Open",
+		"Cancel",
+		content,
+		func(confirmed bool) {
+			book.Close()
+			if !confirmed {
+				return
+			}
+
+			options := selection()
+			rememberOptions(filePath, options)
+			callback(options)
+		},
+		window,
+	)
+
+	sheetDialog.Resize(fyne.NewSize(700, 500))
+	sheetDialog.Show()
+}
+
+// newPreviewTable creates a table showing the rows in data, which may be
+// ragged
+func newPreviewTable(data *[][]string) *widget.Table {
+	return widget.NewTable(
+		func() (int, int) {
+			columns := 0
+			for _, row := range *data {
+				columns = max(columns, len(row))
+			}
+			return len(*data), columns
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, cell fyne.CanvasObject) {
+			text := ""
+			if id.Col < len((*data)[id.Row]) {
+				text = (*data)[id.Row][id.Col]
+			}
+			cell.(*widget.Label).SetText(text)
+		},
+	)
+}
+
+// readOptionsKey is the preference holding the selection made for a file
+func readOptionsKey(filePath string) string {
+	return "readOptions:" + filePath
+}
+
+// rememberedOptions returns the selection last used for a file
+func rememberedOptions(filePath string) ReadOptions {
+	var options ReadOptions
+	if app := fyne.CurrentApp(); app != nil {
+		if saved := app.Preferences().String(readOptionsKey(filePath)); saved != "" {
+			if err := json.Unmarshal([]byte(saved), &options); err != nil {
+				return ReadOptions{}
+			}
+		}
+	}
+	return options
+}
+
+// rememberOptions stores the selection made for a file
+func rememberOptions(filePath string, options ReadOptions) {
+	app := fyne.CurrentApp()
+	if app == nil {
+		return
+	}
+	if saved, err := json.Marshal(options); err == nil {
+		app.Preferences().SetString(readOptionsKey(filePath), string(saved))
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
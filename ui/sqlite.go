@@ -0,0 +1,234 @@
+﻿package ui
+
+import (
+	"database/sql"
+	"fmt"
+	"graph-viewer/dataset"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the sqlite driver
+)
+
+// isSQLite reports whether a file is an SQLite database
+func isSQLite(filePath string) bool {
+	switch dataExt(filePath) {
+	case ".db", ".sqlite", ".sqlite3":
+		return true
+	default:
+		return false
+	}
+}
+
+// openSQLite opens an SQLite database read-only
+func openSQLite(filePath string) (*sql.DB, error) {
+	if isPacked(filePath) {
+		return nil, fmt.Errorf("SQLite databases are read in place, decompress or extract the database first")
+	}
+	// A URI names the file by its absolute path, which starts with a slash
+	// also on Windows, as in file:///C:/data/sales.db
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	location := url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}
+	db, err := sql.Open("sqlite", location.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	return db, nil
+}
+
+// listSQLiteTables returns the tables and views of an SQLite database
+func listSQLiteTables(filePath string) ([]string, error) {
+	db, err := openSQLite(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return sqliteTables(db)
+}
+
+func sqliteTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("listing tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// tableQuery returns the query reading all rows of a table
+func tableQuery(table string) string {
+	return `SELECT * FROM "` + strings.ReplaceAll(table, `"`, `""`) + `"`
+}
+
+// openSQLiteRows runs options.Query against an SQLite database, or reads
+// options.Table, or the only table when the database has one. The types
+// the database declares for the result columns are kept instead of being
+// inferred from the values.
+func openSQLiteRows(filePath string, options ReadOptions) (rowSource, error) {
+	db, err := openSQLite(filePath)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	query := strings.TrimSpace(options.Query)
+	if query == "" {
+		table := options.Table
+		if table == "" {
+			tables, err := sqliteTables(db)
+			if err != nil {
+				db.Close()
+				return rowSource{}, err
+			}
+			if len(tables) != 1 {
+				db.Close()
+				return rowSource{}, fmt.Errorf("the database has %d tables, choose one or write a query: %s", len(tables), strings.Join(tables, ", "))
+			}
+			table = tables[0]
+		}
+		query = tableQuery(table)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		db.Close()
+		return rowSource{}, fmt.Errorf("running query: %w", err)
+	}
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		db.Close()
+		return rowSource{}, err
+	}
+
+	reader := &sqliteRows{rows: rows, values: make([]any, len(columns))}
+	types := make(map[int]dataset.SemanticType)
+	for i, column := range columns {
+		reader.headers = append(reader.headers, column.Name())
+		if t, ok := sqliteType(column.DatabaseTypeName()); ok {
+			types[i] = t
+		}
+	}
+	return rowSource{
+		rows:  reader,
+		types: types,
+		close: func() error {
+			rows.Close()
+			return db.Close()
+		},
+	}, nil
+}
+
+// sqliteType maps a declared column type onto a semantic type following
+// SQLite's type affinity rules, so INTEGER, BIGINT and INT8 are all
+// integers. Columns without a declared type, such as computed ones, and
+// BLOB columns are inferred.
+func sqliteType(declared string) (dataset.SemanticType, bool) {
+	declared = strings.ToUpper(declared)
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if strings.Contains(declared, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case declared == "" || has("BLOB"):
+		return dataset.TypeText, false
+	case has("BOOL"):
+		return dataset.TypeBoolean, true
+	case has("DATE", "TIME"):
+		return dataset.TypeDateTime, true
+	case has("INT"):
+		return dataset.TypeInteger, true
+	case has("CHAR", "CLOB", "TEXT"):
+		return dataset.TypeText, true
+	default:
+		// REAL, FLOAT, DOUBLE, DECIMAL and NUMERIC
+		return dataset.TypeNumeric, true
+	}
+}
+
+// sqliteRows reads the rows of a query result
+type sqliteRows struct {
+	rows    *sql.Rows
+	headers []string
+	values  []any
+}
+
+func (r *sqliteRows) Read() ([]string, error) {
+	if r.headers != nil {
+		headers := r.headers
+		r.headers = nil
+		return headers, nil
+	}
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	pointers := make([]any, len(r.values))
+	for i := range r.values {
+		pointers[i] = &r.values[i]
+	}
+	if err := r.rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	row := make([]string, len(r.values))
+	for i, value := range r.values {
+		row[i] = sqliteText(value)
+	}
+	return row, nil
+}
+
+// sqliteText writes a value read from SQLite as text
+func sqliteText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format("2006-01-02 15:04:05.999999999")
+	default:
+		return fmt.Sprint(v)
+	}
+}
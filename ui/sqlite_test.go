@@ -0,0 +1,139 @@
+﻿package ui
+
+import (
+	"database/sql"
+	"graph-viewer/dataset"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSQLite creates a database running the statements
+func writeSQLite(t *testing.T, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sales.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	return path
+}
+
+func TestReadSQLite(t *testing.T) {
+	path := writeSQLite(t,
+		`CREATE TABLE sales (region TEXT, zip TEXT, sold DATE, units INTEGER, price REAL)`,
+		`INSERT INTO sales VALUES ('North', '01234', '2024-01-01', 3, 1.5), ('South', '05678', '2024-01-02', NULL, 2), ('North', '09999', '2024-01-03', 4, 2500000.5)`,
+	)
+
+	data, err := readData(path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("reading the only table failed: %v", err)
+	}
+	want := [][]string{
+		{"region", "zip", "sold", "units", "price"},
+		{"North", "01234", "2024-01-01", "3", "1.5"},
+		{"South", "05678", "2024-01-02", "", "2"},
+		{"North", "09999", "2024-01-03", "4", "2500000.5"},
+	}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	// Declared types win over what the values look like
+	for name, want := range map[string]dataset.SemanticType{"zip": dataset.TypeText, "sold": dataset.TypeDateTime, "units": dataset.TypeInteger, "price": dataset.TypeNumeric} {
+		if col := data.Column(name); col.Type != want || !col.Declared {
+			t.Errorf("column %s is %s (declared %t), want declared %s", name, col.Type, col.Declared, want)
+		}
+	}
+
+	data, err = readData(path, ReadOptions{Query: "SELECT region, SUM(units) AS units FROM sales GROUP BY region ORDER BY region"})
+	if err != nil {
+		t.Fatalf("running a query failed: %v", err)
+	}
+	want = [][]string{{"region", "units"}, {"North", "7"}, {"South", ""}}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("query records = %q, want %q", got, want)
+	}
+
+	if _, err := readData(path, ReadOptions{Query: "SELECT * FROM missing"}); err == nil {
+		t.Errorf("expected an error for a missing table")
+	}
+}
+
+func TestReadSQLiteKeepsDeclaredText(t *testing.T) {
+	path := writeSQLite(t,
+		`CREATE TABLE events (day TEXT, active TEXT, code VARCHAR(5), units INTEGER)`,
+		`INSERT INTO events VALUES ('2024-01-01', 'yes', '101', 3), ('2024-01-02', 'no', '102', 4)`,
+	)
+
+	data, err := readData(path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+	for _, name := range []string{"day", "active", "code"} {
+		if col := data.Column(name); col.Type != dataset.TypeText || col.Kind != dataset.KindString || !col.Declared {
+			t.Errorf("column %s is %s (declared %t), want declared text", name, col.Type, col.Declared)
+		}
+	}
+
+	// Dates stored as text are read as dates once the type is overridden
+	day, err := data.SetType(data.Index("day"), dataset.TypeDateTime)
+	if err != nil {
+		t.Fatalf("overriding the declared text failed: %v", err)
+	}
+	if col := day.Column("day"); col.Kind != dataset.KindTime {
+		t.Errorf("day overridden as %s, want time", col.Kind)
+	}
+}
+
+func TestReadSQLiteByRelativePath(t *testing.T) {
+	path := writeSQLite(t,
+		`CREATE TABLE sales (region TEXT, units INTEGER)`,
+		`INSERT INTO sales VALUES ('North', 3)`,
+	)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Dir(path)); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	data, err := readData(filepath.Base(path), ReadOptions{})
+	if err != nil {
+		t.Fatalf("reading by a relative path failed: %v", err)
+	}
+	if got, want := data.Records(), [][]string{{"region", "units"}, {"North", "3"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+}
+
+func TestReadSQLiteChoosesTable(t *testing.T) {
+	path := writeSQLite(t,
+		`CREATE TABLE a (x INTEGER)`, `INSERT INTO a VALUES (1)`,
+		`CREATE TABLE "b ""quoted""" (y INTEGER)`, `INSERT INTO "b ""quoted""" VALUES (2)`,
+	)
+
+	tables, err := listSQLiteTables(path)
+	if err != nil || !reflect.DeepEqual(tables, []string{"a", `b "quoted"`}) {
+		t.Fatalf("tables = %q, %v", tables, err)
+	}
+	if _, err := readData(path, ReadOptions{}); err == nil || !strings.Contains(err.Error(), "choose one") {
+		t.Errorf("reading a database of two tables returned %v", err)
+	}
+	data, err := readData(path, ReadOptions{Table: tables[1]})
+	if err != nil {
+		t.Fatalf("reading a quoted table failed: %v", err)
+	}
+	if got := data.Records(); !reflect.DeepEqual(got, [][]string{{"y"}, {"2"}}) {
+		t.Errorf("records = %q", got)
+	}
+}
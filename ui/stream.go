@@ -0,0 +1,290 @@
+﻿package ui
+
+import (
+	"context"
+	"fmt"
+	"graph-viewer/dataset"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// DefaultMemoryBudget bounds the cell text kept in memory while reading when
+// ReadOptions sets no budget. The typed columns built from it take about as
+// much again.
+const DefaultMemoryBudget int64 = 512 << 20
+
+// progressInterval is the least time between two progress reports
+const progressInterval = 100 * time.Millisecond
+
+// ReadProgress reports how far reading a file has come
+type ReadProgress struct {
+	Rows      int   // rows read so far
+	TotalRows int   // rows expected, 0 when unknown
+	Bytes     int64 // bytes read so far
+	Size      int64 // size of the file, 0 when unknown
+}
+
+// Fraction returns the share of the file read, from the bytes read or else
+// the rows read, or -1 when neither total is known
+func (p ReadProgress) Fraction() float64 {
+	switch {
+	case p.Size > 0:
+		return min(float64(p.Bytes)/float64(p.Size), 1)
+	case p.TotalRows > 0:
+		return min(float64(p.Rows)/float64(p.TotalRows), 1)
+	default:
+		return -1
+	}
+}
+
+// BudgetError reports a read that needed more memory than its budget
+type BudgetError struct {
+	Budget int64
+	Rows   int // rows read when the budget ran out
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("the data needs more than %d MB of memory after %d rows; read the first rows, a sample or a summary instead",
+		e.Budget>>20, e.Rows)
+}
+
+// rowReader yields the rows of a file one at a time and io.EOF after the last
+type rowReader interface {
+	Read() ([]string, error)
+}
+
+// lineReader is a rowReader that knows the line each row starts on
+type lineReader interface {
+	rowReader
+	Line() int
+}
+
+// rowSource is a file being read row by row
+type rowSource struct {
+	rows    rowReader
+	counter *countingReader // bytes read from the file, nil when unknown
+	size    int64
+	total   int // rows expected including the header, 0 when unknown
+	close   func() error
+
+	sparse    bool   // rows leave out their empty cells at the end
+	separator string // joins the fields of merged rows
+
+	// types are the column types declared by the source by column index;
+	// the others are inferred from the values
+	types map[int]dataset.SemanticType
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// recordRows reads rows that are already in memory
+type recordRows struct {
+	records [][]string
+}
+
+func (r *recordRows) Read() ([]string, error) {
+	if len(r.records) == 0 {
+		return nil, io.EOF
+	}
+	row := r.records[0]
+	r.records = r.records[1:]
+	return row, nil
+}
+
+// collectRows reads the header row and then the rows selected by options:
+// all of them, the first options.Limit, a random sample of options.Sample
+// rows or their aggregation. The rows kept must fit options.MemoryBudget.
+// Reading stops with the context's error when ctx is canceled.
+func collectRows(ctx context.Context, source rowSource, options ReadOptions) (*dataset.Dataset, error) {
+	headers, err := source.rows.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("insufficient rows in file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("file contains no headers")
+	}
+
+	budget := options.MemoryBudget
+	if budget <= 0 {
+		budget = DefaultMemoryBudget
+	}
+
+	var aggregator *dataset.Aggregator
+	if options.Aggregate != nil {
+		if aggregator, err = dataset.NewAggregator(headers, *options.Aggregate); err != nil {
+			return nil, err
+		}
+	}
+	var sample *reservoir
+	if options.Sample > 0 && aggregator == nil {
+		sample = newReservoir(options.Sample)
+	}
+
+	var (
+		rows       [][]string
+		sourceRows []int
+		used       int64
+		reported   time.Time
+	)
+	report := func(n int) {
+		if options.Progress == nil {
+			return
+		}
+		progress := ReadProgress{Rows: n, TotalRows: max(source.total-1, 0), Size: source.size}
+		if source.counter != nil {
+			progress.Bytes = source.counter.n
+		}
+		options.Progress(progress)
+		reported = time.Now()
+	}
+
+	lines, numbered := source.rows.(lineReader)
+	n := 0
+	for ; options.Limit <= 0 || n < options.Limit; n++ {
+		if n%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if time.Since(reported) >= progressInterval {
+				report(n)
+			}
+		}
+
+		row, err := source.rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Text files number rows by line, others count the header as row 1
+		sourceRow := n + 2
+		if numbered {
+			sourceRow = lines.Line()
+		}
+		if len(row) < len(headers) && source.sparse {
+			row = append(row, make([]string, len(headers)-len(row))...)
+		}
+		if len(row) != len(headers) {
+			repaired, action, ok := repairRow(row, len(headers), options.Ragged, source.separator)
+			if !ok {
+				return nil, fmt.Errorf("row %d has %d columns, expected %d", sourceRow, len(row), len(headers))
+			}
+			if options.OnRepair != nil {
+				options.OnRepair(Repair{Line: sourceRow, Action: action, Fields: len(row), Expected: len(headers)})
+			}
+			row = repaired
+		}
+
+		switch {
+		case aggregator != nil:
+			aggregator.Add(row, sourceRow)
+			used = aggregator.Size()
+		case sample != nil:
+			sample.add(row, sourceRow)
+			used = sample.size
+		default:
+			rows = append(rows, row)
+			sourceRows = append(sourceRows, sourceRow)
+			used += dataset.RowSize(row)
+		}
+		if used > budget {
+			return nil, &BudgetError{Budget: budget, Rows: n + 1}
+		}
+	}
+	report(n)
+
+	switch {
+	case aggregator != nil:
+		if n == 0 {
+			return nil, fmt.Errorf("insufficient rows in file")
+		}
+		return aggregator.Dataset()
+	case sample != nil:
+		rows, sourceRows = sample.sorted()
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("insufficient rows in file")
+	}
+	data, err := dataset.New(headers, rows, sourceRows)
+	if err != nil {
+		return nil, err
+	}
+	return declareTypes(data, source.types), nil
+}
+
+// declareTypes applies the column types declared by the source of data.
+// Columns whose values do not fit their declared type keep the inferred one.
+// Declared text stays text even when the values look like dates, numbers or
+// booleans; the user overrides the type to read them otherwise.
+func declareTypes(data *dataset.Dataset, types map[int]dataset.SemanticType) *dataset.Dataset {
+	for index, t := range types {
+		if declared, err := data.DeclareType(index, t); err == nil {
+			data = declared
+		}
+	}
+	return data
+}
+
+// reservoir keeps a uniform random sample of the rows added to it. The seed
+// is fixed so reading the same file again gives the same sample.
+type reservoir struct {
+	rows       [][]string
+	sourceRows []int
+	n          int
+	seen       int
+	size       int64
+	random     *rand.Rand
+}
+
+// newReservoir returns a reservoir of n rows. Its slices grow as rows are
+// added, so a sample larger than the file costs only the rows read.
+func newReservoir(n int) *reservoir {
+	return &reservoir{n: n, random: rand.New(rand.NewPCG(1, 2))}
+}
+
+func (r *reservoir) add(row []string, sourceRow int) {
+	r.seen++
+	if len(r.rows) < r.n {
+		r.rows = append(r.rows, row)
+		r.sourceRows = append(r.sourceRows, sourceRow)
+		r.size += dataset.RowSize(row)
+		return
+	}
+	if i := r.random.IntN(r.seen); i < len(r.rows) {
+		r.size += dataset.RowSize(row) - dataset.RowSize(r.rows[i])
+		r.rows[i], r.sourceRows[i] = row, sourceRow
+	}
+}
+
+// sorted returns the sampled rows in source order
+func (r *reservoir) sorted() ([][]string, []int) {
+	order := make([]int, len(r.rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return r.sourceRows[order[a]] < r.sourceRows[order[b]] })
+
+	rows := make([][]string, len(order))
+	sourceRows := make([]int, len(order))
+	for i, j := range order {
+		rows[i], sourceRows[i] = r.rows[j], r.sourceRows[j]
+	}
+	return rows, sourceRows
+}
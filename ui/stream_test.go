@@ -0,0 +1,118 @@
+﻿package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"graph-viewer/dataset"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeCSV creates a CSV file with a header and n rows of regions and values
+func writeCSV(t *testing.T, n int) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("Region,Value\n")
+	regions := []string{"North", "South", "East"}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%s,%d\n", regions[i%len(regions)], i)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadDataSelectsRows(t *testing.T) {
+	path := writeCSV(t, 5000)
+
+	data, err := readData(path, ReadOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("reading the first rows failed: %v", err)
+	}
+	if data.Len() != 10 || data.SourceRows[9] != 11 {
+		t.Errorf("first rows: got %d rows ending at source row %d, want 10 ending at 11", data.Len(), data.SourceRows[data.Len()-1])
+	}
+
+	data, err = readData(path, ReadOptions{Sample: 100})
+	if err != nil {
+		t.Fatalf("sampling failed: %v", err)
+	}
+	if data.Len() != 100 {
+		t.Fatalf("sample has %d rows, want 100", data.Len())
+	}
+	for i := 1; i < data.Len(); i++ {
+		if data.SourceRows[i] <= data.SourceRows[i-1] {
+			t.Fatalf("sampled rows are not in source order: %v", data.SourceRows)
+		}
+	}
+	if data.SourceRows[data.Len()-1] < 2500 {
+		t.Errorf("sample does not spread over the file, last row %d", data.SourceRows[data.Len()-1])
+	}
+	again, _ := readData(path, ReadOptions{Sample: 100})
+	if !reflect.DeepEqual(data.SourceRows, again.SourceRows) {
+		t.Errorf("sampling the same file twice gave different rows")
+	}
+}
+
+func TestReadDataAggregates(t *testing.T) {
+	path := writeCSV(t, 6)
+
+	data, err := readData(path, ReadOptions{Aggregate: &dataset.Aggregation{GroupBy: "Region", Function: dataset.AggregateSum}})
+	if err != nil {
+		t.Fatalf("aggregating failed: %v", err)
+	}
+	want := [][]string{{"Region", "Value (sum)"}, {"North", "3"}, {"South", "5"}, {"East", "7"}}
+	if got := data.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("aggregated records = %v, want %v", got, want)
+	}
+}
+
+func TestReadDataMemoryBudget(t *testing.T) {
+	path := writeCSV(t, 1000)
+
+	_, err := readData(path, ReadOptions{MemoryBudget: 4096})
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("reading beyond the budget returned %v, want a *BudgetError", err)
+	}
+
+	// A small sample fits the same budget
+	if _, err := readData(path, ReadOptions{MemoryBudget: 4096, Sample: 10}); err != nil {
+		t.Errorf("sample within the budget failed: %v", err)
+	}
+
+	// A sample larger than the file only holds the rows read
+	data, err := readData(path, ReadOptions{MemoryBudget: 1 << 20, Sample: 1 << 30})
+	if err != nil {
+		t.Fatalf("oversized sample failed: %v", err)
+	}
+	if data.Len() != 1000 {
+		t.Errorf("oversized sample has %d rows, want all 1000", data.Len())
+	}
+}
+
+func TestReadDataProgressAndCancel(t *testing.T) {
+	path := writeCSV(t, 3000)
+
+	var last ReadProgress
+	options := ReadOptions{Progress: func(p ReadProgress) { last = p }}
+	if _, err := readData(path, options); err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+	if last.Rows != 3000 || last.Fraction() != 1 {
+		t.Errorf("final progress = %+v, want all 3000 rows and the whole file", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := readDataContext(ctx, path, ReadOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled read returned %v", err)
+	}
+}
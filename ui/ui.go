@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"graph-viewer/charts"
+	"graph-viewer/dataset"
 	"graph-viewer/logger"
 
 	"fyne.io/fyne/v2"
@@ -17,10 +18,11 @@ func SetupUI(window fyne.Window) {
 	// Verify embedded files at startup
 	verifyEmbeddedFiles()
 
-	label := widget.NewLabel("Upload a CSV/XLS file to display an interactive graph.")
+	label := widget.NewLabel("Upload a CSV, TSV, XLSX, XLS, ODS, JSON, NDJSON or Parquet file, a log or fixed-width report or an SQLite database to display an interactive graph. Files may be compressed with gzip or zstd or in a zip archive.")
 	fileButton := widget.NewButton("Select File", createFileHandler(window))
+	folderButton := widget.NewButton("Combine Files in Folder", createFolderHandler(window))
 
-	content := container.NewVBox(label, fileButton)
+	content := container.NewVBox(label, fileButton, folderButton)
 	window.SetContent(content)
 	window.Resize(fyne.NewSize(800, 400))
 }
@@ -38,54 +40,117 @@ func createFileHandler(window fyne.Window) func() {
 				return // User canceled
 			}
 
-			filePath := reader.URI().Path()
 			defer reader.Close()
+			openFile(window, reader.URI().Path(), func(data *dataset.Dataset) {
+				showJoinStep(window, data, chartData(window))
+			})
+		}, window)
+	}
+}
 
-			headers, rows, err := readData(filePath)
+// createFolderHandler returns a function to handle folder selection, which
+// reads the files of the folder as one
+func createFolderHandler(window fyne.Window) func() {
+	return func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
 			if err != nil {
+				logger.LogErrorWithTrace(fmt.Errorf("folder selection error: %v", err))
 				dialog.ShowError(err, window)
 				return
 			}
+			if folder == nil {
+				return // User canceled
+			}
 
-			ShowHeaderSelection(headers, window, func(graphType string, xAxis string, yAxis string, zAxis string, limits map[string]int) {
-				handleGraphGeneration(window, graphType, xAxis, yAxis, zAxis, headers, rows, limits)
+			showCombineOptions(window, folder.Path(), func(path string, options ReadOptions) {
+				openData(window, path, options, func(data *dataset.Dataset) {
+					showJoinStep(window, data, chartData(window))
+				})
 			})
 		}, window)
 	}
 }
 
+// openFile asks how to read a file as its format needs, then reads it and
+// passes the data to loaded. Archives first ask which of their files to
+// open, workbooks which sheet, table or range to read, text files how they
+// are delimited or split into fields, JSON files how to flatten arrays,
+// Parquet files which columns to read and databases which table or query.
+func openFile(window fyne.Window, filePath string, loaded func(*dataset.Dataset)) {
+	open := func(options ReadOptions) {
+		openData(window, filePath, options, loaded)
+	}
+	switch {
+	case isArchive(filePath):
+		showArchiveEntries(window, filePath, func(entryPath string) {
+			openFile(window, entryPath, loaded)
+		})
+	case isWorkbook(filePath):
+		showSheetSelection(window, filePath, open)
+	case isLog(filePath) || rememberedOptions(filePath).Lines != nil:
+		showLineFormat(window, filePath, open)
+	case isDelimited(filePath):
+		showImportOptions(window, filePath, open)
+	case isJSON(filePath):
+		showJSONOptions(window, filePath, open)
+	case isParquet(filePath):
+		showParquetColumns(window, filePath, open)
+	case isSQLite(filePath):
+		showQueryEditor(window, filePath, open)
+	default:
+		open(ReadOptions{})
+	}
+}
+
+// openData reads the selected file and passes the data to loaded. Large
+// files first ask which of their rows to read.
+func openData(window fyne.Window, filePath string, options ReadOptions, loaded func(*dataset.Dataset)) {
+	read := func(options ReadOptions) {
+		readWithProgress(window, filePath, options, loaded)
+	}
+
+	if isLargeFile(filePath) {
+		showRowSelection(window, filePath, options, read)
+		return
+	}
+	read(options)
+}
+
+// chartData returns a function asking which graph to create from data
+func chartData(window fyne.Window) func(*dataset.Dataset) {
+	return func(data *dataset.Dataset) {
+		ShowHeaderSelection(data, window, func(spec charts.ChartSpec, data *dataset.Dataset, limits map[string]int) {
+			handleGraphGeneration(window, spec, data, limits)
+		})
+	}
+}
+
 // handleGraphGeneration processes the selected data and generates the graph
 func handleGraphGeneration(
 	window fyne.Window,
-	graphType, xAxis, yAxis, zAxis string,
-	headers []string,
-	rows [][]string,
+	spec charts.ChartSpec,
+	data *dataset.Dataset,
 	limits map[string]int,
 ) {
-	logger.LogWithTrace(fmt.Sprintf("Graph Type: %s, X-Axis: %s, Y-Axis: %s, Z-Axis: %s, Limits: %v",
-		graphType, xAxis, yAxis, zAxis, limits))
-
-	var (
-		selectedData [][]string
-		err          error
-	)
-
-	if graphType == "Scatter3D" || graphType == "Bar3D" {
-		selectedData, err = extractSelectedData3D(xAxis, yAxis, zAxis, headers, rows, limits)
-	} else {
-		selectedData, err = extractSelectedData(xAxis, yAxis, headers, rows, limits)
-	}
+	logger.LogWithTrace(fmt.Sprintf("Graph Type: %s, Columns: %v, Stacked: %v, Limits: %v",
+		spec.Type, spec.Columns, spec.Stacked, limits))
 
+	spec.Diagnostics = &charts.Diagnostics{}
+	selectedData, err := ExtractGraphData(spec, data, limits)
 	if err != nil {
 		logger.LogErrorWithTrace(fmt.Errorf("error extracting selected data: %v", err))
 		dialog.ShowError(err, window)
 		return
 	}
 
-	graphFile, err := charts.GenerateGraph(selectedData, graphType)
+	graphFile, err := charts.RenderToFile(spec, selectedData, charts.DefaultOutput)
 	if err != nil {
 		logger.LogErrorWithTrace(fmt.Errorf("error generating graph: %v", err))
 		dialog.ShowError(err, window)
+		if spec.Diagnostics.Len() > 0 {
+			// The rows left out tell why no data was left to chart
+			showDiagnostics(window, spec.Diagnostics)
+		}
 		return
 	}
 
@@ -93,6 +158,11 @@ func handleGraphGeneration(
 		logger.LogErrorWithTrace(fmt.Errorf("error displaying chart in browser: %v", err))
 		dialog.ShowError(errors.New("failed to open chart in browser. Check logs for details."), window)
 	}
+
+	if spec.Diagnostics.Len() > 0 {
+		logger.LogWithTrace(fmt.Sprintf("Rows left out or changed: %s", spec.Diagnostics.Summary()))
+		showDiagnostics(window, spec.Diagnostics)
+	}
 }
 
 // verifyEmbeddedFiles checks if embedded files are present
@@ -108,14 +178,12 @@ func verifyEmbeddedFiles() {
 		logger.LogWithTrace(fmt.Sprintf("- %s", entry.Name()))
 	}
 
-	expectedGifs := []string{
-		"Bar.gif", "Heatmap.gif", "Scatter3D.gif", "Bar3D.gif",
-		"Pie.gif", "Sankey.gif", "ThemeRiver.gif",
-	}
-
-	for _, gifName := range expectedGifs {
-		if _, err := embeddedExamples.ReadFile(fmt.Sprintf("examples/%s", gifName)); err != nil {
-			logger.LogErrorWithTrace(fmt.Errorf("failed to read embedded file %s: %v", gifName, err))
+	for _, chartType := range charts.Types() {
+		if chartType.Preview() == "" {
+			continue
+		}
+		if _, err := globalImageCache.getImage(chartType.Preview()); err != nil {
+			logger.LogErrorWithTrace(fmt.Errorf("failed to read preview for %s: %v", chartType.Name(), err))
 		}
 	}
 }
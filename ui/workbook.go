@@ -0,0 +1,293 @@
+﻿package ui
+
+import (
+	"bytes"
+	"fmt"
+	"graph-viewer/ods"
+	"graph-viewer/xls"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// WorkbookContents lists what can be read from a workbook
+type WorkbookContents struct {
+	Sheets []string
+	Active string
+	Tables []string // tables and defined names referring to a cell range
+}
+
+// workbook is an open spreadsheet file whose sheets can be read in parts
+type workbook interface {
+	contents() WorkbookContents
+
+	// open starts reading the rows selected by options one at a time. Empty
+	// rows at the end of the selection are left out.
+	open(options ReadOptions) (rowSource, error)
+
+	Close() error
+}
+
+// isWorkbook reports whether a file is a spreadsheet with sheets to choose from
+func isWorkbook(filePath string) bool {
+	ext := dataExt(filePath)
+	return ext == ".xlsx" || ext == ".xls" || ext == ".ods"
+}
+
+// openWorkbook opens an XLSX, XLS or ODS file. Workbooks are read at
+// random, so a compressed workbook is read into memory.
+func openWorkbook(filePath string) (workbook, error) {
+	if isPacked(filePath) {
+		data, err := readInput(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return readWorkbookData(filePath, data)
+	}
+
+	switch dataExt(filePath) {
+	case ".xlsx":
+		file, err := excelize.OpenFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return xlsxWorkbook{file}, nil
+	case ".xls":
+		book, err := xls.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return xlsWorkbook{book}, nil
+	case ".ods":
+		book, err := ods.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return odsWorkbook{book}, nil
+	default:
+		return nil, fmt.Errorf("%s is not a workbook", filepath.Base(filePath))
+	}
+}
+
+// readWorkbookData reads a workbook in memory, of the format its name tells
+func readWorkbookData(filePath string, data *bytes.Reader) (workbook, error) {
+	switch dataExt(filePath) {
+	case ".xlsx":
+		file, err := excelize.OpenReader(data)
+		if err != nil {
+			return nil, err
+		}
+		return xlsxWorkbook{file}, nil
+	case ".xls":
+		book, err := xls.Read(data)
+		if err != nil {
+			return nil, err
+		}
+		return xlsWorkbook{book}, nil
+	case ".ods":
+		book, err := ods.Read(data, data.Size())
+		if err != nil {
+			return nil, err
+		}
+		return odsWorkbook{book}, nil
+	default:
+		return nil, fmt.Errorf("%s is not a workbook", filepath.Base(filePath))
+	}
+}
+
+// readWorkbook reads the rows selected by options from an XLSX, XLS or ODS
+// file
+func readWorkbook(filePath string, options ReadOptions) ([][]string, error) {
+	book, err := openWorkbook(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer book.Close()
+
+	return readRows(book, options, 0)
+}
+
+// readRows reads the rows selected by options, at most limit rows when limit
+// is positive
+func readRows(book workbook, options ReadOptions, limit int) ([][]string, error) {
+	source, err := book.open(options)
+	if err != nil {
+		return nil, err
+	}
+	return readSourceRows(source, limit)
+}
+
+// readSourceRows reads at most limit rows from source, all rows when limit is
+// not positive, and closes it
+func readSourceRows(source rowSource, limit int) ([][]string, error) {
+	defer source.close()
+
+	var data [][]string
+	for limit <= 0 || len(data) < limit {
+		row, err := source.rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, row)
+	}
+	return data, nil
+}
+
+// openWorkbookRows opens a workbook to read the rows selected by options,
+// closing the workbook with the rows
+func openWorkbookRows(filePath string, options ReadOptions) (rowSource, error) {
+	book, err := openWorkbook(filePath)
+	if err != nil {
+		return rowSource{}, err
+	}
+	source, err := book.open(options)
+	if err != nil {
+		book.Close()
+		return rowSource{}, err
+	}
+
+	closeRows := source.close
+	source.close = func() error {
+		closeRows()
+		return book.Close()
+	}
+	return source, nil
+}
+
+// sheetRows returns the rows of a sheet loaded whole within the A1-style
+// cell range reference, all rows when it is empty
+func sheetRows(rows [][]string, reference string) (rowSource, error) {
+	bounds := cellRange{firstRow: 1, firstCol: 1}
+	if reference != "" {
+		var err error
+		if bounds, err = parseCellRange(reference); err != nil {
+			return rowSource{}, err
+		}
+	}
+
+	var data [][]string
+	for row := bounds.firstRow; row <= len(rows); row++ {
+		if bounds.lastRow > 0 && row > bounds.lastRow {
+			break
+		}
+		data = append(data, bounds.cells(rows[row-1]))
+	}
+	return rowSource{
+		rows:   &trimmedRows{rows: &recordRows{data}},
+		total:  len(data),
+		close:  func() error { return nil },
+		sparse: true,
+	}, nil
+}
+
+// cellRange is a block of cells with 1-based bounds; a last row or column
+// of 0 leaves the range open towards the end of the sheet
+type cellRange struct {
+	firstCol, firstRow int
+	lastCol, lastRow   int
+}
+
+// parseCellRange parses A1-style ranges: B2:F100, a start cell such as B2,
+// or whole columns such as B:F
+func parseCellRange(reference string) (cellRange, error) {
+	invalid := fmt.Errorf("invalid cell range %q, expected e.g. A1:D20, A1 or A:D", reference)
+	first, last, hasLast := strings.Cut(strings.ToUpper(strings.TrimSpace(reference)), ":")
+
+	var bounds cellRange
+	var err error
+	if bounds.firstCol, bounds.firstRow, err = parseCellBound(first); err != nil {
+		return cellRange{}, invalid
+	}
+	if hasLast {
+		if bounds.lastCol, bounds.lastRow, err = parseCellBound(last); err != nil {
+			return cellRange{}, invalid
+		}
+		if (bounds.firstRow == 0) != (bounds.lastRow == 0) {
+			return cellRange{}, invalid
+		}
+	} else if bounds.firstRow == 0 {
+		return cellRange{}, invalid
+	}
+
+	if bounds.firstRow == 0 {
+		bounds.firstRow = 1
+	}
+	if (bounds.lastCol > 0 && bounds.lastCol < bounds.firstCol) || (bounds.lastRow > 0 && bounds.lastRow < bounds.firstRow) {
+		return cellRange{}, invalid
+	}
+	return bounds, nil
+}
+
+// parseCellBound parses a cell name such as B2, or a column name such as B
+// for which the row is 0
+func parseCellBound(name string) (col, row int, err error) {
+	if col, row, err = excelize.CellNameToCoordinates(name); err == nil {
+		return col, row, nil
+	}
+	col, err = excelize.ColumnNameToNumber(name)
+	return col, 0, err
+}
+
+// cells returns the cells of a row within the range's columns. Rows of a
+// closed range are padded to its full width.
+func (r cellRange) cells(row []string) []string {
+	start := r.firstCol - 1
+	end := len(row)
+	if r.lastCol > 0 {
+		end = r.lastCol
+	}
+	if end < start {
+		end = start
+	}
+
+	cells := make([]string, end-start)
+	for i := range cells {
+		if start+i < len(row) {
+			cells[i] = row[start+i]
+		}
+	}
+	return cells
+}
+
+// trimmedRows leaves out the empty rows at the end of a sheet, which often
+// only hold formatting. Empty rows followed by values are kept.
+type trimmedRows struct {
+	rows rowReader
+	held [][]string // rows read ahead, all empty but the last
+}
+
+func (t *trimmedRows) Read() ([]string, error) {
+	if len(t.held) > 0 {
+		row := t.held[0]
+		t.held = t.held[1:]
+		return row, nil
+	}
+
+	for {
+		row, err := t.rows.Read()
+		if err != nil {
+			return nil, err
+		}
+		if !isEmptyRow(row) && len(t.held) == 0 {
+			return row, nil
+		}
+		t.held = append(t.held, row)
+		if !isEmptyRow(row) {
+			return t.Read()
+		}
+	}
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+﻿package ui
+
+import (
+	"fmt"
+	"graph-viewer/xls"
+	"strings"
+)
+
+// xlsWorkbook reads Excel 97-2003 files, which are loaded whole
+type xlsWorkbook struct {
+	book *xls.Workbook
+}
+
+func (w xlsWorkbook) Close() error {
+	return nil
+}
+
+// contents returns the worksheets; XLS files are not searched for tables
+func (w xlsWorkbook) contents() WorkbookContents {
+	return WorkbookContents{
+		Sheets: w.book.SheetNames(),
+		Active: w.book.Sheets[w.book.Active].Name,
+	}
+}
+
+// open returns the rows selected by options, which are already in memory
+func (w xlsWorkbook) open(options ReadOptions) (rowSource, error) {
+	if options.Table != "" {
+		return rowSource{}, fmt.Errorf("XLS files have no tables, select a sheet and cell range instead")
+	}
+
+	sheet := w.book.Sheets[w.book.Active]
+	if options.Sheet != "" {
+		var ok bool
+		if sheet, ok = w.book.Sheet(options.Sheet); !ok {
+			return rowSource{}, fmt.Errorf("workbook has no sheet %q, sheets: %s", options.Sheet, strings.Join(w.book.SheetNames(), ", "))
+		}
+	}
+
+	return sheetRows(sheet.Rows, options.Range)
+}
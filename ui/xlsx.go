@@ -0,0 +1,169 @@
+﻿package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxWorkbook reads XLSX files through excelize
+type xlsxWorkbook struct {
+	file *excelize.File
+}
+
+func (w xlsxWorkbook) Close() error {
+	return w.file.Close()
+}
+
+// contents returns the sheets, tables and named ranges of the workbook
+func (w xlsxWorkbook) contents() WorkbookContents {
+	file := w.file
+	contents := WorkbookContents{
+		Sheets: file.GetSheetList(),
+		Active: file.GetSheetName(file.GetActiveSheetIndex()),
+	}
+	for _, sheet := range contents.Sheets {
+		tables, err := file.GetTables(sheet)
+		if err != nil {
+			continue
+		}
+		for _, table := range tables {
+			contents.Tables = append(contents.Tables, table.Name)
+		}
+	}
+	for _, name := range file.GetDefinedName() {
+		if _, _, err := splitReference(name.RefersTo); err == nil {
+			contents.Tables = append(contents.Tables, name.Name)
+		}
+	}
+	return contents
+}
+
+// open streams the rows selected by options from the sheet, so only the
+// rows kept are held in memory
+func (w xlsxWorkbook) open(options ReadOptions) (rowSource, error) {
+	sheet, bounds, err := xlsxSelection(w.file, options)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	rows, err := w.file.Rows(sheet)
+	if err != nil {
+		return rowSource{}, err
+	}
+
+	// The sheet dimension tells how many rows to expect for progress reports
+	total := 0
+	if dimension, err := w.file.GetSheetDimension(sheet); err == nil {
+		if used, err := parseCellRange(dimension); err == nil && used.lastRow > 0 {
+			last := used.lastRow
+			if bounds.lastRow > 0 {
+				last = min(last, bounds.lastRow)
+			}
+			total = max(last-bounds.firstRow+1, 0)
+		}
+	}
+
+	return rowSource{
+		rows:   &trimmedRows{rows: &xlsxRows{rows: rows, bounds: bounds}},
+		total:  total,
+		close:  rows.Close,
+		sparse: true,
+	}, nil
+}
+
+// xlsxRows reads the rows of a sheet within a cell range
+type xlsxRows struct {
+	rows   *excelize.Rows
+	bounds cellRange
+	row    int // number of the last row read
+}
+
+func (r *xlsxRows) Read() ([]string, error) {
+	for r.rows.Next() {
+		r.row++
+		if r.row < r.bounds.firstRow {
+			continue
+		}
+		if r.bounds.lastRow > 0 && r.row > r.bounds.lastRow {
+			break
+		}
+
+		cells, err := r.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		return r.bounds.cells(cells), nil
+	}
+	if err := r.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// xlsxSelection resolves options to a sheet and the cell range to read in it
+func xlsxSelection(file *excelize.File, options ReadOptions) (string, cellRange, error) {
+	if options.Table != "" {
+		if options.Range != "" {
+			return "", cellRange{}, fmt.Errorf("a cell range cannot be combined with table %s", options.Table)
+		}
+		return findTable(file, options.Table)
+	}
+
+	sheet := options.Sheet
+	if sheet == "" {
+		sheet = file.GetSheetName(file.GetActiveSheetIndex())
+	} else if index, err := file.GetSheetIndex(sheet); err != nil || index == -1 {
+		return "", cellRange{}, fmt.Errorf("workbook has no sheet %q, sheets: %s", sheet, strings.Join(file.GetSheetList(), ", "))
+	}
+
+	bounds := cellRange{firstRow: 1, firstCol: 1}
+	if options.Range != "" {
+		var err error
+		if bounds, err = parseCellRange(options.Range); err != nil {
+			return "", cellRange{}, err
+		}
+	}
+	return sheet, bounds, nil
+}
+
+// findTable looks up a table, then a defined name, by name
+func findTable(file *excelize.File, name string) (string, cellRange, error) {
+	for _, sheet := range file.GetSheetList() {
+		tables, err := file.GetTables(sheet)
+		if err != nil {
+			continue
+		}
+		for _, table := range tables {
+			if strings.EqualFold(table.Name, name) {
+				bounds, err := parseCellRange(table.Range)
+				return sheet, bounds, err
+			}
+		}
+	}
+
+	for _, definedName := range file.GetDefinedName() {
+		if strings.EqualFold(definedName.Name, name) {
+			sheet, reference, err := splitReference(definedName.RefersTo)
+			if err != nil {
+				return "", cellRange{}, fmt.Errorf("name %s: %w", name, err)
+			}
+			bounds, err := parseCellRange(reference)
+			return sheet, bounds, err
+		}
+	}
+	return "", cellRange{}, fmt.Errorf("workbook has no table or named range %q", name)
+}
+
+// splitReference splits a defined name's reference such as 'Q1 Sales'!$A$1:$D$20
+// into the sheet and the cell range
+func splitReference(refersTo string) (string, string, error) {
+	sheet, reference, ok := strings.Cut(strings.TrimPrefix(refersTo, "="), "!")
+	if !ok || strings.ContainsAny(reference, ",()!") {
+		return "", "", fmt.Errorf("%q is not a single cell range", refersTo)
+	}
+	sheet = strings.ReplaceAll(strings.Trim(sheet, "'"), "''", "'")
+	return sheet, strings.ReplaceAll(reference, "$", ""), nil
+}
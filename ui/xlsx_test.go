@@ -0,0 +1,171 @@
+﻿package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeWorkbook creates a workbook with a summary sheet, a data sheet holding
+// a table and a named range
+func writeWorkbook(t *testing.T) string {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+
+	file.SetSheetRow("Sheet1", "A1", &[]interface{}{"Summary"})
+	if _, err := file.NewSheet("Data"); err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range [][]interface{}{
+		{"Title", nil, nil},
+		{"Region", "Revenue", "Units"},
+		{"North", 10, 1},
+		{"South", 20, 2},
+		{"East", 30, 3},
+	} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		file.SetSheetRow("Data", cell, &row)
+	}
+	if err := file.AddTable("Data", &excelize.Table{Range: "A2:C5", Name: "Sales"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetDefinedName(&excelize.DefinedName{Name: "Revenue", RefersTo: "Data!$B$2:$B$4"}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := file.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadXLSXSelections(t *testing.T) {
+	path := writeWorkbook(t)
+
+	tests := []struct {
+		name    string
+		options ReadOptions
+		want    [][]string
+	}{
+		{"active sheet", ReadOptions{}, [][]string{{"Summary"}}},
+		{"table", ReadOptions{Table: "sales"}, [][]string{
+			{"Region", "Revenue", "Units"}, {"North", "10", "1"}, {"South", "20", "2"}, {"East", "30", "3"},
+		}},
+		{"defined name", ReadOptions{Table: "Revenue"}, [][]string{{"Revenue"}, {"10"}, {"20"}}},
+		{"range", ReadOptions{Sheet: "Data", Range: "A3:B4"}, [][]string{{"North", "10"}, {"South", "20"}}},
+		{"columns", ReadOptions{Sheet: "Data", Range: "B:C"}, [][]string{
+			{"", ""}, {"Revenue", "Units"}, {"10", "1"}, {"20", "2"}, {"30", "3"},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := readWorkbook(path, tt.options)
+		if err != nil {
+			t.Errorf("%s: readWorkbook failed: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: readWorkbook = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, options := range []ReadOptions{
+		{Sheet: "Missing"},
+		{Table: "Missing"},
+		{Sheet: "Data", Range: "C5:A1"},
+		{Table: "Sales", Range: "A1:B2"},
+	} {
+		if _, err := readWorkbook(path, options); err == nil {
+			t.Errorf("readWorkbook(%+v) succeeded, want an error", options)
+		}
+	}
+}
+
+func TestListWorkbook(t *testing.T) {
+	contents, err := ListWorkbook(writeWorkbook(t))
+	if err != nil {
+		t.Fatalf("ListWorkbook failed: %v", err)
+	}
+	if !reflect.DeepEqual(contents.Sheets, []string{"Sheet1", "Data"}) || contents.Active != "Sheet1" {
+		t.Errorf("sheets = %v, active %s", contents.Sheets, contents.Active)
+	}
+	if !reflect.DeepEqual(contents.Tables, []string{"Sales", "Revenue"}) {
+		t.Errorf("tables = %v, want [Sales Revenue]", contents.Tables)
+	}
+}
+
+func TestReadDataStreamsXLSX(t *testing.T) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	const rows = 3000
+	file.SetSheetRow("Sheet1", "A1", &[]interface{}{"Region", "Value"})
+	for i := 1; i <= rows; i++ {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		file.SetSheetRow("Sheet1", cell, &[]interface{}{[]string{"North", "South"}[i%2], i})
+	}
+	// Formatted cells without values at the end of the sheet
+	style, _ := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	cell, _ := excelize.CoordinatesToCellName(1, rows+5)
+	if err := file.SetCellStyle("Sheet1", cell, cell, style); err != nil {
+		t.Fatal(err)
+	}
+	// Excel records the used range, which tells how many rows to expect
+	if err := file.SetSheetDimension("Sheet1", "A1:"+cell); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "large.xlsx")
+	if err := file.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var last ReadProgress
+	data, err := readData(path, ReadOptions{Progress: func(p ReadProgress) { last = p }})
+	if err != nil {
+		t.Fatalf("reading the sheet failed: %v", err)
+	}
+	if data.Len() != rows {
+		t.Errorf("read %d rows, want %d without the empty formatted rows", data.Len(), rows)
+	}
+	if last.Rows != rows || last.TotalRows != rows+4 {
+		t.Errorf("final progress = %+v, want %d of %d rows in the used range", last, rows, rows+4)
+	}
+
+	data, err = readData(path, ReadOptions{Sample: 50})
+	if err != nil {
+		t.Fatalf("sampling the sheet failed: %v", err)
+	}
+	if data.Len() != 50 || data.SourceRows[49] < rows/2 {
+		t.Errorf("sample has %d rows ending at row %d", data.Len(), data.SourceRows[data.Len()-1])
+	}
+
+	data, err = readData(path, ReadOptions{Limit: 5})
+	if err != nil || data.Len() != 5 {
+		t.Errorf("reading the first rows returned %v, %v", data, err)
+	}
+}
+
+func TestTrimmedRowsKeepsInnerEmptyRows(t *testing.T) {
+	rows := &trimmedRows{rows: &recordRows{[][]string{{"a"}, {""}, {"b"}, {""}, {" "}}}}
+	got, err := readRows(staticWorkbook{rows}, ReadOptions{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"a"}, {""}, {"b"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+// staticWorkbook is a workbook reading fixed rows
+type staticWorkbook struct {
+	rows rowReader
+}
+
+func (w staticWorkbook) contents() WorkbookContents { return WorkbookContents{} }
+func (w staticWorkbook) Close() error               { return nil }
+func (w staticWorkbook) open(ReadOptions) (rowSource, error) {
+	return rowSource{rows: w.rows, close: w.Close}, nil
+}
@@ -0,0 +1,88 @@
+package xls
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Built-in number formats that show dates or times, and percentages
+var (
+	builtinDateFormats    = map[uint16]bool{14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true, 45: true, 46: true, 47: true}
+	builtinPercentFormats = map[uint16]bool{9: true, 10: true}
+)
+
+// formatNumber returns the text of a numeric cell with cell format xf.
+// Dates become ISO 8601 and percentages get a percent sign; other numbers
+// are written in full without grouping, to the 15 digits Excel shows.
+func (g *globals) formatNumber(xf uint16, value float64) string {
+	var format uint16
+	if int(xf) < len(g.xfFormats) {
+		format = g.xfFormats[xf]
+	}
+	pattern := g.formats[format]
+
+	switch {
+	case builtinDateFormats[format] || isDatePattern(pattern):
+		return g.formatDate(value)
+	case builtinPercentFormats[format] || strings.Contains(pattern, "%"):
+		return formatFloat(value*100) + "%"
+	default:
+		return formatFloat(value)
+	}
+}
+
+// formatFloat writes a number in full, rounded to the 15 significant digits
+// Excel shows, which drops the binary error of e.g. 0.07 * 100
+func formatFloat(value float64) string {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'g', 15, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// formatDate converts a serial date to ISO 8601, leaving out the time of
+// day when it is midnight
+func (g *globals) formatDate(serial float64) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if g.date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days, fraction := math.Modf(serial)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(math.Round(fraction*86400)) * time.Second)
+
+	switch {
+	case days == 0 && !g.date1904:
+		return t.Format("15:04:05") // a time without a date
+	case fraction == 0:
+		return t.Format("2006-01-02")
+	default:
+		return t.Format("2006-01-02 15:04:05")
+	}
+}
+
+// isDatePattern reports whether a custom number format shows a date or time,
+// ignoring quoted text, escaped characters and [color] or [$-locale] codes
+func isDatePattern(pattern string) bool {
+	inQuotes, inBrackets, escaped := false, false, false
+	for _, r := range strings.ToLower(pattern) {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes:
+			inQuotes = r != '"'
+		case inBrackets:
+			if r == ']' {
+				inBrackets = false
+			}
+		case r == '"':
+			inQuotes = true
+		case r == '[':
+			inBrackets = true
+		case r == '\\' || r == '_' || r == '*':
+			escaped = true
+		case strings.ContainsRune("ymdhs", r):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package xls
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf16"
+)
+
+// Record types read by this package
+const (
+	recordFormula    = 0x0006
+	recordEOF        = 0x000A
+	recordDateMode   = 0x0022
+	recordFilePass   = 0x002F
+	recordContinue   = 0x003C
+	recordWindow1    = 0x003D
+	recordBoundSheet = 0x0085
+	recordMulRK      = 0x00BD
+	recordRString    = 0x00D6
+	recordXF         = 0x00E0
+	recordSST        = 0x00FC
+	recordLabelSST   = 0x00FD
+	recordNumber     = 0x0203
+	recordLabel      = 0x0204
+	recordBoolErr    = 0x0205
+	recordString     = 0x0207
+	recordRK         = 0x027E
+	recordFormat     = 0x041E
+	recordBOF        = 0x0809
+
+	biff8Version = 0x0600
+)
+
+// record is a single BIFF record
+type record struct {
+	kind uint16
+	data []byte
+}
+
+// recordReader walks the records of a substream
+type recordReader struct {
+	stream []byte
+	pos    int
+}
+
+func newRecordReader(stream []byte) *recordReader {
+	return &recordReader{stream: stream}
+}
+
+// next returns the next record, false at the end of the stream
+func (r *recordReader) next() (record, bool) {
+	if r.pos+4 > len(r.stream) {
+		return record{}, false
+	}
+	kind := le16(r.stream[r.pos:])
+	size := int(le16(r.stream[r.pos+2:]))
+	start := r.pos + 4
+	if start+size > len(r.stream) {
+		return record{}, false
+	}
+	r.pos = start + size
+	return record{kind: kind, data: r.stream[start : start+size]}, true
+}
+
+// continues returns the data of the CONTINUE records following the current
+// record, consuming them
+func (r *recordReader) continues() [][]byte {
+	var parts [][]byte
+	for {
+		saved := r.pos
+		rec, ok := r.next()
+		if !ok || rec.kind != recordContinue {
+			r.pos = saved
+			return parts
+		}
+		parts = append(parts, rec.data)
+	}
+}
+
+// readShortString reads a ShortXLUnicodeString: an 8-bit length, option
+// flags and the characters. It returns the string and the bytes consumed.
+func readShortString(data []byte) (string, int, error) {
+	if len(data) < 2 {
+		return "", 0, fmt.Errorf("truncated string")
+	}
+	text, n, err := readChars(data[2:], int(data[0]), data[1]&0x01 != 0)
+	return text, 2 + n, err
+}
+
+// readString reads an XLUnicodeString with a 16-bit length
+func readString(data []byte) (string, int, error) {
+	if len(data) < 3 {
+		return "", 0, fmt.Errorf("truncated string")
+	}
+	text, n, err := readChars(data[3:], int(le16(data)), data[2]&0x01 != 0)
+	return text, 3 + n, err
+}
+
+// readChars decodes count characters stored as UTF-16 or, when not
+// wide, as the low bytes of UTF-16 code units
+func readChars(data []byte, count int, wide bool) (string, int, error) {
+	size := count
+	if wide {
+		size *= 2
+	}
+	if len(data) < size {
+		return "", 0, fmt.Errorf("truncated string")
+	}
+	units := make([]uint16, count)
+	for i := range units {
+		if wide {
+			units[i] = le16(data[2*i:])
+		} else {
+			units[i] = uint16(data[i])
+		}
+	}
+	return string(utf16.Decode(units)), size, nil
+}
+
+// sstReader reads the shared string table, which may be split over the SST
+// record and any number of CONTINUE records
+type sstReader struct {
+	parts [][]byte
+	part  int
+	pos   int
+}
+
+// parseSST decodes the shared strings of an SST record and its continuations
+func parseSST(data []byte, continues [][]byte) ([]string, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("invalid shared string table")
+	}
+	r := &sstReader{parts: append([][]byte{data[8:]}, continues...)}
+	count := int(le32(data[4:]))
+
+	sst := make([]string, 0, min(count, 1<<20))
+	for i := 0; i < count; i++ {
+		text, err := r.readString()
+		if err != nil {
+			return nil, fmt.Errorf("shared string %d: %w", i, err)
+		}
+		sst = append(sst, text)
+	}
+	return sst, nil
+}
+
+// available returns the bytes left in the current part, moving to the next
+// part when the current one is used up
+func (r *sstReader) available() []byte {
+	for r.part < len(r.parts) && r.pos >= len(r.parts[r.part]) {
+		r.part++
+		r.pos = 0
+	}
+	if r.part == len(r.parts) {
+		return nil
+	}
+	return r.parts[r.part][r.pos:]
+}
+
+func (r *sstReader) bytes(n int) ([]byte, error) {
+	data := r.available()
+	if len(data) < n {
+		return nil, fmt.Errorf("truncated shared string table")
+	}
+	r.pos += n
+	return data[:n], nil
+}
+
+// skip moves past n bytes that may span parts
+func (r *sstReader) skip(n int) error {
+	for n > 0 {
+		data := r.available()
+		if data == nil {
+			return fmt.Errorf("truncated shared string table")
+		}
+		step := min(n, len(data))
+		r.pos += step
+		n -= step
+	}
+	return nil
+}
+
+// readString reads an XLUnicodeRichExtendedString. When the characters
+// continue in the next part, that part starts with a new flags byte telling
+// whether the rest is stored wide.
+func (r *sstReader) readString() (string, error) {
+	header, err := r.bytes(3)
+	if err != nil {
+		return "", err
+	}
+	count, flags := int(le16(header)), header[2]
+	wide := flags&0x01 != 0
+
+	runs, extSize := 0, 0
+	if flags&0x08 != 0 {
+		b, err := r.bytes(2)
+		if err != nil {
+			return "", err
+		}
+		runs = int(le16(b))
+	}
+	if flags&0x04 != 0 {
+		b, err := r.bytes(4)
+		if err != nil {
+			return "", err
+		}
+		extSize = int(le32(b))
+	}
+
+	units := make([]uint16, 0, count)
+	part := r.part
+	for len(units) < count {
+		data := r.available()
+		if data == nil {
+			return "", fmt.Errorf("truncated shared string table")
+		}
+		if r.part != part {
+			// Characters continued in a new part start with a flags byte
+			part = r.part
+			wide = data[0]&0x01 != 0
+			r.pos++
+			if data = data[1:]; len(data) == 0 {
+				continue
+			}
+		}
+
+		width := 1
+		if wide {
+			width = 2
+		}
+		n := min(count-len(units), len(data)/width)
+		if n == 0 {
+			return "", fmt.Errorf("truncated shared string table")
+		}
+		for i := 0; i < n; i++ {
+			if wide {
+				units = append(units, le16(data[2*i:]))
+			} else {
+				units = append(units, uint16(data[i]))
+			}
+		}
+		r.pos += n * width
+	}
+
+	if err := r.skip(4*runs + extSize); err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// decodeRK decodes the compressed RK number format: a 30-bit integer or
+// the top 30 bits of a float, optionally divided by 100
+func decodeRK(rk uint32) float64 {
+	var value float64
+	if rk&0x02 != 0 {
+		value = float64(int32(rk) >> 2)
+	} else {
+		value = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		value /= 100
+	}
+	return value
+}
+
+func le16(b []byte) uint16 { return binary.LittleEndian.Uint16(b) }
+func le32(b []byte) uint32 { return binary.LittleEndian.Uint32(b) }
+func le64(b []byte) uint64 { return binary.LittleEndian.Uint64(b) }
+
+func float64frombits(bits uint64) float64 { return math.Float64frombits(bits) }
@@ -0,0 +1,364 @@
+// Package xls reads Excel 97-2003 workbooks stored in the BIFF8 format.
+//
+// Only cell values are read: each worksheet becomes rows of cell text, with
+// numbers formatted plainly and dates written as ISO 8601. Unlike the XLSX
+// values excelize returns, which follow each cell's display format, dates
+// are not written as shown, so they read the same whatever their format.
+package xls
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Workbook holds the worksheets of an XLS file
+type Workbook struct {
+	Sheets []*Sheet // worksheets in workbook order; chart and macro sheets are left out
+	Active int      // index into Sheets of the sheet shown when the file was saved
+}
+
+// Sheet is a worksheet with the text of its cells. Rows end at their last
+// non-empty cell and the rows after the last non-empty row are left out.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// ErrNotBIFF8 is returned for files that are not Excel 97-2003 workbooks,
+// such as older BIFF5 files or other compound documents
+var ErrNotBIFF8 = errors.New("not an Excel 97-2003 (BIFF8) workbook")
+
+// Open reads the workbook at path
+func Open(path string) (*Workbook, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads a workbook from a compound document
+func Read(r io.ReaderAt) (*Workbook, error) {
+	doc, err := mscfb.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotBIFF8, err)
+	}
+
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if entry.Name != "Workbook" && entry.Name != "Book" {
+			continue
+		}
+		if entry.Name == "Book" {
+			return nil, fmt.Errorf("%w: the file uses the Excel 5.0/95 format", ErrNotBIFF8)
+		}
+
+		stream, err := io.ReadAll(entry)
+		if err != nil {
+			return nil, err
+		}
+		return parseWorkbook(stream)
+	}
+	return nil, fmt.Errorf("%w: no Workbook stream", ErrNotBIFF8)
+}
+
+// Sheet returns the worksheet with the given name, ignoring case
+func (w *Workbook) Sheet(name string) (*Sheet, bool) {
+	for _, sheet := range w.Sheets {
+		if strings.EqualFold(sheet.Name, name) {
+			return sheet, true
+		}
+	}
+	return nil, false
+}
+
+// SheetNames returns the names of the worksheets in workbook order
+func (w *Workbook) SheetNames() []string {
+	names := make([]string, len(w.Sheets))
+	for i, sheet := range w.Sheets {
+		names[i] = sheet.Name
+	}
+	return names
+}
+
+// sheetEntry is a BOUNDSHEET record: where a sheet's substream starts
+type sheetEntry struct {
+	name      string
+	offset    uint32
+	worksheet bool
+}
+
+// globals holds what the workbook substream says about all sheets
+type globals struct {
+	sheets    []sheetEntry
+	strings   []string          // shared string table
+	formats   map[uint16]string // custom number formats by id
+	xfFormats []uint16          // number format id of each cell format (XF)
+	date1904  bool
+	activeTab int
+}
+
+// parseWorkbook reads the workbook globals and then each worksheet
+func parseWorkbook(stream []byte) (*Workbook, error) {
+	g, err := parseGlobals(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &Workbook{}
+	for i, entry := range g.sheets {
+		if !entry.worksheet {
+			continue
+		}
+		if i == g.activeTab {
+			book.Active = len(book.Sheets)
+		}
+		if int(entry.offset) >= len(stream) {
+			return nil, fmt.Errorf("sheet %s starts beyond the end of the file", entry.name)
+		}
+
+		rows, err := g.parseSheet(stream[entry.offset:])
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", entry.name, err)
+		}
+		book.Sheets = append(book.Sheets, &Sheet{Name: entry.name, Rows: rows})
+	}
+	if len(book.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook contains no worksheets")
+	}
+	return book, nil
+}
+
+// parseGlobals reads the records of the workbook globals substream
+func parseGlobals(stream []byte) (*globals, error) {
+	g := &globals{formats: make(map[uint16]string)}
+	records := newRecordReader(stream)
+
+	first, ok := records.next()
+	if !ok || first.kind != recordBOF || len(first.data) < 4 {
+		return nil, ErrNotBIFF8
+	}
+	if version := le16(first.data); version != biff8Version {
+		return nil, fmt.Errorf("%w: BIFF version %#x", ErrNotBIFF8, version)
+	}
+
+	for {
+		rec, ok := records.next()
+		if !ok {
+			return nil, fmt.Errorf("workbook globals are truncated")
+		}
+
+		switch rec.kind {
+		case recordEOF:
+			return g, nil
+		case recordFilePass:
+			return nil, fmt.Errorf("the workbook is password protected")
+		case recordBoundSheet:
+			if len(rec.data) < 8 {
+				return nil, fmt.Errorf("invalid sheet record")
+			}
+			name, _, err := readShortString(rec.data[6:])
+			if err != nil {
+				return nil, err
+			}
+			g.sheets = append(g.sheets, sheetEntry{
+				name:      name,
+				offset:    le32(rec.data),
+				worksheet: rec.data[5] == 0,
+			})
+		case recordSST:
+			var err error
+			if g.strings, err = parseSST(rec.data, records.continues()); err != nil {
+				return nil, err
+			}
+		case recordFormat:
+			if len(rec.data) < 2 {
+				continue
+			}
+			format, _, err := readString(rec.data[2:])
+			if err == nil {
+				g.formats[le16(rec.data)] = format
+			}
+		case recordXF:
+			if len(rec.data) >= 4 {
+				g.xfFormats = append(g.xfFormats, le16(rec.data[2:]))
+			}
+		case recordDateMode:
+			g.date1904 = len(rec.data) >= 2 && le16(rec.data) == 1
+		case recordWindow1:
+			if len(rec.data) >= 12 {
+				g.activeTab = int(le16(rec.data[10:]))
+			}
+		}
+	}
+}
+
+// parseSheet reads the cells of a worksheet substream
+func (g *globals) parseSheet(stream []byte) ([][]string, error) {
+	records := newRecordReader(stream)
+	if rec, ok := records.next(); !ok || rec.kind != recordBOF {
+		return nil, fmt.Errorf("missing sheet header")
+	}
+
+	cells := newCellGrid()
+	var formulaRow, formulaCol int = -1, -1
+	for {
+		rec, ok := records.next()
+		if !ok {
+			// Tolerate a missing EOF record at the end of the file
+			return cells.rows(), nil
+		}
+		data := rec.data
+
+		switch rec.kind {
+		case recordEOF:
+			return cells.rows(), nil
+		case recordLabelSST:
+			if len(data) < 10 {
+				continue
+			}
+			index := le32(data[6:])
+			if int(index) >= len(g.strings) {
+				return nil, fmt.Errorf("cell refers to missing shared string %d", index)
+			}
+			cells.set(data, g.strings[index])
+		case recordLabel, recordRString:
+			if len(data) < 8 {
+				continue
+			}
+			text, _, err := readString(data[6:])
+			if err != nil {
+				return nil, err
+			}
+			cells.set(data, text)
+		case recordNumber:
+			if len(data) < 14 {
+				continue
+			}
+			cells.set(data, g.formatNumber(le16(data[4:]), float64frombits(le64(data[6:]))))
+		case recordRK:
+			if len(data) < 10 {
+				continue
+			}
+			cells.set(data, g.formatNumber(le16(data[4:]), decodeRK(le32(data[6:]))))
+		case recordMulRK:
+			// row, first column, (format, rk) per column, last column
+			if len(data) < 6 {
+				continue
+			}
+			row, col := int(le16(data)), int(le16(data[2:]))
+			for pos := 4; pos+6 <= len(data)-2; pos += 6 {
+				cells.put(row, col, g.formatNumber(le16(data[pos:]), decodeRK(le32(data[pos+2:]))))
+				col++
+			}
+		case recordBoolErr:
+			if len(data) < 8 {
+				continue
+			}
+			cells.set(data, boolErrText(data[6], data[7] == 1))
+		case recordFormula:
+			if len(data) < 14 {
+				continue
+			}
+			formulaRow, formulaCol = -1, -1
+			result := data[6:14]
+			if le16(result[6:]) != 0xFFFF {
+				cells.set(data, g.formatNumber(le16(data[4:]), float64frombits(le64(result))))
+				continue
+			}
+			switch result[0] {
+			case 0: // string, held by the STRING record that follows
+				formulaRow, formulaCol = int(le16(data)), int(le16(data[2:]))
+			case 1:
+				cells.set(data, boolErrText(result[2], false))
+			case 2:
+				cells.set(data, boolErrText(result[2], true))
+			}
+		case recordString:
+			if formulaRow == -1 {
+				continue
+			}
+			text, _, err := readString(data)
+			if err != nil {
+				return nil, err
+			}
+			cells.put(formulaRow, formulaCol, text)
+			formulaRow, formulaCol = -1, -1
+		}
+	}
+}
+
+// boolErrText returns the text Excel shows for a boolean or error value
+func boolErrText(value byte, isError bool) string {
+	if !isError {
+		if value != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	switch value {
+	case 0x00:
+		return "#NULL!"
+	case 0x07:
+		return "#DIV/0!"
+	case 0x0F:
+		return "#VALUE!"
+	case 0x17:
+		return "#REF!"
+	case 0x1D:
+		return "#NAME?"
+	case 0x24:
+		return "#NUM!"
+	default:
+		return "#N/A"
+	}
+}
+
+// cellGrid collects cell text by position
+type cellGrid struct {
+	cells   map[[2]int]string
+	lastRow int
+	lastCol map[int]int
+}
+
+func newCellGrid() *cellGrid {
+	return &cellGrid{cells: make(map[[2]int]string), lastRow: -1, lastCol: make(map[int]int)}
+}
+
+// set stores text at the row and column at the start of a cell record
+func (c *cellGrid) set(record []byte, text string) {
+	c.put(int(le16(record)), int(le16(record[2:])), text)
+}
+
+func (c *cellGrid) put(row, col int, text string) {
+	if text == "" {
+		return
+	}
+	c.cells[[2]int{row, col}] = text
+	c.lastRow = max(c.lastRow, row)
+	if last, ok := c.lastCol[row]; !ok || col > last {
+		c.lastCol[row] = col
+	}
+}
+
+// rows returns the cell text row by row
+func (c *cellGrid) rows() [][]string {
+	rows := make([][]string, c.lastRow+1)
+	for row := range rows {
+		last, ok := c.lastCol[row]
+		if !ok {
+			rows[row] = []string{}
+			continue
+		}
+		rows[row] = make([]string, last+1)
+		for col := range rows[row] {
+			rows[row][col] = c.cells[[2]int{row, col}]
+		}
+	}
+	return rows
+}
@@ -0,0 +1,260 @@
+package xls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// biff builds a BIFF8 stream record by record
+type biff struct {
+	bytes.Buffer
+}
+
+func (b *biff) record(kind uint16, parts ...interface{}) {
+	var data bytes.Buffer
+	for _, part := range parts {
+		binary.Write(&data, binary.LittleEndian, part)
+	}
+	binary.Write(&b.Buffer, binary.LittleEndian, [2]uint16{kind, uint16(data.Len())})
+	b.Write(data.Bytes())
+}
+
+// cell writes the row, column and format shared by all cell records
+func cell(row, col, xf uint16) [3]uint16 {
+	return [3]uint16{row, col, xf}
+}
+
+// unicode encodes s as the flags byte and UTF-16 characters of a string
+func unicode(s string) []byte {
+	var data bytes.Buffer
+	data.WriteByte(0x01)
+	binary.Write(&data, binary.LittleEndian, utf16.Encode([]rune(s)))
+	return data.Bytes()
+}
+
+// testWorkbook returns a workbook stream with a worksheet of mixed cells,
+// a chart sheet and a second worksheet that is active
+func testWorkbook() []byte {
+	globals, sheet1, sheet2 := &biff{}, &biff{}, &biff{}
+	bof := func(b *biff, kind uint16) { b.record(recordBOF, uint16(biff8Version), kind, [12]byte{}) }
+
+	// First worksheet: header, shared strings, RK and MULRK numbers, a date,
+	// percentages, a formula with a string result, and a boolean
+	bof(sheet1, 0x10)
+	sheet1.record(recordLabelSST, cell(0, 0, 0), uint32(0))
+	sheet1.record(recordLabelSST, cell(0, 1, 0), uint32(1))
+	sheet1.record(recordLabel, cell(0, 2, 0), uint16(5), []byte{0}, []byte("Share"))
+	sheet1.record(recordLabelSST, cell(1, 0, 0), uint32(2))
+	sheet1.record(recordMulRK, [2]uint16{1, 1}, uint16(1), uint32(45322<<2|0x02), uint16(2), uint32(125<<2|0x03), uint16(2))
+	sheet1.record(recordFormula, cell(2, 0, 0), [8]byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, uint16(0), uint32(0), uint16(0))
+	sheet1.record(recordString, uint16(3), unicode("Sü∂"))
+	sheet1.record(recordNumber, cell(2, 1, 1), math.Float64bits(45323.5))
+	sheet1.record(recordRK, cell(2, 2, 0), uint32(1234<<2|0x03))
+	sheet1.record(recordBoolErr, cell(3, 0, 0), []byte{1, 0})
+	sheet1.record(recordNumber, cell(3, 1, 2), math.Float64bits(0.07))
+	sheet1.record(recordEOF)
+
+	bof(sheet2, 0x10)
+	sheet2.record(recordNumber, cell(0, 0, 0), math.Float64bits(1.5))
+	sheet2.record(recordEOF)
+
+	// The shared string table is split so the second string continues in
+	// a CONTINUE record, switching from 8-bit to UTF-16 characters
+	sst := []byte{}
+	sst = binary.LittleEndian.AppendUint32(sst, 3)
+	sst = binary.LittleEndian.AppendUint32(sst, 3)
+	sst = append(sst, 6, 0, 0)
+	sst = append(sst, "Region"...)
+	sst = append(sst, 7, 0, 0)
+	sst = append(sst, "Rev"...)
+	cont := []byte{0x01}
+	cont = append(cont, binary.LittleEndian.AppendUint16(nil, 'e')...)
+	cont = append(cont, binary.LittleEndian.AppendUint16(nil, 'n')...)
+	cont = append(cont, binary.LittleEndian.AppendUint16(nil, 'u')...)
+	cont = append(cont, binary.LittleEndian.AppendUint16(nil, 'e')...)
+	cont = append(cont, 5, 0, 0)
+	cont = append(cont, "North"...)
+
+	sheetRecord := func(offset uint32, kind byte, name string) []interface{} {
+		return []interface{}{offset, byte(0), kind, byte(len(name)), byte(0), []byte(name)}
+	}
+
+	// Globals are written twice: once to learn their size, once with the
+	// sheet offsets
+	build := func(offsets [3]uint32) []byte {
+		g := &biff{}
+		bof(g, 0x05)
+		g.record(recordWindow1, [5]uint16{}, uint16(2), [3]uint16{})
+		g.record(recordFormat, uint16(164), uint16(10), []byte{0}, []byte("yyyy-mm-dd"))
+		g.record(recordXF, uint16(0), uint16(0), [16]byte{})
+		g.record(recordXF, uint16(0), uint16(164), [16]byte{})
+		g.record(recordXF, uint16(0), uint16(9), [16]byte{})
+		g.record(recordBoundSheet, sheetRecord(offsets[0], 0, "Data")...)
+		g.record(recordBoundSheet, sheetRecord(offsets[1], 2, "Chart")...)
+		g.record(recordBoundSheet, sheetRecord(offsets[2], 0, "Notes")...)
+		g.record(recordSST, sst)
+		g.record(recordContinue, cont)
+		g.record(recordEOF)
+		return g.Bytes()
+	}
+	size := uint32(len(build([3]uint32{})))
+	globals.Write(build([3]uint32{size, size, size + uint32(sheet1.Len())}))
+
+	globals.Write(sheet1.Bytes())
+	globals.Write(sheet2.Bytes())
+	return globals.Bytes()
+}
+
+// compoundFile wraps a stream named Workbook in a minimal compound document.
+// Streams are padded to 4096 bytes so they are stored in regular sectors.
+func compoundFile(stream []byte) []byte {
+	const sector = 512
+	const endOfChain, freeSect, fatSect, noStream = 0xFFFFFFFE, 0xFFFFFFFF, 0xFFFFFFFD, 0xFFFFFFFF
+
+	size := len(stream)
+	padded := append(append([]byte(nil), stream...), make([]byte, max(4096, size)-size+(sector-max(4096, size)%sector)%sector)...)
+	streamSectors := len(padded) / sector
+
+	header := make([]byte, sector)
+	copy(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	le := binary.LittleEndian
+	le.PutUint16(header[24:], 0x3E)
+	le.PutUint16(header[26:], 3)
+	le.PutUint16(header[28:], 0xFFFE)
+	le.PutUint16(header[30:], 9)
+	le.PutUint16(header[32:], 6)
+	le.PutUint32(header[44:], 1)          // FAT sectors
+	le.PutUint32(header[48:], 1)          // first directory sector
+	le.PutUint32(header[56:], 4096)       // mini stream cutoff
+	le.PutUint32(header[60:], endOfChain) // mini FAT
+	le.PutUint32(header[68:], endOfChain) // DIFAT
+	for i := 76; i < sector; i += 4 {
+		le.PutUint32(header[i:], freeSect)
+	}
+	le.PutUint32(header[76:], 0) // the FAT is sector 0
+
+	fat := make([]byte, sector)
+	for i := 0; i < sector/4; i++ {
+		le.PutUint32(fat[4*i:], freeSect)
+	}
+	le.PutUint32(fat[0:], fatSect)
+	le.PutUint32(fat[4:], endOfChain)
+	for i := 0; i < streamSectors; i++ {
+		next := uint32(i + 3)
+		if i == streamSectors-1 {
+			next = endOfChain
+		}
+		le.PutUint32(fat[4*(i+2):], next)
+	}
+
+	entry := func(name string, kind byte, child, start uint32, size uint64) []byte {
+		e := make([]byte, 128)
+		units := utf16.Encode([]rune(name))
+		for i, u := range units {
+			le.PutUint16(e[2*i:], u)
+		}
+		le.PutUint16(e[64:], uint16(2*len(units)+2))
+		e[66], e[67] = kind, 1
+		le.PutUint32(e[68:], noStream)
+		le.PutUint32(e[72:], noStream)
+		le.PutUint32(e[76:], child)
+		le.PutUint32(e[116:], start)
+		le.PutUint64(e[120:], size)
+		return e
+	}
+	directory := append(entry("Root Entry", 5, 1, endOfChain, 0), entry("Workbook", 2, noStream, 2, uint64(len(padded)))...)
+	directory = append(directory, make([]byte, sector-len(directory))...)
+
+	return bytes.Join([][]byte{header, fat, directory, padded}, nil)
+}
+
+func TestRead(t *testing.T) {
+	book, err := Read(bytes.NewReader(compoundFile(testWorkbook())))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if got := book.SheetNames(); !reflect.DeepEqual(got, []string{"Data", "Notes"}) {
+		t.Errorf("sheets = %v, want [Data Notes]", got)
+	}
+	if book.Active != 1 {
+		t.Errorf("active sheet = %d, want 1", book.Active)
+	}
+
+	data, ok := book.Sheet("data")
+	if !ok {
+		t.Fatal("sheet Data not found")
+	}
+	want := [][]string{
+		{"Region", "Revenue", "Share"},
+		{"North", "2024-01-31", "125%"},
+		{"Sü∂", "2024-02-01 12:00:00", "12.34"},
+		{"TRUE", "7%"},
+	}
+	if !reflect.DeepEqual(data.Rows, want) {
+		t.Errorf("rows = %q, want %q", data.Rows, want)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	g := &globals{xfFormats: []uint16{0, 9}}
+	tests := []struct {
+		xf    uint16
+		value float64
+		want  string
+	}{
+		{1, 0.07, "7%"},
+		{1, 0.29, "29%"},
+		{1, 0.57, "57%"},
+		{1, 1.1, "110%"},
+		{1, 0.125, "12.5%"},
+		{0, 0.1 + 0.2, "0.3"},
+		{0, 2500000, "2500000"},
+		{0, 1.5, "1.5"},
+	}
+	for _, tt := range tests {
+		if got := g.formatNumber(tt.xf, tt.value); got != tt.want {
+			t.Errorf("formatNumber(%d, %v) = %q, want %q", tt.xf, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("Region,Revenue\n"))); err == nil {
+		t.Errorf("Read accepted a CSV file")
+	}
+}
+
+func TestDecodeRK(t *testing.T) {
+	tests := map[uint32]float64{
+		1234<<2 | 0x02:    1234,
+		1234<<2 | 0x03:    12.34,
+		0xFFFFFFFE:        -1,
+		0x3FF00000:        1,
+		0x3FF00000 | 0x01: 0.01,
+	}
+	for rk, want := range tests {
+		if got := decodeRK(rk); got != want {
+			t.Errorf("decodeRK(%#x) = %v, want %v", rk, got, want)
+		}
+	}
+}
+
+func TestIsDatePattern(t *testing.T) {
+	for pattern, want := range map[string]bool{
+		"yyyy-mm-dd":        true,
+		"[$-409]h:mm AM/PM": true,
+		"0.00%":             false,
+		`#,##0 "days"`:      false,
+		"[Red]#,##0":        false,
+		"General":           false,
+	} {
+		if got := isDatePattern(pattern); got != want {
+			t.Errorf("isDatePattern(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}